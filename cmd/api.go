@@ -5,14 +5,38 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/hasanbakirci/doc-system/cmd/listener"
+	"github.com/hasanbakirci/doc-system/internal/activity"
+	"github.com/hasanbakirci/doc-system/internal/apikey"
+	"github.com/hasanbakirci/doc-system/internal/audit"
 	"github.com/hasanbakirci/doc-system/internal/auth"
 	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/deadletter"
 	"github.com/hasanbakirci/doc-system/internal/document"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/internal/group"
+	"github.com/hasanbakirci/doc-system/internal/invitation"
+	"github.com/hasanbakirci/doc-system/internal/notification"
+	"github.com/hasanbakirci/doc-system/internal/organization"
+	"github.com/hasanbakirci/doc-system/internal/outbox"
+	"github.com/hasanbakirci/doc-system/internal/privacy"
+	"github.com/hasanbakirci/doc-system/internal/realtime"
+	"github.com/hasanbakirci/doc-system/internal/session"
+	"github.com/hasanbakirci/doc-system/internal/webhook"
 	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/hasanbakirci/doc-system/pkg/graceful"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mail"
 	"github.com/hasanbakirci/doc-system/pkg/middleware"
 	"github.com/hasanbakirci/doc-system/pkg/redisClient"
 	"github.com/labstack/echo/v4"
@@ -47,9 +71,14 @@ func init() {
 		panic(err)
 	}
 	apiCmd.Run = func(cmd *cobra.Command, args []string) {
+		// SIGINT and SIGTERM stop the in-process listener before the server
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
 		instance := echo.New()
 
-		instance.Use(middleware.RecoveryMiddlewareFunc, middleware.LoggingMiddlewareFunc)
+		instance.HTTPErrorHandler = errorHandler.HTTPErrorHandler
+		instance.Use(middleware.RequestIdMiddlewareFunc, middleware.RecoveryMiddlewareFunc, middleware.LoggingMiddlewareFunc)
 
 		// db, err := mongoClient.ConnectDb(ApiConfig.MongoSettings)
 		// if err != nil {
@@ -63,24 +92,159 @@ func init() {
 		if err != nil {
 			fmt.Println("Elastic connection error")
 		}
+		// events are stored in the outbox and delivered by the relay of the consumer command
+		//outboxRepository := outbox.NewOutboxRepository(db)
+		outboxRepository := outbox.NewElasticRepository(elastic)
+		publisher := outbox.NewPublisher(outboxRepository, "doc-system/api")
+		// waitListener lets the in-process listener finish its events on shutdown
+		waitListener := func() {}
+		// background tracks the other loops that stop with the signal context
+		var background sync.WaitGroup
+		if ApiConfig.EventSettings.InProcessConsumer {
+			bus, err := events.NewBus(*ApiConfig, redis, "doc-system/api")
+			if err != nil {
+				panic(err)
+			}
+			defer bus.Close()
+			l := listener.NewListener(*ApiConfig, redis, bus)
+			l.Start(ctx)
+			waitListener = l.Wait
+		}
+		authenticator := middleware.NewAuthenticator(ApiConfig.JwtSettings.SecretKey)
 		// document
-		//documentRepository := document.NewDocumentRepository(db)
-		documentRepository := document.NewElasticRepository(elastic)
-		documentService := document.NewDocumentService(documentRepository, redis)
+		//documentRepository := document.NewDocumentRepository(db, ApiConfig.TenantSettings)
+		documentRepository := document.NewElasticRepository(elastic, ApiConfig.TenantSettings)
+		documentService := document.NewDocumentService(documentRepository, publisher)
 		documentHandler := document.NewDocumentHandler(documentService)
-		document.RegisterDocumentHandlers(instance, documentHandler, ApiConfig.JwtSettings.SecretKey)
+		document.RegisterDocumentHandlers(instance, documentHandler, authenticator)
+		mailSender, err := mail.NewSender(ApiConfig.MailSettings)
+		if err != nil {
+			panic(err)
+		}
+		// audit
+		//auditRepository := audit.NewAuditRepository(db)
+		auditRepository := audit.NewElasticRepository(elastic)
+		auditService := audit.NewAuditService(auditRepository)
+		authenticator.UseImpersonationAuditor(auditService)
+		// sessions
+		sessionRepository := session.NewRedisRepository(redis)
+		sessionService := session.NewSessionService(sessionRepository, ApiConfig.JwtSettings)
+		sessionHandler := session.NewSessionHandler(sessionService)
+		session.RegisterSessionHandlers(instance, sessionHandler, authenticator)
+		authenticator.UseSessions(sessionService)
 		// auth
-		//authRepository := auth.NewAuthRepository(db)
-		authRepository := auth.NewElasticRepository(elastic)
-		authService := auth.NewAuthService(authRepository, *ApiConfig)
+		//authRepository := auth.NewAuthRepository(db, ApiConfig.TenantSettings)
+		authRepository := auth.NewElasticRepository(elastic, ApiConfig.TenantSettings)
+		authService := auth.NewAuthService(authRepository, redis, auditService, sessionService, mailSender, publisher, *ApiConfig)
 		authHandler := auth.NewUserHandler(authService)
-		auth.RegisterUserHandlers(instance, authHandler)
+		auth.RegisterUserHandlers(instance, authHandler, authenticator)
+		authenticator.UseAccounts(authService)
+		if ApiConfig.LdapSettings.Enabled {
+			ldapSync := auth.NewLdapSync(authRepository, ApiConfig.LdapSettings)
+			background.Add(1)
+			go func() {
+				defer background.Done()
+				ldapSync.Run(ctx)
+			}()
+		}
+		// api keys
+		//apiKeyRepository := apikey.NewApiKeyRepository(db)
+		apiKeyRepository := apikey.NewElasticRepository(elastic)
+		apiKeyService := apikey.NewApiKeyService(apiKeyRepository, authRepository, ApiConfig.ApiKeySettings)
+		apiKeyHandler := apikey.NewApiKeyHandler(apiKeyService)
+		apikey.RegisterApiKeyHandlers(instance, apiKeyHandler, authenticator)
+		authenticator.UseApiKeys(apiKeyService)
+		// groups
+		//groupRepository := group.NewGroupRepository(db)
+		groupRepository := group.NewElasticRepository(elastic)
+		groupService := group.NewGroupService(groupRepository, authRepository, redis)
+		groupHandler := group.NewGroupHandler(groupService)
+		group.RegisterGroupHandlers(instance, groupHandler, authenticator)
+		// invitations
+		//invitationRepository := invitation.NewInvitationRepository(db)
+		invitationRepository := invitation.NewElasticRepository(elastic)
+		invitationService := invitation.NewInvitationService(invitationRepository, authRepository, authService, groupService,
+			auditService, mailSender, ApiConfig.AccountSettings)
+		invitationHandler := invitation.NewInvitationHandler(invitationService)
+		invitation.RegisterInvitationHandlers(instance, invitationHandler, authenticator)
+		// activity
+		//activityRepository := activity.NewActivityRepository(db, ApiConfig.TenantSettings)
+		activityRepository := activity.NewElasticRepository(elastic, ApiConfig.TenantSettings)
+		activityService := activity.NewActivityService(activityRepository)
+		activityHandler := activity.NewActivityHandler(activityService)
+		activity.RegisterActivityHandlers(instance, activityHandler, authenticator)
+		// dead letters
+		deadLetterRepository := deadletter.NewRedisRepository(redis, ApiConfig.ConsumerSettings.DeadLetterStream)
+		deadLetterService := deadletter.NewDeadLetterService(deadLetterRepository, publisher)
+		deadLetterHandler := deadletter.NewDeadLetterHandler(deadLetterService, ApiConfig.TenantSettings.SystemAdmins)
+		deadletter.RegisterDeadLetterHandlers(instance, deadLetterHandler, authenticator)
+		// notifications
+		//notificationRepository := notification.NewNotificationRepository(db)
+		//preferenceRepository := notification.NewPreferenceRepository(db)
+		//followRepository := notification.NewFollowRepository(db)
+		notificationRepository := notification.NewElasticRepository(elastic)
+		preferenceRepository := notification.NewElasticPreferenceRepository(elastic)
+		followRepository := notification.NewElasticFollowRepository(elastic)
+		notificationService := notification.NewNotificationService(notificationRepository, preferenceRepository, followRepository,
+			documentRepository, authRepository, mailSender, *ApiConfig)
+		notificationHandler := notification.NewNotificationHandler(notificationService)
+		notification.RegisterNotificationHandlers(instance, notificationHandler, authenticator)
+		// privacy
+		privacyService := privacy.NewPrivacyService(authService, documentService, groupService, auditService, activityService,
+			notificationService)
+		privacyHandler := privacy.NewPrivacyHandler(privacyService)
+		privacy.RegisterPrivacyHandlers(instance, privacyHandler, authenticator)
+		// live document events
+		hub, err := realtime.NewHub(redis, ApiConfig.EventSettings.Bus, ApiConfig.RedisSettings.Stream, ApiConfig.StreamSettings)
+		if err != nil {
+			panic(err)
+		}
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			hub.Run(ctx)
+		}()
+		realtimeHandler := realtime.NewRealtimeHandler(hub, ApiConfig.StreamSettings)
+		realtime.RegisterRealtimeHandlers(instance, realtimeHandler, authenticator)
+		// webhooks
+		//webhookRepository := webhook.NewWebhookRepository(db)
+		//deliveryRepository := webhook.NewDeliveryRepository(db)
+		webhookRepository := webhook.NewElasticRepository(elastic)
+		deliveryRepository := webhook.NewElasticDeliveryRepository(elastic)
+		webhookService := webhook.NewWebhookService(webhookRepository, deliveryRepository)
+		webhookHandler := webhook.NewWebhookHandler(webhookService)
+		webhook.RegisterWebhookHandlers(instance, webhookHandler, authenticator)
+		// organizations
+		//organizationRepository := organization.NewOrganizationRepository(db)
+		organizationRepository := organization.NewElasticRepository(elastic)
+		organizationService := organization.NewOrganizationService(organizationRepository, authRepository, authService, ApiConfig.TenantSettings)
+		organizationHandler := organization.NewOrganizationHandler(organizationService, ApiConfig.TenantSettings.SystemAdmins)
+		organization.RegisterOrganizationHandlers(instance, organizationHandler, authenticator)
+		if err := organizationService.EnsureDefault(context.Background()); err != nil {
+			fmt.Println("Default organization could not be created")
+		}
+		defaultTenant := ApiConfig.TenantSettings.DefaultTenant
+		allTenants := helpers.WithAllTenants(context.Background())
+		if _, err := authRepository.AssignTenant(allTenants, defaultTenant); err != nil {
+			fmt.Println("Users could not be assigned to the default organization")
+		}
+		if _, err := documentRepository.AssignTenant(allTenants, defaultTenant); err != nil {
+			fmt.Println("Documents could not be assigned to the default organization")
+		}
 
 		fmt.Println("Api starting")
-		if err := instance.Start(fmt.Sprintf(":%s", port)); err != nil {
-			fmt.Println("Api fatal error")
-		}
-		graceful.Shutdown(instance, time.Second*2)
+		go func() {
+			if err := instance.Start(fmt.Sprintf(":%s", port)); err != nil && err != http.ErrServerClosed {
+				fmt.Println("Api fatal error")
+				stop()
+			}
+		}()
+
+		<-ctx.Done()
+		fmt.Println("Api shutting down")
+		waitListener()
+		background.Wait()
+		graceful.Stop(instance, time.Second*2)
 	}
 	// Here you will define your flags and configuration settings.
 
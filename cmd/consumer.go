@@ -5,9 +5,16 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/hasanbakirci/doc-system/cmd/listener"
 	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
 
 	"github.com/spf13/cobra"
 )
@@ -40,13 +47,24 @@ func init() {
 
 	consumerCmd.Run = func(cmd *cobra.Command, args []string) {
 
-		forever := make(chan bool)
+		// SIGINT and SIGTERM stop reading new events; the ones in flight are finished
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		l := listener.NewListener(*ApiConfig)
-		l.Start()
+		redis := redisClient.NewRedisClient(ApiConfig.RedisSettings.Uri)
+		bus, err := events.NewBus(*ApiConfig, redis, "doc-system/consumer")
+		if err != nil {
+			panic(err)
+		}
+		defer bus.Close()
 
-		<-forever
+		l := listener.NewListener(*ApiConfig, redis, bus)
+		l.Start(ctx)
 
+		<-ctx.Done()
+		fmt.Println("Consumer shutting down")
+		l.Wait()
+		fmt.Println("Consumer stopped")
 	}
 	// Here you will define your flags and configuration settings.
 
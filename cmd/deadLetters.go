@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/deadletter"
+	"github.com/hasanbakirci/doc-system/internal/outbox"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	"github.com/spf13/cobra"
+)
+
+// deadLettersCmd inspects and replays the events consumers gave up on
+var deadLettersCmd = &cobra.Command{
+	Use:   "deadletters",
+	Short: "Inspect and replay dead-lettered events",
+	Long: `Lists the events consumers gave up on after their retries, together with the
+failure reason, and replays them through the outbox or deletes them.`,
+}
+
+func init() {
+	rootCmd.AddCommand(deadLettersCmd)
+
+	var cfgFile string
+	var limit int64
+	var all bool
+	deadLettersCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "config.dev", "config file (default is $HOME/.golang-api.yaml)")
+
+	ApiConfig, err := config.GetAllValues("./config/", cfgFile)
+	if err != nil {
+		panic(err)
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List dead letters, oldest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := newDeadLetterService(ApiConfig).GetAll(context.Background(), limit)
+			if err != nil {
+				return err
+			}
+			return printJson(result)
+		},
+	}
+	listCmd.Flags().Int64VarP(&limit, "limit", "l", 100, "number of dead letters to list")
+
+	replayCmd := &cobra.Command{
+		Use:   "replay [id]",
+		Short: "Replay a dead letter, or all of them with --all",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			service := newDeadLetterService(ApiConfig)
+			if all {
+				result, err := service.ReplayAll(context.Background())
+				if result != nil {
+					fmt.Printf("replayed %d dead letters\n", result.Replayed)
+				}
+				return err
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("replay takes a dead letter id or --all")
+			}
+			if _, err := service.Replay(context.Background(), args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("replayed %s\n", args[0])
+			return nil
+		},
+	}
+	replayCmd.Flags().BoolVar(&all, "all", false, "replay every dead letter")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a dead letter without replaying it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := newDeadLetterService(ApiConfig).Delete(context.Background(), args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("deleted %s\n", args[0])
+			return nil
+		},
+	}
+
+	deadLettersCmd.AddCommand(listCmd, replayCmd, deleteCmd)
+}
+
+func newDeadLetterService(settings *config.Configuration) deadletter.Service {
+	redis := redisClient.NewRedisClient(settings.RedisSettings.Uri)
+	elastic, err := elasticclient.ConnectElastic()
+	if err != nil {
+		panic(err)
+	}
+	//outboxRepository := outbox.NewOutboxRepository(db)
+	outboxRepository := outbox.NewElasticRepository(elastic)
+	publisher := outbox.NewPublisher(outboxRepository, "doc-system/deadletters")
+	return deadletter.NewDeadLetterService(deadletter.NewRedisRepository(redis, settings.ConsumerSettings.DeadLetterStream), publisher)
+}
+
+func printJson(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -1,28 +1,172 @@
 package listener
 
 import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/activity"
+	"github.com/hasanbakirci/doc-system/internal/auth"
 	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/deadletter"
+	"github.com/hasanbakirci/doc-system/internal/document"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/internal/notification"
+	"github.com/hasanbakirci/doc-system/internal/outbox"
 	"github.com/hasanbakirci/doc-system/internal/queues"
+	"github.com/hasanbakirci/doc-system/internal/webhook"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/mail"
 	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	log "github.com/sirupsen/logrus"
 )
 
+// loop is a background job of the listener that runs until its context is cancelled.
+type loop struct {
+	name string
+	run  func(ctx context.Context)
+}
+
 type listener struct {
-	redisClient     *redisClient.RedisClient
-	createdConsumer queues.CreatedConsumer
+	bus          events.Bus
+	deadLetters  deadletter.Repository
+	settings     config.ConsumerSettings
+	consumers    []queues.Consumer
+	loops        []loop
+	restartDelay time.Duration
+	wg           sync.WaitGroup
 }
 
-func NewListener(settings config.Configuration) listener {
+// NewListener consumes events from the bus, relays the outbox to it and sends the
+// webhook deliveries and notification digests. The api command passes its own bus
+// when it runs the consumer in process.
+func NewListener(settings config.Configuration, redis *redisClient.RedisClient, bus events.Bus) *listener {
+
+	elastic, err := elasticclient.ConnectElastic()
+	if err != nil {
+		log.Errorf("Elastic connection error: %v", err)
+		panic(err)
+	}
+	deadLetters := deadletter.NewRedisRepository(redis, settings.ConsumerSettings.DeadLetterStream)
+
+	//activityRepository := activity.NewActivityRepository(db, settings.TenantSettings)
+	activityRepository := activity.NewElasticRepository(elastic, settings.TenantSettings)
+	activityService := activity.NewActivityService(activityRepository)
 
-	redis := redisClient.NewRedisClient(settings.RedisSettings.Uri)
+	//webhookRepository := webhook.NewWebhookRepository(db)
+	//deliveryRepository := webhook.NewDeliveryRepository(db)
+	webhookRepository := webhook.NewElasticRepository(elastic)
+	deliveryRepository := webhook.NewElasticDeliveryRepository(elastic)
+	webhookService := webhook.NewWebhookService(webhookRepository, deliveryRepository)
+	dispatcher := webhook.NewDispatcher(webhookRepository, deliveryRepository, settings.WebhookSettings)
+
+	mailSender, err := mail.NewSender(settings.MailSettings)
+	if err != nil {
+		panic(err)
+	}
+	//notificationRepository := notification.NewNotificationRepository(db)
+	//preferenceRepository := notification.NewPreferenceRepository(db)
+	//followRepository := notification.NewFollowRepository(db)
+	//documentRepository := document.NewDocumentRepository(db, settings.TenantSettings)
+	//authRepository := auth.NewAuthRepository(db, settings.TenantSettings)
+	notificationRepository := notification.NewElasticRepository(elastic)
+	preferenceRepository := notification.NewElasticPreferenceRepository(elastic)
+	followRepository := notification.NewElasticFollowRepository(elastic)
+	documentRepository := document.NewElasticRepository(elastic, settings.TenantSettings)
+	authRepository := auth.NewElasticRepository(elastic, settings.TenantSettings)
+	notificationService := notification.NewNotificationService(notificationRepository, preferenceRepository, followRepository,
+		documentRepository, authRepository, mailSender, settings)
+	digester := notification.NewDigester(notificationService, settings.NotificationSettings)
+
+	//outboxRepository := outbox.NewOutboxRepository(db)
+	outboxRepository := outbox.NewElasticRepository(elastic)
+	relay := outbox.NewRelay(outboxRepository, bus, settings.OutboxSettings)
+
+	l := &listener{
+		bus:          bus,
+		deadLetters:  deadLetters,
+		settings:     settings.ConsumerSettings,
+		restartDelay: time.Duration(settings.ConsumerSettings.RestartDelay) * time.Second,
+	}
+	l.Handle("activity consumer", settings.RedisSettings.ConsumerGroup, activityService.Record)
+	l.Handle("webhook consumer", settings.WebhookSettings.ConsumerGroup, webhookService.Enqueue)
+	l.Handle("notification consumer", settings.NotificationSettings.ConsumerGroup, notificationService.Notify)
+	l.Run("outbox relay", relay.Run)
+	l.Run("webhook dispatcher", dispatcher.Run)
+	l.Run("notification digests", digester.Run)
+	return l
+}
 
-	createdConsumer := queues.NewCreatedConsumer(redis)
+// Handle registers a consumer of the bus under its own group. Consumers must be
+// registered before Start.
+func (receiver *listener) Handle(name string, group string, handler events.Handler) {
+	receiver.consumers = append(receiver.consumers, queues.Consumer{Name: name, Group: group, Handler: handler})
+}
+
+// Run registers a background loop. Loops must be registered before Start.
+func (receiver *listener) Run(name string, run func(ctx context.Context)) {
+	receiver.loops = append(receiver.loops, loop{name: name, run: run})
+}
+
+// Start runs the consumers and loops until the context is cancelled. Wait blocks
+// until they have stopped and drained the events they were handling.
+func (receiver *listener) Start(ctx context.Context) {
+	for _, consumer := range receiver.consumers {
+		consumer := consumer
+		receiver.start(ctx, consumer.Name, func(ctx context.Context) error {
+			return consumer.Consume(ctx, receiver.bus, receiver.deadLetters, receiver.settings)
+		})
+	}
+	for _, l := range receiver.loops {
+		l := l
+		receiver.start(ctx, l.name, func(ctx context.Context) error {
+			l.run(ctx)
+			return nil
+		})
+	}
+}
+
+func (receiver *listener) start(ctx context.Context, name string, loop func(ctx context.Context) error) {
+	receiver.wg.Add(1)
+	go func() {
+		defer receiver.wg.Done()
+		receiver.supervise(ctx, name, loop)
+		log.Infof("Listener: %s stopped", name)
+	}()
+}
+
+// Wait blocks until every consumer and loop started by Start has returned.
+func (receiver *listener) Wait() {
+	receiver.wg.Wait()
+}
 
-	return listener{
-		redisClient:     redis,
-		createdConsumer: createdConsumer,
+// supervise keeps a consumer loop running, restarting it after restartDelay when it
+// fails or panics, until the context is cancelled.
+func (receiver *listener) supervise(ctx context.Context, name string, loop func(ctx context.Context) error) {
+	for ctx.Err() == nil {
+		err := runLoop(ctx, loop)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("stopped without an error")
+		}
+		log.Errorf("Listener: %s stopped, restarting in %s: %v", name, receiver.restartDelay, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(receiver.restartDelay):
+		}
 	}
 }
 
-func (receiver listener) Start() {
-	go receiver.createdConsumer.Consume("doc-system")
+func runLoop(ctx context.Context, loop func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("stack", string(debug.Stack())).Errorf("Listener: panic: %v", r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return loop(ctx)
 }
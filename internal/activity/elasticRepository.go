@@ -0,0 +1,89 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+)
+
+type elasticRepository struct {
+	client *elasticsearch.Client
+	scope  elasticclient.TenantScope
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, activity *Activity) (string, error) {
+	index, alias := e.scope.WriteIndex(activity.TenantId)
+	if err := elasticclient.Index(ctx, e.client, index, alias, activity.ID, activity); err != nil {
+		return "", err
+	}
+	return activity.ID, nil
+}
+
+// GetAll implements Repository
+func (e *elasticRepository) GetAll(ctx context.Context, filter Filter) ([]Activity, error) {
+	queries := []map[string]interface{}{{"match_all": map[string]interface{}{}}}
+	if filter.Subject != "" {
+		queries = append(queries, elasticclient.Term("Subject", filter.Subject))
+	}
+	if filter.Actor != "" {
+		queries = append(queries, elasticclient.Term("Actor", filter.Actor))
+	}
+	if filter.Type != "" {
+		queries = append(queries, elasticclient.Term("Type", filter.Type))
+	}
+	occurredAt := map[string]interface{}{}
+	if !filter.From.IsZero() {
+		occurredAt["gte"] = filter.From.Format(time.RFC3339Nano)
+	}
+	if !filter.To.IsZero() {
+		occurredAt["lte"] = filter.To.Format(time.RFC3339Nano)
+	}
+	if len(occurredAt) > 0 {
+		queries = append(queries, map[string]interface{}{"range": map[string]interface{}{"OccurredAt": occurredAt}})
+	}
+	index, scoped, err := e.scope.Query(ctx, elasticclient.Must(queries...))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Activity](ctx, e.client, index, map[string]interface{}{
+		"size":  filter.Limit,
+		"sort":  []interface{}{map[string]interface{}{"OccurredAt": "desc"}},
+		"query": scoped,
+	})
+}
+
+// GetAllByUser implements Repository
+func (e *elasticRepository) GetAllByUser(ctx context.Context, userId string) ([]Activity, error) {
+	index, scoped, err := e.scope.Query(ctx, map[string]interface{}{"bool": map[string]interface{}{
+		"should": []interface{}{
+			elasticclient.Term("Actor", userId),
+			elasticclient.Term("Subject", userId),
+		},
+		"minimum_should_match": 1,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Activity](ctx, e.client, index, map[string]interface{}{
+		"size":  10000,
+		"query": scoped,
+	})
+}
+
+// Update implements Repository
+func (e *elasticRepository) Update(ctx context.Context, activity *Activity) (bool, error) {
+	index, alias := e.scope.WriteIndex(activity.TenantId)
+	if err := elasticclient.Index(ctx, e.client, index, alias, activity.ID, activity); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client, settings config.TenantSettings) Repository {
+	scope := elasticclient.NewTenantScope("activity_19092022", "activity", settings.Isolation == "separate")
+	return &elasticRepository{client: elastic, scope: scope}
+}
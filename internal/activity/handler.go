@@ -0,0 +1,50 @@
+package activity
+
+import (
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func (h Handler) getAllActivity(c echo.Context) error {
+	query := new(ActivityQuery)
+	if _, err := helpers.Validate(c, query); err != nil {
+		return err
+	}
+	result, err := h.service.GetAll(c.Request().Context(), *query)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getDocumentActivity(c echo.Context) error {
+	id := c.Param("id")
+	query := new(ActivityQuery)
+	if _, err := helpers.Validate(c, query); err != nil {
+		return err
+	}
+	result, err := h.service.GetBySubject(c.Request().Context(), id, *query)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func NewActivityHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+// RegisterActivityHandlers lets admins browse the activity of their organization and
+// everyone who can read documents see the history of a document.
+func RegisterActivityHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	instance.GET("api/activity", h.getAllActivity, authenticator.TokenHandlerMiddlewareFunc("admin"))
+	instance.GET("api/documents/:id/activity", h.getDocumentActivity, authenticator.ScopedMiddlewareFunc(helpers.DocumentsRead, "user", "admin"))
+}
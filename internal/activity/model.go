@@ -0,0 +1,103 @@
+package activity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/events"
+)
+
+// Activity is a published event kept for the activity log. The event data is kept
+// serialized so that the payloads of different event types do not clash in the
+// index mapping.
+type Activity struct {
+	ID          string    `bson:"_id"`
+	Type        string    `bson:"type"`
+	Source      string    `bson:"source"`
+	Subject     string    `bson:"subject"`
+	Actor       string    `bson:"actor"`
+	TenantId    string    `bson:"tenant_id"`
+	DataVersion string    `bson:"data_version"`
+	Data        string    `bson:"data"`
+	OccurredAt  time.Time `bson:"occurred_at"`
+}
+
+// Filter narrows the activity log; zero fields do not filter.
+type Filter struct {
+	Subject string
+	Actor   string
+	Type    string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+type ActivityQuery struct {
+	Actor string `query:"actor"`
+	Type  string `query:"type"`
+	From  string `query:"from"`
+	To    string `query:"to"`
+	Limit int    `query:"limit" validate:"min=0,max=1000"`
+}
+
+type ActivityResponse struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Source      string          `json:"source"`
+	Subject     string          `json:"subject"`
+	Actor       string          `json:"actor"`
+	DataVersion string          `json:"data_version"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+func NewActivity(event *events.Event) *Activity {
+	occurredAt, err := time.Parse(time.RFC3339Nano, event.Time)
+	if err != nil {
+		occurredAt = time.Now().UTC()
+	}
+	activity := &Activity{
+		ID:          event.ID,
+		Type:        event.Type,
+		Source:      event.Source,
+		Subject:     event.Subject,
+		Actor:       event.Actor,
+		TenantId:    event.TenantId,
+		DataVersion: event.DataVersion,
+		Data:        string(event.Data),
+		OccurredAt:  occurredAt,
+	}
+	// the account is gone, so its e-mail address and name are not kept
+	if event.Type == events.UserDeleted {
+		activity.Data = ""
+	}
+	return activity
+}
+
+// Anonymize replaces the user's id with alias and drops the event data, which may
+// carry the user's e-mail address, name or ip address.
+func (a *Activity) Anonymize(userId, alias string) {
+	a.Data = ""
+	if a.Actor == userId {
+		a.Actor = alias
+	}
+	if a.Subject == userId {
+		a.Subject = alias
+	}
+}
+
+func (a *Activity) ToActivityResponse() *ActivityResponse {
+	response := &ActivityResponse{
+		ID:          a.ID,
+		Type:        a.Type,
+		Source:      a.Source,
+		Subject:     a.Subject,
+		Actor:       a.Actor,
+		DataVersion: a.DataVersion,
+		OccurredAt:  a.OccurredAt,
+	}
+	if a.Data != "" {
+		response.Data = json.RawMessage(a.Data)
+	}
+	return response
+}
@@ -0,0 +1,112 @@
+package activity
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type activityRepository struct {
+	collections mongoClient.TenantCollections
+}
+
+func (a activityRepository) Create(ctx context.Context, activity *Activity) (string, error) {
+	_, err := a.collections.Collection(activity.TenantId).ReplaceOne(ctx, bson.M{"_id": activity.ID}, activity, options.Replace().SetUpsert(true))
+	if err != nil {
+		return "", err
+	}
+	return activity.ID, nil
+}
+
+func (a activityRepository) GetAll(ctx context.Context, filter Filter) ([]Activity, error) {
+	query := bson.M{}
+	if filter.Subject != "" {
+		query["subject"] = filter.Subject
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	occurredAt := bson.M{}
+	if !filter.From.IsZero() {
+		occurredAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		occurredAt["$lte"] = filter.To
+	}
+	if len(occurredAt) > 0 {
+		query["occurred_at"] = occurredAt
+	}
+
+	collections, err := a.collections.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scoped, err := a.collections.Filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	findOptions := options.Find().SetSort(bson.M{"occurred_at": -1}).SetLimit(int64(filter.Limit))
+	activities := make([]Activity, 0)
+	for _, collection := range collections {
+		cursor, err := collection.Find(ctx, scoped, findOptions)
+		if err != nil {
+			return nil, err
+		}
+		found := make([]Activity, 0)
+		if err := cursor.All(ctx, &found); err != nil {
+			return nil, err
+		}
+		activities = append(activities, found...)
+	}
+	// merge the newest of every collection
+	sort.Slice(activities, func(i, j int) bool { return activities[i].OccurredAt.After(activities[j].OccurredAt) })
+	if len(activities) > filter.Limit {
+		activities = activities[:filter.Limit]
+	}
+	return activities, nil
+}
+
+func (a activityRepository) GetAllByUser(ctx context.Context, userId string) ([]Activity, error) {
+	collections, err := a.collections.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scoped, err := a.collections.Filter(ctx, bson.M{"$or": []bson.M{{"actor": userId}, {"subject": userId}}})
+	if err != nil {
+		return nil, err
+	}
+	activities := make([]Activity, 0)
+	for _, collection := range collections {
+		cursor, err := collection.Find(ctx, scoped)
+		if err != nil {
+			return nil, err
+		}
+		found := make([]Activity, 0)
+		if err := cursor.All(ctx, &found); err != nil {
+			return nil, err
+		}
+		activities = append(activities, found...)
+	}
+	return activities, nil
+}
+
+func (a activityRepository) Update(ctx context.Context, activity *Activity) (bool, error) {
+	result, err := a.collections.Collection(activity.TenantId).ReplaceOne(ctx, bson.M{"_id": activity.ID}, activity)
+	if err != nil || result.MatchedCount < 1 {
+		return false, err
+	}
+	return true, nil
+}
+
+func NewActivityRepository(db *mongo.Database, settings config.TenantSettings) Repository {
+	collections := mongoClient.NewTenantCollections(db, "activity", settings.Isolation == "separate")
+	return &activityRepository{collections: collections}
+}
@@ -0,0 +1,17 @@
+package activity
+
+import (
+	"context"
+)
+
+type Repository interface {
+	// Create stores the activity under the event id, so a redelivered event is
+	// stored once.
+	Create(ctx context.Context, activity *Activity) (string, error)
+	// GetAll returns the matching activities of the context's organization, newest first.
+	GetAll(ctx context.Context, filter Filter) ([]Activity, error)
+	// GetAllByUser returns the activities of the context's organization the user
+	// acted in or was the subject of.
+	GetAllByUser(ctx context.Context, userId string) ([]Activity, error)
+	Update(ctx context.Context, activity *Activity) (bool, error)
+}
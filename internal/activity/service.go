@@ -0,0 +1,103 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+)
+
+type Service interface {
+	Record(ctx context.Context, event *events.Event) error
+	GetAll(ctx context.Context, query ActivityQuery) ([]ActivityResponse, error)
+	GetBySubject(ctx context.Context, subject string, query ActivityQuery) ([]ActivityResponse, error)
+	Anonymize(ctx context.Context, userId string) (int64, error)
+}
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type activityService struct {
+	repository Repository
+}
+
+// Record stores an event of the bus. Redelivered events overwrite their first copy.
+func (a activityService) Record(ctx context.Context, event *events.Event) error {
+	_, err := a.repository.Create(ctx, NewActivity(event))
+	return err
+}
+
+func (a activityService) GetAll(ctx context.Context, query ActivityQuery) ([]ActivityResponse, error) {
+	return a.GetBySubject(ctx, "", query)
+}
+
+// GetBySubject returns the activity of a document or user, newest first.
+func (a activityService) GetBySubject(ctx context.Context, subject string, query ActivityQuery) ([]ActivityResponse, error) {
+	filter, err := query.toFilter()
+	if err != nil {
+		return nil, err
+	}
+	filter.Subject = subject
+	activities, err := a.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, appError.Unavailable("activity_unavailable", "Service: failed to read activity").Wrap(err)
+	}
+	activityResponses := make([]ActivityResponse, 0)
+	for i := 0; i < len(activities); i++ {
+		activityResponses = append(activityResponses, *activities[i].ToActivityResponse())
+	}
+	return activityResponses, nil
+}
+
+// Anonymize rewrites the activities of an erased user under a random alias, like
+// the audit trail does.
+func (a activityService) Anonymize(ctx context.Context, userId string) (int64, error) {
+	activities, err := a.repository.GetAllByUser(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	alias := "erased-" + uuid.New().String()
+	var anonymized int64
+	for i := range activities {
+		activities[i].Anonymize(userId, alias)
+		if _, err := a.repository.Update(ctx, &activities[i]); err != nil {
+			return anonymized, err
+		}
+		anonymized++
+	}
+	return anonymized, nil
+}
+
+// toFilter reads the RFC 3339 time range and caps the page size.
+func (q ActivityQuery) toFilter() (Filter, error) {
+	filter := Filter{Actor: q.Actor, Type: q.Type, Limit: q.Limit}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	var err error
+	if q.From != "" {
+		if filter.From, err = time.Parse(time.RFC3339, q.From); err != nil {
+			return filter, appError.Validation("invalid_time_range", "Service: from must be an RFC 3339 time").Wrap(err)
+		}
+	}
+	if q.To != "" {
+		if filter.To, err = time.Parse(time.RFC3339, q.To); err != nil {
+			return filter, appError.Validation("invalid_time_range", "Service: to must be an RFC 3339 time").Wrap(err)
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, appError.Validation("invalid_time_range", "Service: to must not be before from")
+	}
+	return filter, nil
+}
+
+func NewActivityService(repo Repository) Service {
+	return &activityService{repository: repo}
+}
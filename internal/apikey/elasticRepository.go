@@ -0,0 +1,73 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/pkg/errors"
+)
+
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, apiKey *ApiKey) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, apiKey.ID, apiKey); err != nil {
+		return "", err
+	}
+	return apiKey.ID, nil
+}
+
+// GetByHash implements Repository
+func (e *elasticRepository) GetByHash(ctx context.Context, hash string) (*ApiKey, error) {
+	apiKeys, err := elasticclient.Search[ApiKey](ctx, e.client, e.index, map[string]interface{}{
+		"query": elasticclient.Term("Hash", hash),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(apiKeys) < 1 {
+		return nil, errors.New("Elastic repository: api key not found")
+	}
+	return &apiKeys[0], nil
+}
+
+// GetAllByUser implements Repository
+func (e *elasticRepository) GetAllByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	return elasticclient.Search[ApiKey](ctx, e.client, e.index, map[string]interface{}{
+		"size": 1000,
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"must":     []interface{}{elasticclient.Term("UserId", userId)},
+			"must_not": []interface{}{map[string]interface{}{"wildcard": map[string]interface{}{"RevokedAt.keyword": "?*"}}},
+		}},
+	})
+}
+
+// Revoke implements Repository
+func (e *elasticRepository) Revoke(ctx context.Context, id string, userId string) (bool, error) {
+	query := map[string]interface{}{"bool": map[string]interface{}{
+		"must":     []interface{}{elasticclient.Term("ID", id), elasticclient.Term("UserId", userId)},
+		"must_not": []interface{}{map[string]interface{}{"wildcard": map[string]interface{}{"RevokedAt.keyword": "?*"}}},
+	}}
+	updated, err := elasticclient.UpdateByQuery(ctx, e.client, e.index, query, map[string]interface{}{
+		"RevokedAt": time.Now().Format(timeLayout),
+	})
+	return updated > 0, err
+}
+
+// UpdateLastUsed implements Repository
+func (e *elasticRepository) UpdateLastUsed(ctx context.Context, id string, lastUsedAt string) (bool, error) {
+	updated, err := elasticclient.UpdateByQuery(ctx, e.client, e.index, elasticclient.Term("ID", id), map[string]interface{}{
+		"LastUsedAt": lastUsedAt,
+	})
+	return updated > 0, err
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	return &elasticRepository{client: elastic, index: "api_keys_19092022", alias: "api_keys"}
+}
@@ -0,0 +1,60 @@
+package apikey
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func (h Handler) createApiKey(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	request := new(CreateApiKeyRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Create(c.Request().Context(), uid, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Store the key now, it will not be shown again")
+}
+
+func (h Handler) getAllApiKeys(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.GetAll(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) revokeApiKey(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	id := c.Param("id")
+
+	result, err := h.service.Revoke(c.Request().Context(), uid, id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func NewApiKeyHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+func RegisterApiKeyHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	session := authenticator.TokenPurposeMiddlewareFunc("")
+	instance.POST("api/users/me/api-keys", h.createApiKey, session, middleware.NotImpersonatedMiddlewareFunc)
+	instance.GET("api/users/me/api-keys", h.getAllApiKeys, session)
+	instance.DELETE("api/users/me/api-keys/:id", h.revokeApiKey, session, middleware.NotImpersonatedMiddlewareFunc)
+}
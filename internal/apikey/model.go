@@ -0,0 +1,89 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+type ApiKey struct {
+	ID         string   `bson:"_id"`
+	UserId     string   `bson:"user_id"`
+	Name       string   `bson:"name"`
+	Prefix     string   `bson:"prefix"`
+	Hash       string   `bson:"hash"`
+	Scopes     []string `bson:"scopes"`
+	ExpiresAt  string   `bson:"expires_at"`
+	LastUsedAt string   `bson:"last_used_at"`
+	RevokedAt  string   `bson:"revoked_at"`
+	CreatedAt  string   `bson:"created_at"`
+}
+
+type CreateApiKeyRequest struct {
+	Name          string   `json:"name" validate:"required"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0"`
+}
+
+type ApiKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at"`
+	LastUsedAt string   `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatedApiKeyResponse is the only response that carries the plain key.
+type CreatedApiKeyResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
+
+// ToApiKey generates the plain key handed to the user once; only its hash is stored.
+func (receiver *CreateApiKeyRequest) ToApiKey(userId string, lifetime time.Duration) (*ApiKey, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	key := "dsk_" + hex.EncodeToString(raw)
+	now := time.Now()
+	return &ApiKey{
+		ID:        uuid.New().String(),
+		UserId:    userId,
+		Name:      receiver.Name,
+		Prefix:    key[:12],
+		Hash:      HashKey(key),
+		Scopes:    receiver.Scopes,
+		ExpiresAt: now.Add(lifetime).Format(timeLayout),
+		CreatedAt: now.Format(timeLayout),
+	}, key, nil
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *ApiKey) Expired(now time.Time) bool {
+	expiresAt, err := time.ParseInLocation(timeLayout, a.ExpiresAt, time.Local)
+	return err != nil || now.After(expiresAt)
+}
+
+func (a *ApiKey) ToApiKeyResponse() *ApiKeyResponse {
+	return &ApiKeyResponse{
+		ID:         a.ID,
+		Name:       a.Name,
+		Prefix:     a.Prefix,
+		Scopes:     a.Scopes,
+		ExpiresAt:  a.ExpiresAt,
+		LastUsedAt: a.LastUsedAt,
+		CreatedAt:  a.CreatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type apiKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func (a apiKeyRepository) Create(ctx context.Context, apiKey *ApiKey) (string, error) {
+	if _, err := a.collection.InsertOne(ctx, apiKey); err != nil {
+		return "", err
+	}
+	return apiKey.ID, nil
+}
+
+func (a apiKeyRepository) GetByHash(ctx context.Context, hash string) (*ApiKey, error) {
+	apiKey := new(ApiKey)
+	if err := a.collection.FindOne(ctx, bson.M{"hash": hash}).Decode(apiKey); err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (a apiKeyRepository) GetAllByUser(ctx context.Context, userId string) ([]ApiKey, error) {
+	cursor, err := a.collection.Find(ctx, bson.M{"user_id": userId, "revoked_at": ""})
+	if err != nil {
+		return nil, err
+	}
+	apiKeys := make([]ApiKey, 0)
+	if err = cursor.All(ctx, &apiKeys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (a apiKeyRepository) Revoke(ctx context.Context, id string, userId string) (bool, error) {
+	filter := bson.M{"_id": id, "user_id": userId, "revoked_at": ""}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now().Format(timeLayout)}}
+	updateResult, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount > 0, nil
+}
+
+func (a apiKeyRepository) UpdateLastUsed(ctx context.Context, id string, lastUsedAt string) (bool, error) {
+	update := bson.M{"$set": bson.M{"last_used_at": lastUsedAt}}
+	updateResult, err := a.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount > 0, nil
+}
+
+func NewApiKeyRepository(db *mongo.Database) Repository {
+	col := db.Collection("api_keys")
+	return &apiKeyRepository{collection: col}
+}
@@ -0,0 +1,13 @@
+package apikey
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, apiKey *ApiKey) (string, error)
+	GetByHash(ctx context.Context, hash string) (*ApiKey, error)
+	GetAllByUser(ctx context.Context, userId string) ([]ApiKey, error)
+	Revoke(ctx context.Context, id string, userId string) (bool, error)
+	UpdateLastUsed(ctx context.Context, id string, lastUsedAt string) (bool, error)
+}
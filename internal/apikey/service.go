@@ -0,0 +1,107 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Create(ctx context.Context, userId string, request CreateApiKeyRequest) (*CreatedApiKeyResponse, error)
+	GetAll(ctx context.Context, userId string) ([]ApiKeyResponse, error)
+	Revoke(ctx context.Context, userId string, id string) (bool, error)
+	ResolveApiKey(ctx context.Context, key string) (*helpers.UserClaim, error)
+}
+
+type apiKeyService struct {
+	repository Repository
+	users      auth.Repository
+	settings   config.ApiKeySettings
+}
+
+func (a apiKeyService) Create(ctx context.Context, userId string, request CreateApiKeyRequest) (*CreatedApiKeyResponse, error) {
+	user, err := a.users.GetById(ctx, userId)
+	if err != nil {
+		return nil, appError.NotFound("user_not_found", "Service: user id not found")
+	}
+	for _, scope := range request.Scopes {
+		if !helpers.HasPermission(user.Role, scope) {
+			return nil, appError.Validation("scope_not_allowed", "Service: scope is not allowed for this user: "+scope)
+		}
+	}
+	days := request.ExpiresInDays
+	if days == 0 {
+		days = a.settings.DefaultLifetime
+	}
+	if a.settings.MaxLifetime > 0 && days > a.settings.MaxLifetime {
+		return nil, appError.Validation("lifetime_exceeded", "Service: api key lifetime exceeds the allowed maximum")
+	}
+
+	apiKey, key, err := request.ToApiKey(userId, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return nil, appError.Internal("api_key_not_generated", "Service: failed to generate api key").Wrap(err)
+	}
+	if _, err := a.repository.Create(ctx, apiKey); err != nil {
+		return nil, appError.Unavailable("api_key_not_created", "Service: failed to create api key").Wrap(err)
+	}
+	return &CreatedApiKeyResponse{ApiKeyResponse: *apiKey.ToApiKeyResponse(), Key: key}, nil
+}
+
+func (a apiKeyService) GetAll(ctx context.Context, userId string) ([]ApiKeyResponse, error) {
+	apiKeys, err := a.repository.GetAllByUser(ctx, userId)
+	if err != nil {
+		return nil, appError.NotFound("api_keys_not_found", err.Error())
+	}
+	responses := make([]ApiKeyResponse, 0)
+	for i := 0; i < len(apiKeys); i++ {
+		responses = append(responses, *apiKeys[i].ToApiKeyResponse())
+	}
+	return responses, nil
+}
+
+func (a apiKeyService) Revoke(ctx context.Context, userId string, id string) (bool, error) {
+	result, _ := a.repository.Revoke(ctx, id, userId)
+	if !result {
+		return false, appError.NotFound("api_key_not_found", "Service: api key not found")
+	}
+	return true, nil
+}
+
+// ResolveApiKey maps a key to its owner. The scopes are narrowed to what the owner's
+// current role still allows, so demoting a user also limits their keys.
+func (a apiKeyService) ResolveApiKey(ctx context.Context, key string) (*helpers.UserClaim, error) {
+	apiKey, err := a.repository.GetByHash(ctx, HashKey(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey.RevokedAt != "" || apiKey.Expired(now) {
+		return nil, errors.New("api key is revoked or expired")
+	}
+	// the key is resolved before the request is scoped to an organization
+	user, err := a.users.GetById(helpers.WithAllTenants(ctx), apiKey.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]string, 0)
+	for _, scope := range apiKey.Scopes {
+		if helpers.HasPermission(user.Role, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if _, err := a.repository.UpdateLastUsed(ctx, apiKey.ID, now.Format(timeLayout)); err != nil {
+		log.Errorf("Service: failed to update api key usage: %v", err)
+	}
+	return &helpers.UserClaim{ID: user.ID, Role: user.Role, TenantId: user.TenantId, Scopes: scopes}, nil
+}
+
+func NewApiKeyService(repo Repository, users auth.Repository, settings config.ApiKeySettings) Service {
+	return &apiKeyService{repository: repo, users: users, settings: settings}
+}
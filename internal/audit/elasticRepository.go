@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/pkg/errors"
+)
+
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, event *Event) (string, error) {
+	if err := elasticclient.EnsureIndex(ctx, e.client, e.index, e.alias); err != nil {
+		return "", err
+	}
+	dataBytes, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	req := esapi.IndexRequest{Index: e.index, DocumentID: event.ID, Body: bytes.NewReader(dataBytes)}
+	res, err := req.Do(ctx, e.client)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return "", errors.Wrap(errors.New(res.String()), "esClient.Index error")
+	}
+	return event.ID, nil
+}
+
+// Update implements Repository
+func (e *elasticRepository) Update(ctx context.Context, event *Event) (bool, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, event.ID, event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetAllByUser implements Repository
+func (e *elasticRepository) GetAllByUser(ctx context.Context, userId string) ([]Event, error) {
+	return elasticclient.Search[Event](ctx, e.client, e.alias, map[string]interface{}{
+		"size": 10000,
+		"sort": []interface{}{map[string]interface{}{"CreatedAt.keyword": "asc"}},
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"should": []interface{}{
+				elasticclient.Term("ActorId", userId),
+				elasticclient.Term("SubjectId", userId),
+				map[string]interface{}{"multi_match": map[string]interface{}{"query": userId, "fields": []string{"Details.*.keyword"}}},
+			},
+			"minimum_should_match": 1,
+		}},
+	})
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	return &elasticRepository{client: elastic, index: "audit_19092022", alias: "audit"}
+}
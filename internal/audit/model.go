@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AccountLocked   = "user.account_locked"
+	AccountUnlocked = "user.account_unlocked"
+	IpLocked        = "user.ip_locked"
+
+	ImpersonationStarted = "user.impersonation_started"
+	ImpersonatedRequest  = "user.impersonated_request"
+
+	UserInvited        = "user.invited"
+	InvitationAccepted = "user.invitation_accepted"
+
+	AccountDeactivated = "user.account_deactivated"
+	AccountReactivated = "user.account_reactivated"
+	UserErased         = "user.erased"
+	UserDataExported   = "user.data_exported"
+)
+
+type Event struct {
+	ID        string            `bson:"_id"`
+	Type      string            `bson:"type"`
+	ActorId   string            `bson:"actor_id"`
+	SubjectId string            `bson:"subject_id"`
+	IpAddress string            `bson:"ip_address"`
+	Details   map[string]string `bson:"details"`
+	CreatedAt string            `bson:"created_at"`
+}
+
+func NewEvent(eventType, actorId, subjectId, ipAddress string, details map[string]string) *Event {
+	return &Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		ActorId:   actorId,
+		SubjectId: subjectId,
+		IpAddress: ipAddress,
+		Details:   details,
+		CreatedAt: time.Now().Format("2006-01-02-15-04-05"),
+	}
+}
+
+// Anonymize replaces every reference to the user with the alias and drops the
+// personal data the event holds about them.
+func (e *Event) Anonymize(userId, alias string) {
+	if e.ActorId == userId || e.SubjectId == userId {
+		e.IpAddress = ""
+		delete(e.Details, "email")
+	}
+	if e.ActorId == userId {
+		e.ActorId = alias
+	}
+	if e.SubjectId == userId {
+		e.SubjectId = alias
+	}
+	for key, value := range e.Details {
+		if value == userId {
+			e.Details[key] = alias
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type auditRepository struct {
+	collection *mongo.Collection
+}
+
+func (a auditRepository) Create(ctx context.Context, event *Event) (string, error) {
+	if _, err := a.collection.InsertOne(ctx, event); err != nil {
+		return "", err
+	}
+	return event.ID, nil
+}
+
+func (a auditRepository) Update(ctx context.Context, event *Event) (bool, error) {
+	result, err := a.collection.ReplaceOne(ctx, bson.M{"_id": event.ID}, event)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (a auditRepository) GetAllByUser(ctx context.Context, userId string) ([]Event, error) {
+	detailValues := bson.M{"$map": bson.M{
+		"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$details", bson.M{}}}},
+		"in":    "$$this.v",
+	}}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"actor_id": userId},
+		bson.M{"subject_id": userId},
+		bson.M{"$expr": bson.M{"$in": bson.A{userId, detailValues}}},
+	}}
+	cursor, err := a.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0)
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func NewAuditRepository(db *mongo.Database) Repository {
+	col := db.Collection("audit")
+	return &auditRepository{collection: col}
+}
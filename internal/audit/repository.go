@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, event *Event) (string, error)
+	Update(ctx context.Context, event *Event) (bool, error)
+	// GetAllByUser returns the events the user acted in, was the subject of or is
+	// named in the details of, oldest first.
+	GetAllByUser(ctx context.Context, userId string) ([]Event, error)
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Record(ctx context.Context, event *Event)
+	RecordImpersonatedRequest(ctx context.Context, impersonatorId, userId, ipAddress, method, path string)
+	GetAllByUser(ctx context.Context, userId string) ([]Event, error)
+	Anonymize(ctx context.Context, userId string) (int64, error)
+}
+
+type auditService struct {
+	repository Repository
+}
+
+// Record stores the event and mirrors it to the log. Audit failures are logged
+// instead of failing the request that triggered them. Events raised by an
+// impersonated request name the impersonating admin in their details.
+func (a auditService) Record(ctx context.Context, event *Event) {
+	if impersonatorId := helpers.Impersonator(ctx); impersonatorId != "" {
+		if event.Details == nil {
+			event.Details = map[string]string{}
+		}
+		event.Details["impersonated_by"] = impersonatorId
+	}
+	log.WithFields(log.Fields{
+		"audit":           event.Type,
+		"actor_id":        event.ActorId,
+		"subject_id":      event.SubjectId,
+		"ip_address":      event.IpAddress,
+		"impersonated_by": event.Details["impersonated_by"],
+	}).Info("audit event")
+
+	if _, err := a.repository.Create(ctx, event); err != nil {
+		log.Errorf("Audit: failed to store %s event: %v", event.Type, err)
+	}
+}
+
+func (a auditService) RecordImpersonatedRequest(ctx context.Context, impersonatorId, userId, ipAddress, method, path string) {
+	a.Record(ctx, NewEvent(ImpersonatedRequest, impersonatorId, userId, ipAddress, map[string]string{
+		"method":          method,
+		"path":            path,
+		"impersonated_by": impersonatorId,
+	}))
+}
+
+func (a auditService) GetAllByUser(ctx context.Context, userId string) ([]Event, error) {
+	return a.repository.GetAllByUser(ctx, userId)
+}
+
+// Anonymize rewrites the user's events under a random alias, so the trail still shows
+// what one account did without telling whose account it was.
+func (a auditService) Anonymize(ctx context.Context, userId string) (int64, error) {
+	events, err := a.repository.GetAllByUser(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	alias := "erased-" + uuid.New().String()
+	var anonymized int64
+	for i := range events {
+		events[i].Anonymize(userId, alias)
+		if _, err := a.repository.Update(ctx, &events[i]); err != nil {
+			return anonymized, err
+		}
+		anonymized++
+	}
+	return anonymized, nil
+}
+
+func NewAuditService(repo Repository) Service {
+	return &auditService{repository: repo}
+}
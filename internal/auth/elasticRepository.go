@@ -4,29 +4,32 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
+	"github.com/hasanbakirci/doc-system/internal/config"
 	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/pkg/errors"
 )
 
 type elasticRepository struct {
 	client *elasticsearch.Client
-	index  string
-	alias  string
+	scope  elasticclient.TenantScope
 }
 
 // CheckEmail implements Repository
 func (e *elasticRepository) CheckEmail(ctx context.Context, email string) (bool, error) {
 	shouldFilter := map[string]interface{}{"term": map[string]interface{}{"Email.keyword": email}}
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return false, err
+	}
 	query := map[string]interface{}{
-		"query": shouldFilter,
+		"query": scoped,
 	}
 	dataBytes, err := json.Marshal(&query)
 	if err != nil {
@@ -34,7 +37,7 @@ func (e *elasticRepository) CheckEmail(ctx context.Context, email string) (bool,
 	}
 	res, err := e.client.Search(
 		e.client.Search.WithContext(ctx),
-		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithIndex(index),
 		e.client.Search.WithBody(bytes.NewReader(dataBytes)),
 		e.client.Search.WithPretty(),
 		e.client.Search.WithHuman(),
@@ -50,7 +53,7 @@ func (e *elasticRepository) CheckEmail(ctx context.Context, email string) (bool,
 
 	hits := elasticclient.ElasticResponse[User]{}
 	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return false, err
 	}
 	if hits.Hits.Total.Value > 0 {
 		return true, nil
@@ -60,15 +63,19 @@ func (e *elasticRepository) CheckEmail(ctx context.Context, email string) (bool,
 
 // Create implements Repository
 func (e *elasticRepository) Create(ctx context.Context, user *User) (string, error) {
-	exists, err := e.client.Indices.Exists([]string{e.index})
+	index, alias := e.scope.WriteIndex(user.TenantId)
+	exists, err := e.client.Indices.Exists([]string{index})
+	if err != nil {
+		return "", err
+	}
 	if exists.StatusCode != 200 {
-		//index, err := e.client.Indices.Create(e.index)
+		//index, err := e.client.Indices.Create(index)
 		//fmt.Println(index.Body)
 		//if err != nil {
 		//	errorHandler.Panic(400, err.Error())
 		//}
 		//log.Info("created index:", index.Body)
-		//alias, err := e.client.Indices.PutAlias([]string{e.index}, uuid.New().String())
+		//alias, err := e.client.Indices.PutAlias([]string{e.scope.SearchIndex(ctx)}, uuid.New().String())
 		//fmt.Println(alias.Body)
 		//if err != nil {
 		//	errorHandler.Panic(400, err.Error())
@@ -76,7 +83,7 @@ func (e *elasticRepository) Create(ctx context.Context, user *User) (string, err
 		//log.Info("created alias:", alias.StatusCode)
 		settings := map[string]interface{}{
 			"aliases": map[string]interface{}{
-				e.alias: map[string]interface{}{},
+				alias: map[string]interface{}{},
 			},
 			"settings": map[string]interface{}{
 				"number_of_shards":   3,
@@ -88,26 +95,30 @@ func (e *elasticRepository) Create(ctx context.Context, user *User) (string, err
 			return "", err
 		}
 		req := esapi.IndicesCreateRequest{
-			Index: e.index,
+			Index: index,
 			Body:  bytes.NewReader(dataBytes),
 		}
 		res, err := req.Do(ctx, e.client)
-		if err != nil || res.StatusCode > 201 {
-			errorHandler.Panic(400, err.Error())
+		if err != nil {
+			return "", err
 		}
-		log.Infof("Elastic repositroy: %s index created, %s alias added.", e.index, e.alias)
-	}
-	if err != nil {
-		errorHandler.Panic(404, err.Error())
+		res.Body.Close()
+		if res.IsError() {
+			return "", errors.Wrap(errors.New(res.String()), "esClient.IndicesCreate error")
+		}
+		log.Infof("Elastic repositroy: %s index created, %s alias added.", index, alias)
 	}
 	user.Create()
 	u, _ := json.Marshal(user)
-	req := esapi.IndexRequest{Index: e.index, DocumentID: user.ID, Body: bytes.NewReader(u)}
+	req := esapi.IndexRequest{Index: index, DocumentID: user.ID, Body: bytes.NewReader(u)}
 	res, err := req.Do(ctx, e.client)
-	if err != nil || res.StatusCode > 201 {
-		errorHandler.Panic(400, err.Error())
+	if err != nil {
+		return "", err
 	}
 	defer res.Body.Close()
+	if res.IsError() {
+		return "", errors.Wrap(errors.New(res.String()), "esClient.Index error")
+	}
 
 	return user.ID, nil
 }
@@ -115,15 +126,19 @@ func (e *elasticRepository) Create(ctx context.Context, user *User) (string, err
 // Delete implements Repository
 func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error) {
 	shouldFilter := map[string]interface{}{"match": map[string]interface{}{"ID.keyword": id}}
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return false, err
+	}
 	query := map[string]interface{}{
-		"query": shouldFilter,
+		"query": scoped,
 	}
 	dataBytes, err := json.Marshal(&query)
 	if err != nil {
 		return false, err
 	}
 	reader := bytes.NewReader(dataBytes)
-	res, err := e.client.DeleteByQuery([]string{e.index}, reader,
+	res, err := e.client.DeleteByQuery([]string{index}, reader,
 		e.client.DeleteByQuery.WithContext(ctx),
 		e.client.DeleteByQuery.WithPretty(),
 		e.client.DeleteByQuery.WithHuman(),
@@ -140,7 +155,7 @@ func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error)
 
 	deleted := elasticclient.ElasticResultResponse{}
 	if err := json.NewDecoder(res.Body).Decode(&deleted); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return false, err
 	}
 	if deleted.Deleted < 1 {
 		return false, errors.New("id not found")
@@ -150,11 +165,24 @@ func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error)
 
 // GetAll implements Repository
 func (e *elasticRepository) GetAll(ctx context.Context) ([]User, error) {
+	index, scoped, err := e.scope.Query(ctx, map[string]interface{}{"match_all": map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	query := map[string]interface{}{
+		"query": scoped,
+	}
+	dataBytes, err := json.Marshal(&query)
+	if err != nil {
+		return nil, err
+	}
 	res, err := e.client.Search(
 		e.client.Search.WithContext(ctx),
-		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithIndex(index),
+		e.client.Search.WithBody(bytes.NewReader(dataBytes)),
 		e.client.Search.WithPretty(),
 		e.client.Search.WithHuman(),
+		e.client.Search.WithIgnoreUnavailable(true),
 		e.client.Search.WithTimeout(5*time.Second),
 	)
 	if err != nil {
@@ -167,25 +195,26 @@ func (e *elasticRepository) GetAll(ctx context.Context) ([]User, error) {
 
 	hits := elasticclient.ElasticResponse[User]{}
 	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return nil, err
 	}
 
-	hitsize := len(hits.Hits.Hits)
-	if hitsize > 0 {
-		resList := make([]User, hitsize)
-		for i, source := range hits.Hits.Hits {
-			resList[i] = source.Source
-		}
-		return resList, nil
+	// no users is an empty list, like in the mongo repository, so callers can tell it apart from failures
+	resList := make([]User, len(hits.Hits.Hits))
+	for i, source := range hits.Hits.Hits {
+		resList[i] = source.Source
 	}
-	return nil, errors.New("Elastic repository: Users index is null")
+	return resList, nil
 }
 
 // GetByEmail implements Repository
 func (e *elasticRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	shouldFilter := map[string]interface{}{"term": map[string]interface{}{"Email.keyword": email}}
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return nil, err
+	}
 	query := map[string]interface{}{
-		"query": shouldFilter,
+		"query": scoped,
 	}
 	dataBytes, err := json.Marshal(&query)
 	if err != nil {
@@ -193,7 +222,7 @@ func (e *elasticRepository) GetByEmail(ctx context.Context, email string) (*User
 	}
 	res, err := e.client.Search(
 		e.client.Search.WithContext(ctx),
-		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithIndex(index),
 		e.client.Search.WithBody(bytes.NewReader(dataBytes)),
 		e.client.Search.WithPretty(),
 		e.client.Search.WithHuman(),
@@ -210,7 +239,7 @@ func (e *elasticRepository) GetByEmail(ctx context.Context, email string) (*User
 
 	hits := elasticclient.ElasticResponse[User]{}
 	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return nil, err
 	}
 	hitsize := len(hits.Hits.Hits)
 	if hitsize < 1 {
@@ -226,8 +255,12 @@ func (e *elasticRepository) GetByEmail(ctx context.Context, email string) (*User
 // GetById implements Repository
 func (e *elasticRepository) GetById(ctx context.Context, id string) (*User, error) {
 	shouldFilter := map[string]interface{}{"match": map[string]interface{}{"ID.keyword": id}}
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return nil, err
+	}
 	query := map[string]interface{}{
-		"query": shouldFilter,
+		"query": scoped,
 	}
 	dataBytes, err := json.Marshal(&query)
 	if err != nil {
@@ -235,7 +268,7 @@ func (e *elasticRepository) GetById(ctx context.Context, id string) (*User, erro
 	}
 	res, err := e.client.Search(
 		e.client.Search.WithContext(ctx),
-		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithIndex(index),
 		e.client.Search.WithBody(bytes.NewReader(dataBytes)),
 		e.client.Search.WithPretty(),
 		e.client.Search.WithHuman(),
@@ -252,7 +285,7 @@ func (e *elasticRepository) GetById(ctx context.Context, id string) (*User, erro
 
 	hits := elasticclient.ElasticResponse[User]{}
 	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return nil, err
 	}
 	hitsize := len(hits.Hits.Hits)
 	if hitsize < 1 {
@@ -267,37 +300,116 @@ func (e *elasticRepository) GetById(ctx context.Context, id string) (*User, erro
 
 // Update implements Repository
 func (e *elasticRepository) Update(ctx context.Context, id string, user *User) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{
+		"Username":      user.Username,
+		"Password":      user.Password,
+		"Email":         user.Email,
+		"Role":          user.Role,
+		"EmailVerified": user.EmailVerified,
+	})
+}
+
+// UpdateTwoFactor implements Repository
+func (e *elasticRepository) UpdateTwoFactor(ctx context.Context, id string, twoFactor TwoFactor) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{"TwoFactor": twoFactor})
+}
+
+// UpdatePassword implements Repository
+func (e *elasticRepository) UpdatePassword(ctx context.Context, id string, password string) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{"Password": password})
+}
+
+// UpdateEmailVerified implements Repository
+func (e *elasticRepository) UpdateEmailVerified(ctx context.Context, id string, verified bool) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{"EmailVerified": verified})
+}
+
+// GetByExternalId implements Repository
+func (e *elasticRepository) GetByExternalId(ctx context.Context, provider string, externalId string) (*User, error) {
+	index, scoped, err := e.scope.Query(ctx, elasticclient.Must(elasticclient.Term("Provider", provider), elasticclient.Term("ExternalId", externalId)))
+	if err != nil {
+		return nil, err
+	}
+	users, err := elasticclient.Search[User](ctx, e.client, index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) < 1 {
+		return nil, errors.New("Elastic repository: external id not found")
+	}
+	return &users[0], nil
+}
+
+// UpdateExternalId implements Repository
+func (e *elasticRepository) UpdateExternalId(ctx context.Context, id string, provider string, externalId string) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{"Provider": provider, "ExternalId": externalId})
+}
+
+// UpdateRole implements Repository
+func (e *elasticRepository) UpdateRole(ctx context.Context, id string, role string) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{"Role": role})
+}
+
+// UpdateDeactivated implements Repository
+func (e *elasticRepository) UpdateDeactivated(ctx context.Context, id string, deactivatedAt string) (bool, error) {
+	return e.updateFields(ctx, id, map[string]interface{}{"Deactivated": deactivatedAt != "", "DeactivatedAt": deactivatedAt})
+}
+
+// GetAllByProvider implements Repository
+func (e *elasticRepository) GetAllByProvider(ctx context.Context, provider string) ([]User, error) {
+	index, scoped, err := e.scope.Query(ctx, elasticclient.Term("Provider", provider))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[User](ctx, e.client, index, map[string]interface{}{
+		"size":  10000,
+		"query": scoped,
+	})
+}
+
+// updateFields overwrites the given top level fields of a user through script params,
+// so nested values do not have to be rendered into the script source.
+func (e *elasticRepository) updateFields(ctx context.Context, id string, fields map[string]interface{}) (bool, error) {
+	fields["UpdatedAt"] = time.Now().Format("2006-01-02-15-04-05")
 	updateField := map[string]interface{}{
-		"source": fmt.Sprintf(
-			"ctx._source['Username'] = '%s';ctx._source['Password'] = '%s';ctx._source['Email'] = '%s';ctx._source['Role'] = '%s';ctx._source['UpdatedAt'] = '%s'",
-			user.Username, user.Password, user.Email, user.Role, user.UpdatedAt)}
+		"source": "for (entry in params.fields.entrySet()) { ctx._source[entry.getKey()] = entry.getValue(); }",
+		"params": map[string]interface{}{"fields": fields},
+	}
 
 	shouldFilter := map[string]interface{}{"match_phrase": map[string]interface{}{"ID.keyword": id}}
 
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return false, err
+	}
 	updateRequest := map[string]interface{}{
 		"script": updateField,
-		"query":  shouldFilter,
+		"query":  scoped,
 	}
 	dataBytes, err := json.Marshal(&updateRequest)
 	if err != nil {
 		return false, err
 	}
 
-	reader := bytes.NewReader(dataBytes)
-	res, err := e.client.UpdateByQuery([]string{e.index},
-		e.client.UpdateByQuery.WithBody(reader),
+	res, err := e.client.UpdateByQuery([]string{index},
+		e.client.UpdateByQuery.WithBody(bytes.NewReader(dataBytes)),
 		e.client.UpdateByQuery.WithContext(ctx),
-		e.client.UpdateByQuery.WithPretty(),
-		e.client.UpdateByQuery.WithHuman(),
+		e.client.UpdateByQuery.WithRefresh(true),
 		e.client.UpdateByQuery.WithTimeout(5*time.Second))
 	if err != nil {
 		return false, err
 	}
 	defer res.Body.Close()
 
+	if res.IsError() {
+		return false, errors.Wrap(errors.New(res.String()), "esClient.UpdateByQuery error")
+	}
+
 	updated := elasticclient.ElasticResultResponse{}
 	if err := json.NewDecoder(res.Body).Decode(&updated); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return false, err
 	}
 	if updated.Updated < 1 {
 		return false, errors.New("id not found")
@@ -305,6 +417,18 @@ func (e *elasticRepository) Update(ctx context.Context, id string, user *User) (
 	return true, nil
 }
 
-func NewElasticRepository(elastic *elasticsearch.Client) Repository {
-	return &elasticRepository{client: elastic, index: "users_19092022", alias: "users"}
+// AssignTenant implements Repository
+func (e *elasticRepository) AssignTenant(ctx context.Context, tenantId string) (int64, error) {
+	if !helpers.AllTenants(ctx) {
+		return 0, helpers.ErrAllTenantsRequired
+	}
+	query := map[string]interface{}{"bool": map[string]interface{}{
+		"must_not": []interface{}{map[string]interface{}{"wildcard": map[string]interface{}{"TenantId.keyword": "?*"}}},
+	}}
+	return elasticclient.UpdateByQuery(ctx, e.client, e.scope.Index, query, map[string]interface{}{"TenantId": tenantId})
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client, settings config.TenantSettings) Repository {
+	scope := elasticclient.NewTenantScope("users_19092022", "users", settings.Isolation == "separate")
+	return &elasticRepository{client: elastic, scope: scope}
 }
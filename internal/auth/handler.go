@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
 	"github.com/labstack/echo/v4"
 )
 
@@ -12,17 +15,19 @@ type Handler struct {
 	service Service
 }
 
+// createUser is the anonymous sign-up, so it never grants a role above user. Admins
+// are invited or created by system admins.
 func (h *Handler) createUser(c echo.Context) error {
-	request := new(CreateUserRequest)
+	request := new(SignUpRequest)
 	if _, err := helpers.Validate(c, request); err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 	//if err := c.Bind(request); err != nil {
-	//	return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+	//	return err
 	//}
-	result, err := h.service.Create(c.Request().Context(), *request)
+	result, err := h.service.Create(c.Request().Context(), request.ToCreateUserRequest())
 	if err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 	return errorHandler.Success(c, http.StatusCreated, result, "Success")
 }
@@ -31,25 +36,17 @@ func (h *Handler) updateUser(c echo.Context) error {
 	id := c.Param("id")
 	request := new(UpdateUserRequest)
 	if _, err := helpers.Validate(c, request); err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 	//if err := c.Bind(request); err != nil {
-	//	return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+	//	return err
 	//}
+	request.ActorId = fmt.Sprintf("%v", c.Get("id"))
+	request.ActorRole = fmt.Sprintf("%v", c.Get("role"))
 
 	result, err := h.service.Update(c.Request().Context(), id, *request)
-	if !result {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
-	}
-	return errorHandler.Success(c, http.StatusOK, result, "Success")
-}
-
-func (h *Handler) deleteUser(c echo.Context) error {
-	id := c.Param("id")
-
-	result, err := h.service.Delete(c.Request().Context(), id)
-	if !result {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+	if err != nil {
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, result, "Success")
 }
@@ -57,7 +54,7 @@ func (h *Handler) deleteUser(c echo.Context) error {
 func (h *Handler) getAllUsers(c echo.Context) error {
 	result, err := h.service.GetAll(c.Request().Context())
 	if err != nil {
-		return errorHandler.Error(c, http.StatusNotFound, err.Error())
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, result, "Success")
 }
@@ -67,7 +64,7 @@ func (h *Handler) getByIdUser(c echo.Context) error {
 
 	result, err := h.service.GetById(c.Request().Context(), id)
 	if err != nil {
-		return errorHandler.Error(c, http.StatusNotFound, err.Error())
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, result, "Success")
 }
@@ -75,11 +72,206 @@ func (h *Handler) getByIdUser(c echo.Context) error {
 func (h *Handler) loginUser(c echo.Context) error {
 	request := new(LoginUserRequest)
 	if err := c.Bind(request); err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return appError.Validation("invalid_body", "Invalid request body").Wrap(err)
 	}
+	request.ClientIp = c.RealIP()
+	request.UserAgent = c.Request().UserAgent()
 	result, err := h.service.Login(c.Request().Context(), *request)
 	if err != nil {
-		return errorHandler.Error(c, http.StatusNotFound, err.Error())
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) loginTwoFactor(c echo.Context) error {
+	request := new(TwoFactorLoginRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	request.ClientIp = c.RealIP()
+	request.UserAgent = c.Request().UserAgent()
+	result, err := h.service.LoginTwoFactor(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) impersonateUser(c echo.Context) error {
+	id := c.Param("id")
+	request := new(ImpersonateRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	request.ActorId = fmt.Sprintf("%v", c.Get("id"))
+	request.SessionId = fmt.Sprintf("%v", c.Get("sid"))
+	request.ClientIp = c.RealIP()
+
+	result, err := h.service.Impersonate(c.Request().Context(), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) enrollTwoFactor(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.EnrollTwoFactor(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) confirmTwoFactor(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	request := new(TwoFactorCodeRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.ConfirmTwoFactor(c.Request().Context(), uid, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) disableTwoFactor(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	request := new(TwoFactorCodeRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.DisableTwoFactor(c.Request().Context(), uid, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) regenerateRecoveryCodes(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	request := new(TwoFactorCodeRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.RegenerateRecoveryCodes(c.Request().Context(), uid, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) unlockUser(c echo.Context) error {
+	id := c.Param("id")
+	actorId := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.Unlock(c.Request().Context(), id, actorId)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) terminateUserSessions(c echo.Context) error {
+	id := c.Param("id")
+
+	count, err := h.service.TerminateSessions(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, count, "Success")
+}
+
+func (h *Handler) deactivateUser(c echo.Context) error {
+	id := c.Param("id")
+	actorId := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.Deactivate(c.Request().Context(), id, actorId)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) reactivateUser(c echo.Context) error {
+	id := c.Param("id")
+	actorId := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.Reactivate(c.Request().Context(), id, actorId)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) forgotPassword(c echo.Context) error {
+	request := new(EmailRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.ForgotPassword(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "If the e-mail address exists a reset link was sent")
+}
+
+func (h *Handler) resetPassword(c echo.Context) error {
+	request := new(ResetPasswordRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.ResetPassword(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) verifyEmail(c echo.Context) error {
+	request := new(VerifyEmailRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.VerifyEmail(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) resendVerification(c echo.Context) error {
+	request := new(EmailRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.ResendVerification(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h *Handler) oidcLogin(c echo.Context) error {
+	url, err := h.service.OidcLoginUrl(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, url)
+}
+
+func (h *Handler) oidcCallback(c echo.Context) error {
+	request := new(OidcCallbackRequest)
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, request); err != nil {
+		return appError.Validation("invalid_body", "Invalid request body").Wrap(err)
+	}
+	request.ClientIp = c.RealIP()
+	request.UserAgent = c.Request().UserAgent()
+	result, err := h.service.OidcCallback(c.Request().Context(), *request)
+	if err != nil {
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, result, "Success")
 }
@@ -88,11 +280,31 @@ func NewUserHandler(s Service) Handler {
 	return Handler{service: s}
 }
 
-func RegisterUserHandlers(instance *echo.Echo, h Handler) {
+func RegisterUserHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
 	instance.POST("api/users", h.createUser)
-	instance.PUT("api/users/:id", h.updateUser)
-	instance.DELETE("api/users/:id", h.deleteUser)
-	instance.GET("api/users", h.getAllUsers)
-	instance.GET("api/users/:id", h.getByIdUser)
+	// the token scopes these to the caller's organization
+	member := authenticator.TokenHandlerMiddlewareFunc("user", "admin")
+	instance.PUT("api/users/:id", h.updateUser, member, middleware.NotImpersonatedMiddlewareFunc)
+	instance.GET("api/users", h.getAllUsers, member)
+	instance.GET("api/users/:id", h.getByIdUser, member)
 	instance.POST("api/users/login", h.loginUser)
+	instance.POST("api/users/login/2fa", h.loginTwoFactor)
+	instance.GET("api/users/login/oidc", h.oidcLogin)
+	instance.GET("api/users/login/oidc/callback", h.oidcCallback)
+	instance.POST("api/users/password/forgot", h.forgotPassword)
+	instance.POST("api/users/password/reset", h.resetPassword)
+	instance.POST("api/users/email/verify", h.verifyEmail)
+	instance.POST("api/users/email/verify/resend", h.resendVerification)
+	admin := authenticator.TokenHandlerMiddlewareFunc("admin")
+	instance.POST("api/users/:id/unlock", h.unlockUser, admin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/users/:id/impersonate", h.impersonateUser, admin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/users/:id/deactivate", h.deactivateUser, admin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/users/:id/reactivate", h.reactivateUser, admin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.DELETE("api/users/:id/sessions", h.terminateUserSessions, admin, middleware.NotImpersonatedMiddlewareFunc)
+
+	setup := authenticator.TokenPurposeMiddlewareFunc("", helpers.TwoFactorSetupPurpose)
+	instance.POST("api/users/me/2fa/enroll", h.enrollTwoFactor, setup, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/users/me/2fa/confirm", h.confirmTwoFactor, setup, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/users/me/2fa/disable", h.disableTwoFactor, authenticator.TokenPurposeMiddlewareFunc(""), middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/users/me/2fa/recovery-codes", h.regenerateRecoveryCodes, authenticator.TokenPurposeMiddlewareFunc(""), middleware.NotImpersonatedMiddlewareFunc)
 }
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/ldapClient"
+	log "github.com/sirupsen/logrus"
+)
+
+const ldapProvider = "ldap"
+
+// ldapLogin binds against the directory and returns the matching local account,
+// provisioning it on first login. It returns nil when the credentials are wrong.
+func (a authService) ldapLogin(ctx context.Context, login, password string) (*User, error) {
+	entry, err := a.ldap.Authenticate(login, password)
+	if err == ldapClient.ErrInvalidCredentials || err == ldapClient.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, appError.Unavailable("directory_unavailable", "Service: directory is temporarily unavailable").Wrap(err)
+	}
+	return a.provisionLdapUser(ctx, entry)
+}
+
+func (a authService) provisionLdapUser(ctx context.Context, entry *ldapClient.Entry) (*User, error) {
+	settings := a.config.LdapSettings
+	role := groupsToRole(entry.Groups, settings.GroupRoles, settings.DefaultRole)
+
+	user, err := a.repository.GetByExternalId(ctx, ldapProvider, entry.Dn)
+	if err != nil {
+		if exists, _ := a.repository.CheckEmail(ctx, entry.Email); exists || entry.Email == "" {
+			return nil, appError.Forbidden("no_account_for_identity", "Service: no directory account can be linked to this e-mail address")
+		}
+		username := entry.Username
+		if username == "" {
+			username = entry.Email
+		}
+		return a.createExternalUser(ctx, ldapProvider, entry.Dn, username, entry.Email, role)
+	}
+	return user, a.syncRole(ctx, user, role)
+}
+
+// LdapSync periodically maps the directory groups of every ldap account to its role.
+type LdapSync struct {
+	repository Repository
+	client     *ldapClient.LdapClient
+	settings   config.LdapSettings
+}
+
+func NewLdapSync(repo Repository, settings config.LdapSettings) *LdapSync {
+	return &LdapSync{repository: repo, client: ldapClient.NewLdapClient(settings), settings: settings}
+}
+
+func (s *LdapSync) Run(ctx context.Context) {
+	interval := time.Duration(s.settings.SyncInterval) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sync(ctx); err != nil {
+				log.Errorf("Ldap sync: %v", err)
+			}
+		}
+	}
+}
+
+func (s *LdapSync) Sync(ctx context.Context) error {
+	ctx = helpers.WithAllTenants(ctx)
+	users, err := s.repository.GetAllByProvider(ctx, ldapProvider)
+	if err != nil {
+		return err
+	}
+	updated := 0
+	for _, user := range users {
+		entry, err := s.client.Lookup(user.ExternalId)
+		if err == ldapClient.ErrNotFound {
+			log.Warnf("Ldap sync: %s no longer exists in the directory", user.ExternalId)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		role := groupsToRole(entry.Groups, s.settings.GroupRoles, s.settings.DefaultRole)
+		if role == user.Role {
+			continue
+		}
+		if _, err := s.repository.UpdateRole(ctx, user.ID, role); err != nil {
+			return err
+		}
+		updated++
+	}
+	log.Infof("Ldap sync: checked %d users, updated %d roles", len(users), updated)
+	return nil
+}
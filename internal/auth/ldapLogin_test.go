@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+func TestGroupsToRole(t *testing.T) {
+	mappings := []config.GroupRole{
+		{Group: "admins", Role: "admin"},
+		{Group: "Editors", Role: "user"},
+	}
+	tests := []struct {
+		name   string
+		groups []string
+		want   string
+	}{
+		{"first mapping wins", []string{"editors", "admins"}, "admin"},
+		{"case insensitive", []string{"EDITORS"}, "user"},
+		{"unmapped groups", []string{"staff"}, "viewer"},
+		{"no groups", nil, "viewer"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if role := groupsToRole(test.groups, mappings, "viewer"); role != test.want {
+				t.Fatalf("groupsToRole(%v) = %q, want %q", test.groups, role, test.want)
+			}
+		})
+	}
+}
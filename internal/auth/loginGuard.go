@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+)
+
+var errLoginLocked = errors.New("too many failed login attempts")
+
+// maxThrottle caps the delay between attempts when MaxDelay is not set.
+const maxThrottle = 24 * time.Hour
+
+// loginGuard keeps failed login counters per account and per client ip in redis,
+// throttling further attempts progressively and locking them out past the thresholds.
+type loginGuard struct {
+	redis    *redisClient.RedisClient
+	settings config.LockoutSettings
+}
+
+type loginFailure struct {
+	AccountLocked bool
+	IpLocked      bool
+}
+
+func newLoginGuard(redis *redisClient.RedisClient, settings config.LockoutSettings) loginGuard {
+	return loginGuard{redis: redis, settings: settings}
+}
+
+func accountKey(email, suffix string) string {
+	return fmt.Sprintf("doc-system:login:account:%s:%s", strings.ToLower(strings.TrimSpace(email)), suffix)
+}
+
+func ipKey(ip, suffix string) string {
+	return fmt.Sprintf("doc-system:login:ip:%s:%s", ip, suffix)
+}
+
+// Check returns errLoginLocked while the account or the ip is locked or throttled.
+func (g loginGuard) Check(email, ip string) error {
+	keys := []string{accountKey(email, "locked"), accountKey(email, "throttle")}
+	if ip != "" {
+		keys = append(keys, ipKey(ip, "locked"), ipKey(ip, "throttle"))
+	}
+	for _, key := range keys {
+		ttl, err := g.redis.TTL(key)
+		if err != nil {
+			return err
+		}
+		if ttl > 0 {
+			return errLoginLocked
+		}
+	}
+	return nil
+}
+
+func (g loginGuard) Fail(email, ip string) (*loginFailure, error) {
+	window := time.Duration(g.settings.AttemptWindow) * time.Minute
+	result := &loginFailure{}
+
+	count, err := g.redis.Increment(accountKey(email, "failures"), window)
+	if err != nil {
+		return nil, err
+	}
+	if result.AccountLocked, err = g.penalize(count, g.settings.MaxAccountAttempts, func(suffix string) string { return accountKey(email, suffix) }); err != nil {
+		return nil, err
+	}
+
+	if ip == "" {
+		return result, nil
+	}
+	count, err = g.redis.Increment(ipKey(ip, "failures"), window)
+	if err != nil {
+		return nil, err
+	}
+	if result.IpLocked, err = g.penalize(count, g.settings.MaxIpAttempts, func(suffix string) string { return ipKey(ip, suffix) }); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// penalize locks the key once the failures reach max, otherwise delays the next
+// attempt by an exponentially growing amount of seconds.
+func (g loginGuard) penalize(failures int64, max int, key func(string) string) (bool, error) {
+	if max > 0 && failures >= int64(max) {
+		lockout := time.Duration(g.settings.LockoutTime) * time.Minute
+		if err := g.redis.SetWithExpiration(key("locked"), failures, lockout); err != nil {
+			return false, err
+		}
+		return true, g.redis.Delete(key("failures"), key("throttle"))
+	}
+	delay := g.throttleDelay(failures)
+	if delay <= 0 {
+		return false, nil
+	}
+	return false, g.redis.SetWithExpiration(key("throttle"), failures, delay)
+}
+
+// throttleDelay doubles DelayBase with every failure past DelayAfter, up to MaxDelay.
+// Without a MaxDelay the delay still stops growing at maxThrottle, so it can not
+// overflow when lockouts are disabled.
+func (g loginGuard) throttleDelay(failures int64) time.Duration {
+	if failures <= int64(g.settings.DelayAfter) || g.settings.DelayBase <= 0 {
+		return 0
+	}
+	maxDelay := time.Duration(g.settings.MaxDelay) * time.Second
+	if maxDelay <= 0 || maxDelay > maxThrottle {
+		maxDelay = maxThrottle
+	}
+	return helpers.Backoff(int(failures-int64(g.settings.DelayAfter)), time.Duration(g.settings.DelayBase)*time.Second, maxDelay)
+}
+
+func (g loginGuard) Succeed(email string) error {
+	return g.redis.Delete(accountKey(email, "failures"), accountKey(email, "throttle"))
+}
+
+func (g loginGuard) Unlock(email string) error {
+	return g.redis.Delete(accountKey(email, "failures"), accountKey(email, "throttle"), accountKey(email, "locked"))
+}
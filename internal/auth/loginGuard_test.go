@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+func TestThrottleDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings config.LockoutSettings
+		failures int64
+		want     time.Duration
+	}{
+		{"below threshold", config.LockoutSettings{DelayAfter: 3, DelayBase: 1}, 3, 0},
+		{"first delay", config.LockoutSettings{DelayAfter: 3, DelayBase: 1}, 4, time.Second},
+		{"doubles", config.LockoutSettings{DelayAfter: 3, DelayBase: 1}, 6, 4 * time.Second},
+		{"capped by max delay", config.LockoutSettings{DelayAfter: 3, DelayBase: 1, MaxDelay: 60}, 20, time.Minute},
+		{"capped without max delay", config.LockoutSettings{DelayAfter: 3, DelayBase: 1}, 100, maxThrottle},
+		{"no delay base", config.LockoutSettings{DelayAfter: 3}, 10, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := loginGuard{settings: test.settings}
+			if delay := g.throttleDelay(test.failures); delay != test.want {
+				t.Fatalf("throttleDelay(%d) = %v, want %v", test.failures, delay, test.want)
+			}
+		})
+	}
+}
@@ -1,50 +1,170 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/labstack/gommon/log"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID        string `bson:"_id"`
-	Username  string `bson:"username"`
-	Password  string `bson:"password"`
-	Email     string `bson:"email"`
-	Role      string `bson:"role"`
-	CreatedAt string `bson:"created_at"`
-	UpdatedAt string `bson:"updated_at"`
+	ID            string    `bson:"_id"`
+	Username      string    `bson:"username"`
+	Password      string    `bson:"password"`
+	Email         string    `bson:"email"`
+	Role          string    `bson:"role"`
+	TwoFactor     TwoFactor `bson:"two_factor"`
+	EmailVerified bool      `bson:"email_verified"`
+	Provider      string    `bson:"provider"`
+	ExternalId    string    `bson:"external_id"`
+	TenantId      string    `bson:"tenant_id"`
+	Deactivated   bool      `bson:"deactivated"`
+	DeactivatedAt string    `bson:"deactivated_at"`
+	CreatedAt     string    `bson:"created_at"`
+	UpdatedAt     string    `bson:"updated_at"`
+}
+
+type TwoFactor struct {
+	Enabled       bool     `bson:"enabled"`
+	Secret        string   `bson:"secret"`
+	PendingSecret string   `bson:"pending_secret"`
+	RecoveryCodes []string `bson:"recovery_codes"`
+	LastUsedStep  int64    `bson:"last_used_step"`
+}
+
+// SignUpRequest creates an account of the regular user role; a role sent along is
+// ignored.
+type SignUpRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
 }
 
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
-	Role     string `json:"role" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=user admin"`
+	// set by callers that already proved the address, such as accepted invitations
+	EmailVerified bool `json:"-"`
 }
 
 type UpdateUserRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
-	Role     string `json:"role" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=user admin"`
+	// the caller, set by the handler
+	ActorId   string `json:"-"`
+	ActorRole string `json:"-"`
 }
 
-type UserResponse struct {
-	ID        string `json:"id"`
-	Username  string `json:"username"`
-	Password  string `json:"password"`
+// UserEvent is the data of the user events. ClientIp and UserAgent are only set for
+// logins.
+type UserEvent struct {
+	UserId    string `json:"user_id,omitempty"`
+	TenantId  string `json:"tenant_id,omitempty"`
+	Username  string `json:"username,omitempty"`
 	Email     string `json:"email"`
-	Role      string `json:"role"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Role      string `json:"role,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	ClientIp  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+func (u *User) ToUserEvent() UserEvent {
+	return UserEvent{
+		UserId:   u.ID,
+		TenantId: u.TenantId,
+		Username: u.Username,
+		Email:    u.Email,
+		Role:     u.Role,
+		Provider: u.Provider,
+	}
+}
+
+type UserResponse struct {
+	ID               string `json:"id"`
+	TenantId         string `json:"tenant_id"`
+	Username         string `json:"username"`
+	Password         string `json:"password"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	EmailVerified    bool   `json:"email_verified"`
+	Deactivated      bool   `json:"deactivated"`
+	DeactivatedAt    string `json:"deactivated_at,omitempty"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
 }
 
 type LoginUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	ClientIp  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type LoginResponse struct {
+	Token                  string `json:"token,omitempty"`
+	TwoFactorRequired      bool   `json:"two_factor_required"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required"`
+	ChallengeToken         string `json:"challenge_token,omitempty"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	ClientIp       string `json:"-"`
+	UserAgent      string `json:"-"`
+}
+
+type ImpersonateRequest struct {
+	Reason    string `json:"reason" validate:"required"`
+	ActorId   string `json:"-"`
+	SessionId string `json:"-"`
+	ClientIp  string `json:"-"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type EmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type OidcCallbackRequest struct {
+	Code      string `query:"code"`
+	State     string `query:"state"`
+	Error     string `query:"error"`
+	ClientIp  string `query:"-"`
+	UserAgent string `query:"-"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpAuthUri string `json:"otpauth_uri"`
+	QrPayload  string `json:"qr_payload"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 func (receiver *User) Create() *User {
@@ -59,6 +179,15 @@ func (receiver *User) Create() *User {
 	}
 }
 
+func (receiver *SignUpRequest) ToCreateUserRequest() CreateUserRequest {
+	return CreateUserRequest{
+		Username: receiver.Username,
+		Password: receiver.Password,
+		Email:    receiver.Email,
+		Role:     "user",
+	}
+}
+
 func (receiver *CreateUserRequest) ToUser() *User {
 	return &User{
 		ID:        uuid.New().String(),
@@ -71,6 +200,27 @@ func (receiver *CreateUserRequest) ToUser() *User {
 	}
 }
 
+// newExternalUser provisions an account for an identity managed elsewhere. The
+// random password keeps the local login unusable until a reset.
+func newExternalUser(provider, externalId, username, email, role string) (*User, error) {
+	password, _, err := newAccountToken()
+	if err != nil {
+		return nil, err
+	}
+	return &User{
+		ID:            uuid.New().String(),
+		Username:      username,
+		Password:      password,
+		Email:         email,
+		Role:          role,
+		EmailVerified: true,
+		Provider:      provider,
+		ExternalId:    externalId,
+		CreatedAt:     time.Now().Format("2006-01-02-15-04-05"),
+		UpdatedAt:     time.Now().Format("2006-01-02-15-04-05"),
+	}, nil
+}
+
 func (receiver *UpdateUserRequest) ToUser() *User {
 	return &User{
 		Username:  receiver.Username,
@@ -83,26 +233,78 @@ func (receiver *UpdateUserRequest) ToUser() *User {
 
 func (receiver *User) ToUserResponse() *UserResponse {
 	return &UserResponse{
-		ID:        receiver.ID,
-		Username:  receiver.Username,
-		Password:  receiver.Password,
-		Email:     receiver.Email,
-		Role:      receiver.Role,
-		CreatedAt: receiver.CreatedAt,
-		UpdatedAt: receiver.UpdatedAt,
+		ID:               receiver.ID,
+		TenantId:         receiver.TenantId,
+		Username:         receiver.Username,
+		Password:         receiver.Password,
+		Email:            receiver.Email,
+		Role:             receiver.Role,
+		TwoFactorEnabled: receiver.TwoFactor.Enabled,
+		EmailVerified:    receiver.EmailVerified,
+		Deactivated:      receiver.Deactivated,
+		DeactivatedAt:    receiver.DeactivatedAt,
+		CreatedAt:        receiver.CreatedAt,
+		UpdatedAt:        receiver.UpdatedAt,
 	}
 }
 
-func (receiver *User) HashPassword() {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(receiver.Password), 14)
+func (receiver *User) HashPassword(hasher passwordHasher) error {
+	hash, err := hasher.Hash(receiver.Password)
 	if err != nil {
-		log.Error("Hash Password Error : %v", err)
-		panic(err)
+		log.Errorf("Hash Password Error : %v", err)
+		return appError.Internal("password_not_hashed", "Service: failed to hash password").Wrap(err)
+	}
+	receiver.Password = hash
+	return nil
+}
+
+func (receiver *User) CheckPasswordHash(hasher passwordHasher, password string) bool {
+	return hasher.Verify(receiver.Password, password)
+}
+
+func newRecoveryCodes(count int) (codes []string, hashes []string, err error) {
+	for i := 0; i < count; i++ {
+		raw := make([]byte, 10)
+		if _, err = rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		code = code[:8] + "-" + code[8:16]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseRecoveryCode removes the matching recovery code so that it can not be used twice.
+func (receiver *TwoFactor) UseRecoveryCode(code string) bool {
+	hash := hashRecoveryCode(code)
+	for i, c := range receiver.RecoveryCodes {
+		if c == hash {
+			receiver.RecoveryCodes = append(receiver.RecoveryCodes[:i], receiver.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// newAccountToken returns a random token for e-mail links together with the hash
+// it is stored under, so a leaked store does not leak usable tokens.
+func newAccountToken() (token string, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return "", "", err
 	}
-	receiver.Password = string(bytes)
+	token = hex.EncodeToString(raw)
+	return token, hashAccountToken(token), nil
 }
 
-func (receiver *User) CheckPasswordHash(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(receiver.Password), []byte(password))
-	return err == nil
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
 }
@@ -4,16 +4,19 @@ import (
 	"context"
 	"time"
 
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type authRepository struct {
-	collection *mongo.Collection
+	collections mongoClient.TenantCollections
 }
 
 func (a authRepository) Create(ctx context.Context, user *User) (string, error) {
-	result, err := a.collection.InsertOne(ctx, user)
+	result, err := a.collections.Collection(user.TenantId).InsertOne(ctx, user)
 	if result.InsertedID == nil {
 		return "", err
 	}
@@ -23,39 +26,35 @@ func (a authRepository) Create(ctx context.Context, user *User) (string, error)
 func (a authRepository) Update(ctx context.Context, id string, user *User) (bool, error) {
 	filter := bson.M{"_id": id}
 	update := bson.M{"$set": bson.M{
-		"user":       user.Username,
-		"password":   user.Password,
-		"email":      user.Email,
-		"role":       user.Role,
-		"updated_at": time.Now().Format("2006-01-02-15-04-05"),
+		"user":           user.Username,
+		"password":       user.Password,
+		"email":          user.Email,
+		"role":           user.Role,
+		"email_verified": user.EmailVerified,
+		"updated_at":     time.Now().Format("2006-01-02-15-04-05"),
 	}}
-	updateResult, err := a.collection.UpdateOne(ctx, filter, update)
-	if updateResult.ModifiedCount < 1 {
+	updateResult, err := a.collections.UpdateOne(ctx, filter, update)
+	if err != nil || updateResult.ModifiedCount < 1 {
 		return false, err
 	}
 	return true, nil
 }
 
 func (a authRepository) Delete(ctx context.Context, id string) (bool, error) {
-	deleteResult, err := a.collection.DeleteOne(ctx, bson.M{"_id": id})
-	if deleteResult.DeletedCount < 1 {
+	deleteResult, err := a.collections.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil || deleteResult.DeletedCount < 1 {
 		return false, err
 	}
 	return true, nil
 }
 
 func (a authRepository) GetAll(ctx context.Context) ([]User, error) {
-	cursor, err := a.collection.Find(ctx, bson.M{})
-	users := make([]User, 0)
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, err
-	}
-	return users, nil
+	return mongoClient.FindAll[User](ctx, a.collections, bson.M{})
 }
 
 func (a authRepository) GetById(ctx context.Context, id string) (*User, error) {
 	user := new(User)
-	if err := a.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+	if err := a.collections.FindOne(ctx, bson.M{"_id": id}, user); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -63,21 +62,78 @@ func (a authRepository) GetById(ctx context.Context, id string) (*User, error) {
 
 func (a authRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	user := new(User)
-	if err := a.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+	if err := a.collections.FindOne(ctx, bson.M{"email": email}, user); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
 func (a authRepository) CheckEmail(ctx context.Context, email string) (bool, error) {
-	count, err := a.collection.CountDocuments(ctx, bson.M{"email": email})
+	count, err := a.collections.CountDocuments(ctx, bson.M{"email": email})
 	if err != nil {
 		return false, err
 	}
 	return count >= 1, nil
 }
 
-func NewAuthRepository(db *mongo.Database) Repository {
-	col := db.Collection("users")
-	return &authRepository{collection: col}
+func (a authRepository) UpdateTwoFactor(ctx context.Context, id string, twoFactor TwoFactor) (bool, error) {
+	return a.updateFields(ctx, id, bson.M{"two_factor": twoFactor})
+}
+
+func (a authRepository) UpdatePassword(ctx context.Context, id string, password string) (bool, error) {
+	return a.updateFields(ctx, id, bson.M{"password": password})
+}
+
+func (a authRepository) UpdateEmailVerified(ctx context.Context, id string, verified bool) (bool, error) {
+	return a.updateFields(ctx, id, bson.M{"email_verified": verified})
+}
+
+func (a authRepository) GetByExternalId(ctx context.Context, provider string, externalId string) (*User, error) {
+	user := new(User)
+	if err := a.collections.FindOne(ctx, bson.M{"provider": provider, "external_id": externalId}, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (a authRepository) UpdateExternalId(ctx context.Context, id string, provider string, externalId string) (bool, error) {
+	return a.updateFields(ctx, id, bson.M{"provider": provider, "external_id": externalId})
+}
+
+func (a authRepository) UpdateRole(ctx context.Context, id string, role string) (bool, error) {
+	return a.updateFields(ctx, id, bson.M{"role": role})
+}
+
+func (a authRepository) GetAllByProvider(ctx context.Context, provider string) ([]User, error) {
+	return mongoClient.FindAll[User](ctx, a.collections, bson.M{"provider": provider})
+}
+
+func (a authRepository) UpdateDeactivated(ctx context.Context, id string, deactivatedAt string) (bool, error) {
+	return a.updateFields(ctx, id, bson.M{"deactivated": deactivatedAt != "", "deactivated_at": deactivatedAt})
+}
+
+func (a authRepository) updateFields(ctx context.Context, id string, fields bson.M) (bool, error) {
+	fields["updated_at"] = time.Now().Format("2006-01-02-15-04-05")
+	updateResult, err := a.collections.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount > 0, nil
+}
+
+func (a authRepository) AssignTenant(ctx context.Context, tenantId string) (int64, error) {
+	if !helpers.AllTenants(ctx) {
+		return 0, helpers.ErrAllTenantsRequired
+	}
+	filter := bson.M{"tenant_id": bson.M{"$in": bson.A{nil, ""}}}
+	updateResult, err := a.collections.Collection("").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"tenant_id": tenantId}})
+	if err != nil {
+		return 0, err
+	}
+	return updateResult.ModifiedCount, nil
+}
+
+func NewAuthRepository(db *mongo.Database, settings config.TenantSettings) Repository {
+	collections := mongoClient.NewTenantCollections(db, "users", settings.Isolation == "separate")
+	return &authRepository{collections: collections}
 }
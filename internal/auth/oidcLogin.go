@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/oidc"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	oidcProvider = "oidc"
+	oidcStateKey = "doc-system:oidc:state:"
+)
+
+type oidcState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+func (a authService) OidcLoginUrl(ctx context.Context) (string, error) {
+	if a.oidc == nil {
+		return "", errOidcDisabled
+	}
+	state, errState := oidc.RandomString()
+	nonce, errNonce := oidc.RandomString()
+	verifier, errVerifier := oidc.RandomString()
+	if errState != nil || errNonce != nil || errVerifier != nil {
+		return "", appError.Internal("oidc_login_failed", "Service: failed to start oidc login")
+	}
+	if err := a.redis.SetWithExpiration(oidcStateKey+state, oidcState{Nonce: nonce, Verifier: verifier}, 10*time.Minute); err != nil {
+		return "", appError.Unavailable("oidc_login_failed", "Service: failed to start oidc login").Wrap(err)
+	}
+	url, err := a.oidc.AuthCodeUrl(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", appError.Unavailable("identity_provider_unreachable", "Service: identity provider is not reachable").Wrap(err)
+	}
+	return url, nil
+}
+
+func (a authService) OidcCallback(ctx context.Context, request OidcCallbackRequest) (*LoginResponse, error) {
+	if a.oidc == nil {
+		return nil, errOidcDisabled
+	}
+	if request.Error != "" {
+		return nil, appError.Unauthorized("identity_provider_rejected", "Service: identity provider rejected the login: "+request.Error)
+	}
+	state := oidcState{}
+	found, err := a.redis.GetAndDelete(oidcStateKey+request.State, &state)
+	if err != nil {
+		return nil, appError.Unavailable("oidc_state_unavailable", "Service: failed to read oidc state").Wrap(err)
+	}
+	if !found || request.Code == "" {
+		return nil, appError.Validation("oidc_state_invalid", "Service: oidc state is invalid or expired")
+	}
+
+	token, err := a.oidc.Exchange(ctx, request.Code, state.Verifier)
+	if err != nil {
+		return nil, appError.Unauthorized("oidc_exchange_failed", "Service: failed to exchange authorization code").Wrap(err)
+	}
+	claims, err := a.oidc.VerifyIdToken(ctx, token.IdToken, state.Nonce)
+	if err != nil {
+		return nil, appError.Unauthorized("invalid_id_token", "Service: invalid id token").Wrap(err)
+	}
+
+	user, err := a.provisionOidcUser(helpers.WithAllTenants(ctx), claims)
+	if err != nil {
+		return nil, err
+	}
+	return a.issueToken(ctx, user, request.ClientIp, request.UserAgent)
+}
+
+// provisionOidcUser finds the account of the external identity, links an existing
+// account with the same verified e-mail or creates one, and syncs its role from
+// the group claim.
+func (a authService) provisionOidcUser(ctx context.Context, claims jwt.MapClaims) (*User, error) {
+	settings := a.config.OidcSettings
+	subject, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	emailVerified, _ := claims["email_verified"].(bool)
+	if subject == "" {
+		return nil, appError.Unauthorized("invalid_id_token", "Service: id token has no subject")
+	}
+	role := a.oidcRole(claims)
+
+	user, err := a.repository.GetByExternalId(ctx, oidcProvider, subject)
+	if err != nil && email != "" && emailVerified {
+		if user, err = a.repository.GetByEmail(ctx, email); err == nil {
+			if _, err := a.repository.UpdateExternalId(ctx, user.ID, oidcProvider, subject); err != nil {
+				return nil, appError.Unavailable("identity_not_linked", "Service: failed to link external identity").Wrap(err)
+			}
+		}
+	}
+	if err != nil {
+		if !settings.AllowSignup || email == "" {
+			return nil, appError.Forbidden("no_account_for_identity", "Service: no account for this identity")
+		}
+		if exists, _ := a.repository.CheckEmail(ctx, email); exists {
+			return nil, appError.Forbidden("email_not_verified_by_provider", "Service: an account with this e-mail exists but the identity provider did not verify it")
+		}
+		return a.createExternalUser(ctx, oidcProvider, subject, oidcUsername(claims, email), email, role)
+	}
+	return user, a.syncRole(ctx, user, role)
+}
+
+// createExternalUser stores a new account for an identity of the provider.
+func (a authService) createExternalUser(ctx context.Context, provider, externalId, username, email, role string) (*User, error) {
+	user, err := newExternalUser(provider, externalId, username, email, role)
+	if err != nil {
+		return nil, appError.Internal("user_not_provisioned", "Service: failed to provision user").Wrap(err)
+	}
+	user.TenantId = a.tenantOf(ctx)
+	if err := user.HashPassword(a.hasher); err != nil {
+		return nil, err
+	}
+	if _, err := a.repository.Create(ctx, user); err != nil {
+		return nil, appError.Unavailable("user_not_provisioned", "Service: failed to provision user").Wrap(err)
+	}
+	log.Infof("Service: provisioned user %s from %s", user.ID, provider)
+	events.Publish(helpers.WithTenant(ctx, user.TenantId), a.publisher, events.UserCreated, user.ID, "", user.ToUserEvent())
+	return user, nil
+}
+
+func (a authService) syncRole(ctx context.Context, user *User, role string) error {
+	if user.Role == role {
+		return nil
+	}
+	if _, err := a.repository.UpdateRole(ctx, user.ID, role); err != nil {
+		return appError.Unavailable("role_not_synced", "Service: failed to sync user role").Wrap(err)
+	}
+	user.Role = role
+	return nil
+}
+
+func (a authService) oidcRole(claims jwt.MapClaims) string {
+	settings := a.config.OidcSettings
+	groups := make([]string, 0)
+	switch v := claims[settings.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if name, ok := g.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+	case string:
+		groups = append(groups, v)
+	}
+	return groupsToRole(groups, settings.GroupRoles, settings.DefaultRole)
+}
+
+// groupsToRole returns the role of the first mapping whose group the user belongs to.
+func groupsToRole(groups []string, mappings []config.GroupRole, defaultRole string) string {
+	member := map[string]bool{}
+	for _, g := range groups {
+		member[strings.ToLower(g)] = true
+	}
+	for _, mapping := range mappings {
+		if member[strings.ToLower(mapping.Group)] {
+			return mapping.Role
+		}
+	}
+	return defaultRole
+}
+
+func oidcUsername(claims jwt.MapClaims, email string) string {
+	for _, claim := range []string{"preferred_username", "name"} {
+		if name, ok := claims[claim].(string); ok && name != "" {
+			return name
+		}
+	}
+	return email
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordHasher hashes new passwords with the configured algorithm but verifies
+// hashes of every supported algorithm, so stored bcrypt hashes keep working after
+// switching to argon2id and get upgraded on the next login.
+type passwordHasher struct {
+	settings config.PasswordSettings
+}
+
+type argon2Params struct {
+	time      uint32
+	memory    uint32
+	threads   uint8
+	keyLength uint32
+}
+
+func newPasswordHasher(settings config.PasswordSettings) passwordHasher {
+	if settings.Hasher == "" {
+		settings.Hasher = "bcrypt"
+	}
+	if settings.BcryptCost == 0 {
+		settings.BcryptCost = 14
+	}
+	if settings.Argon2Time == 0 {
+		settings.Argon2Time = 3
+	}
+	if settings.Argon2Memory == 0 {
+		settings.Argon2Memory = 64 * 1024
+	}
+	if settings.Argon2Threads == 0 {
+		settings.Argon2Threads = 2
+	}
+	if settings.Argon2KeyLength == 0 {
+		settings.Argon2KeyLength = 32
+	}
+	return passwordHasher{settings: settings}
+}
+
+func (p passwordHasher) params() argon2Params {
+	return argon2Params{
+		time:      p.settings.Argon2Time,
+		memory:    p.settings.Argon2Memory,
+		threads:   p.settings.Argon2Threads,
+		keyLength: p.settings.Argon2KeyLength,
+	}
+}
+
+func (p passwordHasher) Hash(password string) (string, error) {
+	if p.settings.Hasher != "argon2id" {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.settings.BcryptCost)
+		return string(bytes), err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	params := p.params()
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, params.keyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (p passwordHasher) Verify(hash, password string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, params.keyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash reports whether the hash was made with another algorithm or weaker
+// parameters than the configured ones.
+func (p passwordHasher) NeedsRehash(hash string) bool {
+	if p.settings.Hasher == "argon2id" {
+		params, _, _, err := decodeArgon2Hash(hash)
+		return err != nil || params != p.params()
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < p.settings.BcryptCost
+}
+
+func decodeArgon2Hash(hash string) (params argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version")
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, err
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, err
+	}
+	params.keyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+type passwordPolicy struct {
+	settings config.PasswordSettings
+	breached map[string]struct{}
+}
+
+// newPasswordPolicy loads the breached password list, which holds plain passwords
+// or SHA-1 hex digests of them, one per line.
+func newPasswordPolicy(settings config.PasswordSettings) (passwordPolicy, error) {
+	policy := passwordPolicy{settings: settings, breached: map[string]struct{}{}}
+	if settings.BreachedListFile == "" {
+		return policy, nil
+	}
+	file, err := os.Open(settings.BreachedListFile)
+	if err != nil {
+		return policy, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !isSha1Hex(line) {
+			line = breachedDigest(strings.ToLower(line))
+		}
+		policy.breached[strings.ToLower(line)] = struct{}{}
+	}
+	return policy, scanner.Err()
+}
+
+func isSha1Hex(value string) bool {
+	if len(value) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
+
+func breachedDigest(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// isBreached matches the exact password against digest lists and its lower case
+// form against plain entries, which are compared case-insensitively.
+func (p passwordPolicy) isBreached(password string) bool {
+	if _, found := p.breached[breachedDigest(password)]; found {
+		return true
+	}
+	_, found := p.breached[breachedDigest(strings.ToLower(password))]
+	return found
+}
+
+// Validate returns a description of the first rule the password breaks.
+func (p passwordPolicy) Validate(password, email string) error {
+	if len([]rune(password)) < p.settings.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.settings.MinLength)
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	switch {
+	case p.settings.RequireUpper && !upper:
+		return fmt.Errorf("password must contain an upper case letter")
+	case p.settings.RequireLower && !lower:
+		return fmt.Errorf("password must contain a lower case letter")
+	case p.settings.RequireDigit && !digit:
+		return fmt.Errorf("password must contain a digit")
+	case p.settings.RequireSymbol && !symbol:
+		return fmt.Errorf("password must contain a symbol")
+	}
+	if email != "" && strings.EqualFold(password, email) {
+		return fmt.Errorf("password must not be equal to the e-mail address")
+	}
+	if p.isBreached(password) {
+		return fmt.Errorf("password appears in a list of breached passwords")
+	}
+	return nil
+}
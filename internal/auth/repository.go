@@ -12,4 +12,16 @@ type Repository interface {
 	GetById(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	CheckEmail(ctx context.Context, email string) (bool, error)
+	UpdateTwoFactor(ctx context.Context, id string, twoFactor TwoFactor) (bool, error)
+	UpdatePassword(ctx context.Context, id string, password string) (bool, error)
+	UpdateEmailVerified(ctx context.Context, id string, verified bool) (bool, error)
+	GetByExternalId(ctx context.Context, provider string, externalId string) (*User, error)
+	UpdateExternalId(ctx context.Context, id string, provider string, externalId string) (bool, error)
+	UpdateRole(ctx context.Context, id string, role string) (bool, error)
+	GetAllByProvider(ctx context.Context, provider string) ([]User, error)
+	// UpdateDeactivated deactivates the user at the given time, an empty one reactivates.
+	UpdateDeactivated(ctx context.Context, id string, deactivatedAt string) (bool, error)
+	// AssignTenant moves records created before organizations existed into the tenant.
+	// With separate isolation they stay in the shared index or collection.
+	AssignTenant(ctx context.Context, tenantId string) (int64, error)
 }
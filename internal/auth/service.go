@@ -2,9 +2,22 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/audit"
 	"github.com/hasanbakirci/doc-system/internal/config"
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/internal/session"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/ldapClient"
+	"github.com/hasanbakirci/doc-system/pkg/mail"
+	"github.com/hasanbakirci/doc-system/pkg/oidc"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	log "github.com/sirupsen/logrus"
 )
 
 type Service interface {
@@ -13,68 +26,694 @@ type Service interface {
 	Delete(ctx context.Context, id string) (bool, error)
 	GetAll(ctx context.Context) ([]UserResponse, error)
 	GetById(ctx context.Context, id string) (*UserResponse, error)
-	Login(ctx context.Context, request LoginUserRequest) (string, error)
+	Login(ctx context.Context, request LoginUserRequest) (*LoginResponse, error)
+	LoginTwoFactor(ctx context.Context, request TwoFactorLoginRequest) (*LoginResponse, error)
+	EnrollTwoFactor(ctx context.Context, id string) (*TwoFactorEnrollResponse, error)
+	ConfirmTwoFactor(ctx context.Context, id string, request TwoFactorCodeRequest) (*RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, id string, request TwoFactorCodeRequest) (bool, error)
+	RegenerateRecoveryCodes(ctx context.Context, id string, request TwoFactorCodeRequest) (*RecoveryCodesResponse, error)
+	Unlock(ctx context.Context, id string, actorId string) (bool, error)
+	Impersonate(ctx context.Context, id string, request ImpersonateRequest) (*LoginResponse, error)
+	Deactivate(ctx context.Context, id string, actorId string) (bool, error)
+	Reactivate(ctx context.Context, id string, actorId string) (bool, error)
+	TerminateSessions(ctx context.Context, id string) (int, error)
+	ValidateAccount(ctx context.Context, userId string) error
+	ForgotPassword(ctx context.Context, request EmailRequest) (bool, error)
+	ResetPassword(ctx context.Context, request ResetPasswordRequest) (bool, error)
+	VerifyEmail(ctx context.Context, request VerifyEmailRequest) (bool, error)
+	ResendVerification(ctx context.Context, request EmailRequest) (bool, error)
+	OidcLoginUrl(ctx context.Context) (string, error)
+	OidcCallback(ctx context.Context, request OidcCallbackRequest) (*LoginResponse, error)
 }
 
+const (
+	passwordResetKey     = "doc-system:password-reset:"
+	emailVerificationKey = "doc-system:email-verification:"
+	deactivatedKey       = "doc-system:deactivated:"
+	twoFactorUsedKey     = "doc-system:two-factor-used:"
+	// twoFactorUsedTtl outlives the window in which a totp code is accepted
+	twoFactorUsedTtl = 5 * time.Minute
+)
+
 type authService struct {
 	repository Repository
+	redis      *redisClient.RedisClient
+	guard      loginGuard
+	hasher     passwordHasher
+	policy     passwordPolicy
+	audit      audit.Service
+	sessions   session.Service
+	mail       mail.Sender
+	publisher  events.Publisher
+	oidc       *oidc.Provider
+	ldap       *ldapClient.LdapClient
 	config     config.Configuration
 }
 
-func (a authService) Login(ctx context.Context, request LoginUserRequest) (string, error) {
-	_, err := a.repository.CheckEmail(ctx, request.Email)
+var (
+	errUserNotFound         = appError.NotFound("user_not_found", "Service: user id not found")
+	errInvalidCredentials   = appError.Unauthorized("invalid_credentials", "Service: invalid e-mail or password")
+	errAccountDeactivated   = appError.Forbidden("account_deactivated", "Service: account is deactivated")
+	errTwoFactorNotEnabled  = appError.Validation("two_factor_not_enabled", "Service: two-factor authentication is not enabled")
+	errInvalidTwoFactorCode = appError.Unauthorized("invalid_two_factor_code", "Service: invalid two-factor code")
+	errTwoFactorNotUpdated  = appError.Unavailable("two_factor_not_updated", "Service: failed to update two-factor state")
+	errRecoveryCodes        = appError.Internal("recovery_codes_not_generated", "Service: failed to generate recovery codes")
+	errOidcDisabled         = appError.NotFound("oidc_disabled", "Service: oidc login is not enabled")
+)
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+func (a authService) dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = a.hasher.Hash("doc-system")
+	})
+	return dummyHash
+}
+
+// tenantOf returns the organization new records of the context belong to.
+func (a authService) tenantOf(ctx context.Context) string {
+	if tenantId := helpers.Tenant(ctx); tenantId != "" {
+		return tenantId
+	}
+	return a.config.TenantSettings.DefaultTenant
+}
+
+func (a authService) validatePassword(password, email string) error {
+	if err := a.policy.Validate(password, email); err != nil {
+		return appError.Validation("weak_password", "Service: "+err.Error())
+	}
+	return nil
+}
+
+// userById loads a user of the context's organization, reporting a missing one as
+// not found.
+func (a authService) userById(ctx context.Context, id string) (*User, error) {
+	user, err := a.repository.GetById(ctx, id)
 	if err != nil {
-		errorHandler.Panic(400, "Service: e-mail address already exists")
+		return nil, errUserNotFound.Wrap(err)
 	}
+	return user, nil
+}
 
-	user, e := a.repository.GetByEmail(ctx, request.Email)
-	if e != nil {
-		errorHandler.Panic(404, "Service: e-mail address was not found")
+func (a authService) Login(ctx context.Context, request LoginUserRequest) (*LoginResponse, error) {
+	// the e-mail address tells which organization the user belongs to
+	ctx = helpers.WithAllTenants(ctx)
+	if err := a.checkLoginGuard(request.Email, request.ClientIp); err != nil {
+		return nil, err
+	}
+
+	user, err := a.repository.GetByEmail(ctx, request.Email)
+	switch {
+	case a.ldap != nil && (err != nil || user.Provider == ldapProvider):
+		if user, err = a.ldapLogin(ctx, request.Email, request.Password); err != nil {
+			return nil, err
+		}
+		if user == nil {
+			a.loginFailed(ctx, request.Email, "", request.ClientIp)
+			return nil, errInvalidCredentials
+		}
+	case err != nil:
+		// compare against a dummy hash so unknown e-mails take as long as wrong passwords
+		a.hasher.Verify(a.dummyPasswordHash(), request.Password)
+		a.loginFailed(ctx, request.Email, "", request.ClientIp)
+		return nil, errInvalidCredentials
+	case !user.CheckPasswordHash(a.hasher, request.Password):
+		a.loginFailed(ctx, request.Email, user.ID, request.ClientIp)
+		return nil, errInvalidCredentials
+	case a.hasher.NeedsRehash(user.Password):
+		a.rehashPassword(ctx, user, request.Password)
+	}
+	if a.config.AccountSettings.RequireEmailVerification && !user.EmailVerified {
+		return nil, appError.Forbidden("email_not_verified", "Service: e-mail address is not verified")
+	}
+	response, err := a.issueToken(ctx, user, request.ClientIp, request.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	// only a login that got through every check resets the attempts
+	if err := a.guard.Succeed(user.Email); err != nil {
+		log.Errorf("Service: failed to reset login attempts: %v", err)
+	}
+	return response, nil
+}
+
+// rehashPassword upgrades a hash made with an outdated algorithm or parameters while
+// the plain password is at hand. Failures only postpone the upgrade to the next login.
+func (a authService) rehashPassword(ctx context.Context, user *User, password string) {
+	hash, err := a.hasher.Hash(password)
+	if err != nil {
+		log.Errorf("Service: failed to rehash password: %v", err)
+		return
+	}
+	if _, err := a.repository.UpdatePassword(ctx, user.ID, hash); err != nil {
+		log.Errorf("Service: failed to store rehashed password: %v", err)
+		return
+	}
+	user.Password = hash
+}
+
+func (a authService) checkLoginGuard(email, ip string) error {
+	err := a.guard.Check(email, ip)
+	if err == errLoginLocked {
+		return appError.TooManyRequests("login_locked", "Service: too many failed login attempts, try again later")
+	}
+	if err != nil {
+		return appError.Unavailable("login_unavailable", "Service: login is temporarily unavailable").Wrap(err)
+	}
+	return nil
+}
+
+// loginFailed counts a failed attempt and records an audit event when it locks the
+// account or the client ip. The user id is empty when the e-mail is unknown.
+func (a authService) loginFailed(ctx context.Context, email, userId, ip string) {
+	events.Publish(ctx, a.publisher, events.UserLoginFailed, userId, "", UserEvent{UserId: userId, Email: email, ClientIp: ip})
+	failure, err := a.guard.Fail(email, ip)
+	if err != nil {
+		log.Errorf("Service: failed to count login attempt: %v", err)
+		return
+	}
+	if failure.AccountLocked {
+		a.audit.Record(ctx, audit.NewEvent(audit.AccountLocked, "", userId, ip, map[string]string{"email": email}))
+	}
+	if failure.IpLocked {
+		a.audit.Record(ctx, audit.NewEvent(audit.IpLocked, "", userId, ip, map[string]string{"email": email}))
+	}
+}
+
+func (a authService) Unlock(ctx context.Context, id string, actorId string) (bool, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := a.guard.Unlock(user.Email); err != nil {
+		return false, appError.Unavailable("user_not_unlocked", "Service: failed to unlock user").Wrap(err)
+	}
+	a.audit.Record(ctx, audit.NewEvent(audit.AccountUnlocked, actorId, user.ID, "", map[string]string{"email": user.Email}))
+	return true, nil
+}
+
+// Impersonate issues a short-lived token acting as the user. Admin accounts cannot
+// be impersonated, so the token never grants more than the admin already has.
+func (a authService) Impersonate(ctx context.Context, id string, request ImpersonateRequest) (*LoginResponse, error) {
+	if id == request.ActorId {
+		return nil, appError.Validation("self_impersonation", "Service: cannot impersonate yourself")
+	}
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == "admin" {
+		return nil, appError.Forbidden("admin_impersonation", "Service: admin accounts cannot be impersonated")
+	}
+	if user.Deactivated {
+		return nil, errAccountDeactivated
+	}
+	a.audit.Record(ctx, audit.NewEvent(audit.ImpersonationStarted, request.ActorId, user.ID, request.ClientIp, map[string]string{
+		"email":  user.Email,
+		"reason": request.Reason,
+	}))
+	token := helpers.GenerateImpersonationToken(user.ID, user.Role, user.TenantId, request.ActorId, request.SessionId, a.config.JwtSettings)
+	return &LoginResponse{Token: token}, nil
+}
+
+// Deactivate blocks the user from logging in and ends their sessions. The flag is
+// mirrored to redis so that the token middleware can reject impersonation tokens,
+// scoped tokens and api keys without loading the user.
+func (a authService) Deactivate(ctx context.Context, id string, actorId string) (bool, error) {
+	if id == actorId {
+		return false, appError.Validation("self_deactivation", "Service: cannot deactivate yourself")
+	}
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if user.Deactivated {
+		return false, appError.Conflict("account_already_deactivated", "Service: account is already deactivated")
+	}
+	now := time.Now().Format("2006-01-02-15-04-05")
+	if result, err := a.repository.UpdateDeactivated(ctx, id, now); err != nil || !result {
+		return false, appError.Unavailable("user_not_deactivated", "Service: failed to deactivate user").Wrap(err)
+	}
+	if err := a.redis.SetWithExpiration(deactivatedKey+id, now, 0); err != nil {
+		log.Errorf("Service: failed to store deactivation: %v", err)
+	}
+	_, _ = a.sessions.DeleteAllByUser(ctx, id)
+	a.audit.Record(ctx, audit.NewEvent(audit.AccountDeactivated, actorId, user.ID, "", map[string]string{"email": user.Email}))
+	return true, nil
+}
+
+func (a authService) Reactivate(ctx context.Context, id string, actorId string) (bool, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !user.Deactivated {
+		return false, appError.Conflict("account_not_deactivated", "Service: account is not deactivated")
+	}
+	if result, err := a.repository.UpdateDeactivated(ctx, id, ""); err != nil || !result {
+		return false, appError.Unavailable("user_not_reactivated", "Service: failed to reactivate user").Wrap(err)
+	}
+	if err := a.redis.Delete(deactivatedKey + id); err != nil {
+		log.Errorf("Service: failed to drop deactivation: %v", err)
+	}
+	a.audit.Record(ctx, audit.NewEvent(audit.AccountReactivated, actorId, user.ID, "", map[string]string{"email": user.Email}))
+	return true, nil
+}
+
+// TerminateSessions signs the user out everywhere. The user is loaded first so that
+// admins can only reach users of their own organization.
+func (a authService) TerminateSessions(ctx context.Context, id string) (int, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return a.sessions.DeleteAllByUser(ctx, user.ID)
+}
+
+// ValidateAccount reports an error for deactivated users, and when the flag can not
+// be read, so tokens fail closed like their sessions do.
+func (a authService) ValidateAccount(ctx context.Context, userId string) error {
+	var deactivatedAt string
+	found, err := a.redis.Get(deactivatedKey+userId, &deactivatedAt)
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("account was deactivated at %s", deactivatedAt)
+	}
+	return nil
+}
+
+// issueToken hands out a session token, or a short-lived challenge token when the
+// user still has to pass (or set up) the second factor.
+func (a authService) issueToken(ctx context.Context, user *User, clientIp, userAgent string) (*LoginResponse, error) {
+	if user.Deactivated {
+		return nil, errAccountDeactivated
+	}
+	challengeTime := time.Duration(a.config.TwoFactorSettings.ChallengeTime) * time.Minute
+	if user.TwoFactor.Enabled {
+		return &LoginResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    helpers.GenerateScopedJwtToken(user.ID, user.Role, user.TenantId, helpers.TwoFactorLoginPurpose, challengeTime, a.config.JwtSettings),
+		}, nil
+	}
+	if a.isTwoFactorEnforced(user.Role) {
+		return &LoginResponse{
+			TwoFactorSetupRequired: true,
+			ChallengeToken:         helpers.GenerateScopedJwtToken(user.ID, user.Role, user.TenantId, helpers.TwoFactorSetupPurpose, challengeTime, a.config.JwtSettings),
+		}, nil
+	}
+	token, err := a.sessionToken(ctx, user, clientIp, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResponse{Token: token}, nil
+}
+
+// sessionToken starts a new login session and issues the token bound to it.
+func (a authService) sessionToken(ctx context.Context, user *User, clientIp, userAgent string) (string, error) {
+	started, err := a.sessions.Create(ctx, user.ID, clientIp, userAgent)
+	if err != nil {
+		return "", appError.Unavailable("session_not_created", "Service: failed to create session").Wrap(err)
+	}
+	event := user.ToUserEvent()
+	event.ClientIp, event.UserAgent = clientIp, userAgent
+	events.Publish(helpers.WithTenant(ctx, user.TenantId), a.publisher, events.UserLogin, user.ID, user.ID, event)
+	return helpers.GenerateJwtToken(user.ID, user.Role, user.TenantId, started.ID, a.config.JwtSettings), nil
+}
+
+func (a authService) isTwoFactorEnforced(role string) bool {
+	for _, r := range a.config.TwoFactorSettings.EnforcedRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (a authService) LoginTwoFactor(ctx context.Context, request TwoFactorLoginRequest) (*LoginResponse, error) {
+	claims := helpers.VerifyToken(request.ChallengeToken, a.config.JwtSettings.SecretKey)
+	if claims == nil || claims.Purpose != helpers.TwoFactorLoginPurpose {
+		return nil, appError.Unauthorized("invalid_challenge_token", "Service: invalid challenge token")
+	}
+	ctx = helpers.WithTenant(ctx, claims.TenantId)
+	user, err := a.userById(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor.Enabled {
+		return nil, errTwoFactorNotEnabled
+	}
+	if user.Deactivated {
+		return nil, errAccountDeactivated
+	}
+	if err := a.checkLoginGuard(user.Email, request.ClientIp); err != nil {
+		return nil, err
+	}
+	lastUsedStep := user.TwoFactor.LastUsedStep
+	if !a.verifyTwoFactorCode(&user.TwoFactor, request.Code, true) {
+		a.loginFailed(ctx, user.Email, user.ID, request.ClientIp)
+		return nil, errInvalidTwoFactorCode
+	}
+	// a totp code moves the last used step, a recovery code does not
+	use := "recovery:" + hashRecoveryCode(request.Code)
+	if user.TwoFactor.LastUsedStep != lastUsedStep {
+		use = "totp:" + strconv.FormatInt(user.TwoFactor.LastUsedStep, 10)
+	}
+	if err := a.claimTwoFactorCode(user.ID, use); err != nil {
+		return nil, err
+	}
+	if _, err := a.repository.UpdateTwoFactor(ctx, user.ID, user.TwoFactor); err != nil {
+		return nil, errTwoFactorNotUpdated.Wrap(err)
+	}
+	token, err := a.sessionToken(ctx, user, request.ClientIp, request.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResponse{Token: token}, nil
+}
+
+// claimTwoFactorCode marks the code as used in redis before the user is updated, so
+// concurrent logins that read the same state can not both use it.
+func (a authService) claimTwoFactorCode(userId, use string) error {
+	claimed, err := a.redis.SetIfAbsent(twoFactorUsedKey+userId+":"+use, true, twoFactorUsedTtl)
+	if err != nil {
+		return appError.Unavailable("two_factor_unavailable", "Service: failed to verify two-factor code").Wrap(err)
+	}
+	if !claimed {
+		return errInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifyTwoFactorCode accepts a totp code that was not used before and, when allowed,
+// one of the remaining recovery codes. The caller persists the updated state.
+func (a authService) verifyTwoFactorCode(twoFactor *TwoFactor, code string, allowRecovery bool) bool {
+	if step, ok := helpers.ValidateTotp(twoFactor.Secret, code, time.Now()); ok {
+		if step <= twoFactor.LastUsedStep {
+			return false
+		}
+		twoFactor.LastUsedStep = step
+		return true
+	}
+	return allowRecovery && twoFactor.UseRecoveryCode(code)
+}
+
+func (a authService) EnrollTwoFactor(ctx context.Context, id string) (*TwoFactorEnrollResponse, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor.Enabled {
+		return nil, appError.Conflict("two_factor_already_enabled", "Service: two-factor authentication is already enabled")
+	}
+	secret, err := helpers.GenerateTotpSecret()
+	if err != nil {
+		return nil, appError.Internal("two_factor_secret_not_generated", "Service: failed to generate two-factor secret").Wrap(err)
+	}
+	user.TwoFactor.PendingSecret = secret
+	if _, err := a.repository.UpdateTwoFactor(ctx, id, user.TwoFactor); err != nil {
+		return nil, errTwoFactorNotUpdated.Wrap(err)
 	}
-	if !user.CheckPasswordHash(request.Password) {
-		errorHandler.Panic(400, "Service: wrong password")
+	uri := helpers.TotpUri(a.config.TwoFactorSettings.Issuer, user.Email, secret)
+	return &TwoFactorEnrollResponse{Secret: secret, OtpAuthUri: uri, QrPayload: uri}, nil
+}
+
+func (a authService) ConfirmTwoFactor(ctx context.Context, id string, request TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor.PendingSecret == "" {
+		return nil, appError.Validation("two_factor_enrollment_not_started", "Service: two-factor enrollment was not started")
+	}
+	pending := TwoFactor{Secret: user.TwoFactor.PendingSecret}
+	if !a.verifyTwoFactorCode(&pending, request.Code, false) {
+		return nil, errInvalidTwoFactorCode
+	}
+	codes, hashes, err := newRecoveryCodes(a.config.TwoFactorSettings.RecoveryCodes)
+	if err != nil {
+		return nil, errRecoveryCodes.Wrap(err)
 	}
-	token := helpers.GenerateJwtToken(user.ID, user.Role, a.config.JwtSettings)
-	return token, nil
+	pending.Enabled = true
+	pending.RecoveryCodes = hashes
+	if _, err := a.repository.UpdateTwoFactor(ctx, id, pending); err != nil {
+		return nil, errTwoFactorNotUpdated.Wrap(err)
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
 }
 
+func (a authService) DisableTwoFactor(ctx context.Context, id string, request TwoFactorCodeRequest) (bool, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !user.TwoFactor.Enabled {
+		return false, errTwoFactorNotEnabled
+	}
+	if a.isTwoFactorEnforced(user.Role) {
+		return false, appError.Forbidden("two_factor_required", "Service: two-factor authentication is required for this role")
+	}
+	if !a.verifyTwoFactorCode(&user.TwoFactor, request.Code, true) {
+		return false, errInvalidTwoFactorCode
+	}
+	if result, err := a.repository.UpdateTwoFactor(ctx, id, TwoFactor{}); err != nil || !result {
+		return false, errTwoFactorNotUpdated.Wrap(err)
+	}
+	return true, nil
+}
+
+func (a authService) RegenerateRecoveryCodes(ctx context.Context, id string, request TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor.Enabled {
+		return nil, errTwoFactorNotEnabled
+	}
+	if !a.verifyTwoFactorCode(&user.TwoFactor, request.Code, false) {
+		return nil, errInvalidTwoFactorCode
+	}
+	codes, hashes, err := newRecoveryCodes(a.config.TwoFactorSettings.RecoveryCodes)
+	if err != nil {
+		return nil, errRecoveryCodes.Wrap(err)
+	}
+	user.TwoFactor.RecoveryCodes = hashes
+	if _, err := a.repository.UpdateTwoFactor(ctx, id, user.TwoFactor); err != nil {
+		return nil, errTwoFactorNotUpdated.Wrap(err)
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Create adds the user to the organization of the context, or to the default one
+// for sign-ups. E-mail addresses are unique across organizations since logins
+// look users up by address alone.
 func (a authService) Create(ctx context.Context, request CreateUserRequest) (string, error) {
-	status, _ := a.repository.CheckEmail(ctx, request.Email)
+	status, _ := a.repository.CheckEmail(helpers.WithAllTenants(ctx), request.Email)
 	if status {
-		errorHandler.Panic(400, "Service: email already exists")
+		return "", appError.Conflict("email_exists", "Service: email already exists")
+	}
+	if err := a.validatePassword(request.Password, request.Email); err != nil {
+		return "", err
 	}
 	user := request.ToUser()
-	user.HashPassword()
+	user.TenantId = a.tenantOf(ctx)
+	if err := user.HashPassword(a.hasher); err != nil {
+		return "", err
+	}
+	user.EmailVerified = request.EmailVerified || !a.config.AccountSettings.RequireEmailVerification
 
 	id, e := a.repository.Create(ctx, user)
 	if e != nil {
-		errorHandler.Panic(404, "Service: failed to create user")
+		return "", appError.Unavailable("user_not_created", "Service: failed to create user").Wrap(e)
+	}
+	user.ID = id
+	events.Publish(helpers.WithTenant(ctx, user.TenantId), a.publisher, events.UserCreated, id, helpers.Actor(ctx), user.ToUserEvent())
+	if !user.EmailVerified {
+		a.sendVerification(ctx, user)
 	}
 	return id, nil
 }
 
+// ForgotPassword always reports success so the response does not reveal whether
+// the e-mail address belongs to an account.
+func (a authService) ForgotPassword(ctx context.Context, request EmailRequest) (bool, error) {
+	ctx = helpers.WithAllTenants(ctx)
+	user, err := a.repository.GetByEmail(ctx, request.Email)
+	if err != nil {
+		return true, nil
+	}
+	ttl := time.Duration(a.config.AccountSettings.ResetTokenTime) * time.Minute
+	token, err := a.storeAccountToken(passwordResetKey, user.ID, ttl)
+	if err != nil {
+		log.Errorf("Service: failed to store password reset token: %v", err)
+		return true, nil
+	}
+	a.sendMail(ctx, user.Email, "Reset your doc-system password", fmt.Sprintf(
+		"Hello %s,\n\nUse the link below to choose a new password. It expires in %d minutes.\n\n%s/reset-password?token=%s\n\nIf you did not ask for a reset you can ignore this e-mail.\n",
+		user.Username, a.config.AccountSettings.ResetTokenTime, a.config.AccountSettings.BaseUrl, token))
+	return true, nil
+}
+
+func (a authService) ResetPassword(ctx context.Context, request ResetPasswordRequest) (bool, error) {
+	ctx = helpers.WithAllTenants(ctx)
+	id, err := a.consumeAccountToken(passwordResetKey, request.Token)
+	if err != nil {
+		return false, err
+	}
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := a.validatePassword(request.Password, user.Email); err != nil {
+		return false, err
+	}
+	user.Password = request.Password
+	if err := user.HashPassword(a.hasher); err != nil {
+		return false, err
+	}
+	if result, err := a.repository.UpdatePassword(ctx, user.ID, user.Password); err != nil || !result {
+		return false, appError.Unavailable("password_not_reset", "Service: failed to reset password").Wrap(err)
+	}
+	if err := a.guard.Unlock(user.Email); err != nil {
+		log.Errorf("Service: failed to reset login attempts: %v", err)
+	}
+	// whoever knew the old password may still hold a token
+	_, _ = a.sessions.DeleteAllByUser(ctx, user.ID)
+	return true, nil
+}
+
+func (a authService) VerifyEmail(ctx context.Context, request VerifyEmailRequest) (bool, error) {
+	ctx = helpers.WithAllTenants(ctx)
+	id, err := a.consumeAccountToken(emailVerificationKey, request.Token)
+	if err != nil {
+		return false, err
+	}
+	if result, err := a.repository.UpdateEmailVerified(ctx, id, true); err != nil || !result {
+		return false, appError.NotFound("email_not_verified", "Service: failed to verify e-mail address").Wrap(err)
+	}
+	return true, nil
+}
+
+func (a authService) ResendVerification(ctx context.Context, request EmailRequest) (bool, error) {
+	ctx = helpers.WithAllTenants(ctx)
+	user, err := a.repository.GetByEmail(ctx, request.Email)
+	if err == nil && !user.EmailVerified {
+		a.sendVerification(ctx, user)
+	}
+	return true, nil
+}
+
+func (a authService) sendVerification(ctx context.Context, user *User) {
+	ttl := time.Duration(a.config.AccountSettings.VerificationTokenTime) * time.Minute
+	token, err := a.storeAccountToken(emailVerificationKey, user.ID, ttl)
+	if err != nil {
+		log.Errorf("Service: failed to store e-mail verification token: %v", err)
+		return
+	}
+	a.sendMail(ctx, user.Email, "Verify your doc-system e-mail address", fmt.Sprintf(
+		"Hello %s,\n\nPlease confirm your e-mail address with the link below.\n\n%s/verify-email?token=%s\n",
+		user.Username, a.config.AccountSettings.BaseUrl, token))
+}
+
+func (a authService) sendMail(ctx context.Context, to, subject, body string) {
+	if err := a.mail.Send(ctx, mail.Message{To: []string{to}, Subject: subject, Body: body}); err != nil {
+		log.Errorf("Service: failed to send %q e-mail: %v", subject, err)
+	}
+}
+
+func (a authService) storeAccountToken(prefix, id string, ttl time.Duration) (string, error) {
+	token, hash, err := newAccountToken()
+	if err != nil {
+		return "", err
+	}
+	return token, a.redis.SetWithExpiration(prefix+hash, id, ttl)
+}
+
+// consumeAccountToken resolves a single-use token to its user id and removes it.
+func (a authService) consumeAccountToken(prefix, token string) (string, error) {
+	var id string
+	found, err := a.redis.GetAndDelete(prefix+hashAccountToken(token), &id)
+	if err != nil {
+		return "", appError.Unavailable("token_unavailable", "Service: failed to read token").Wrap(err)
+	}
+	if !found {
+		return "", appError.Validation("token_invalid", "Service: token is invalid or expired")
+	}
+	return id, nil
+}
+
+// Update lets admins edit every account of their organization and users only their
+// own, without changing its role.
 func (a authService) Update(ctx context.Context, id string, request UpdateUserRequest) (bool, error) {
+	if request.ActorRole != "admin" && request.ActorId != id {
+		return false, appError.Forbidden("user_update_not_allowed", "Service: users can only update their own account")
+	}
+	current, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if request.ActorRole != "admin" && request.Role != current.Role {
+		return false, appError.Forbidden("role_change_not_allowed", "Service: only admins can change roles")
+	}
+	// addresses are unique across organizations, like on sign-up
+	if request.Email != current.Email {
+		status, err := a.repository.CheckEmail(helpers.WithAllTenants(ctx), request.Email)
+		if err != nil {
+			return false, appError.Unavailable("email_not_checked", "Service: failed to check email").Wrap(err)
+		}
+		if status {
+			return false, appError.Conflict("email_exists", "Service: email already exists")
+		}
+	}
+	if err := a.validatePassword(request.Password, request.Email); err != nil {
+		return false, err
+	}
 	user := request.ToUser()
-	user.HashPassword()
-	result, _ := a.repository.Update(ctx, id, user)
+	if err := user.HashPassword(a.hasher); err != nil {
+		return false, err
+	}
+	// a new address has to be verified again, like on sign-up
+	emailChanged := request.Email != current.Email
+	user.EmailVerified = current.EmailVerified
+	if emailChanged {
+		user.EmailVerified = !a.config.AccountSettings.RequireEmailVerification
+	}
+	result, err := a.repository.Update(ctx, id, user)
+	if err != nil {
+		return false, appError.Unavailable("user_not_updated", "Service: failed to update user").Wrap(err)
+	}
 	if !result {
-		errorHandler.Panic(404, "Service: failed to update user")
+		return false, errUserNotFound
+	}
+	if emailChanged && !user.EmailVerified {
+		user.ID = id
+		a.sendVerification(ctx, user)
 	}
 	return result, nil
 }
 
 func (a authService) Delete(ctx context.Context, id string) (bool, error) {
-	result, _ := a.repository.Delete(ctx, id)
-	if !result {
-		errorHandler.Panic(404, "Service: failed to delete user")
+	user, err := a.userById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	result, err := a.repository.Delete(ctx, id)
+	if err != nil || !result {
+		return false, errUserNotFound.Wrap(err)
 	}
+	_, _ = a.sessions.DeleteAllByUser(ctx, id)
+	events.Publish(ctx, a.publisher, events.UserDeleted, id, helpers.Actor(ctx), user.ToUserEvent())
 	return true, nil
 }
 
 func (a authService) GetAll(ctx context.Context) ([]UserResponse, error) {
 	users, err := a.repository.GetAll(ctx)
 	if err != nil {
-		errorHandler.Panic(404, err.Error())
+		return nil, appError.NotFound("users_not_found", err.Error())
 	}
 	userResponses := make([]UserResponse, 0)
 	for i := 0; i < len(users); i++ {
@@ -85,14 +724,42 @@ func (a authService) GetAll(ctx context.Context) ([]UserResponse, error) {
 }
 
 func (a authService) GetById(ctx context.Context, id string) (*UserResponse, error) {
-	user, err := a.repository.GetById(ctx, id)
+	user, err := a.userById(ctx, id)
 	if err != nil {
-		errorHandler.Panic(404, "Service: user id not found")
+		return nil, err
 	}
-	result := user.ToUserResponse()
-	return result, nil
+	return user.ToUserResponse(), nil
 }
 
-func NewAuthService(repo Repository, cfg config.Configuration) Service {
-	return &authService{repository: repo, config: cfg}
+func NewAuthService(repo Repository, redis *redisClient.RedisClient, auditService audit.Service, sessionService session.Service, sender mail.Sender, publisher events.Publisher, cfg config.Configuration) Service {
+	policy, err := newPasswordPolicy(cfg.PasswordSettings)
+	if err != nil {
+		log.Errorf("Service: failed to load password policy: %v", err)
+		panic(err)
+	}
+	service := &authService{
+		repository: repo,
+		redis:      redis,
+		guard:      newLoginGuard(redis, cfg.LockoutSettings),
+		hasher:     newPasswordHasher(cfg.PasswordSettings),
+		policy:     policy,
+		audit:      auditService,
+		sessions:   sessionService,
+		mail:       sender,
+		publisher:  publisher,
+		config:     cfg,
+	}
+	if cfg.OidcSettings.Enabled {
+		service.oidc = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OidcSettings.Issuer,
+			ClientId:     cfg.OidcSettings.ClientId,
+			ClientSecret: cfg.OidcSettings.ClientSecret,
+			RedirectUrl:  cfg.OidcSettings.RedirectUrl,
+			Scopes:       cfg.OidcSettings.Scopes,
+		}, nil)
+	}
+	if cfg.LdapSettings.Enabled {
+		service.ldap = ldapClient.NewLdapClient(cfg.LdapSettings)
+	}
+	return service
 }
@@ -11,9 +11,25 @@ var (
 )
 
 type Configuration struct {
-	MongoSettings MongoSettings
-	JwtSettings   JwtSettings
-	RedisSettings RedisSettings
+	MongoSettings        MongoSettings
+	JwtSettings          JwtSettings
+	RedisSettings        RedisSettings
+	TwoFactorSettings    TwoFactorSettings
+	LockoutSettings      LockoutSettings
+	MailSettings         MailSettings
+	AccountSettings      AccountSettings
+	PasswordSettings     PasswordSettings
+	ApiKeySettings       ApiKeySettings
+	OidcSettings         OidcSettings
+	LdapSettings         LdapSettings
+	TenantSettings       TenantSettings
+	OutboxSettings       OutboxSettings
+	EventSettings        EventSettings
+	NatsSettings         NatsSettings
+	ConsumerSettings     ConsumerSettings
+	WebhookSettings      WebhookSettings
+	StreamSettings       StreamSettings
+	NotificationSettings NotificationSettings
 }
 
 type MongoSettings struct {
@@ -22,14 +38,221 @@ type MongoSettings struct {
 	Timeout      int
 }
 
+// JwtSettings sign the tokens; SessionTime is in hours, ImpersonationTime in minutes.
 type JwtSettings struct {
-	SessionTime int
-	SecretKey   string
+	SessionTime       int
+	ImpersonationTime int
+	SecretKey         string
 }
 
+// RedisSettings locate redis and the stream the document events are written to, read
+// by the consumer command through ConsumerGroup. Consumer names the instance inside
+// the group and defaults to the host name and process id. ClaimIdle, in seconds, is
+// how long an entry may stay unacknowledged before another consumer takes it over.
 type RedisSettings struct {
-	Channel string
-	Uri     string
+	Channel       string
+	Uri           string
+	Stream        string
+	MaxLen        int64
+	ConsumerGroup string
+	Consumer      string
+	BatchSize     int64
+	ClaimIdle     int
+}
+
+// TwoFactorSettings ChallengeTime is in minutes.
+type TwoFactorSettings struct {
+	Issuer        string
+	EnforcedRoles []string
+	ChallengeTime int
+	RecoveryCodes int
+}
+
+// LockoutSettings windows are in minutes, delays in seconds.
+type LockoutSettings struct {
+	MaxAccountAttempts int
+	MaxIpAttempts      int
+	AttemptWindow      int
+	LockoutTime        int
+	DelayAfter         int
+	DelayBase          int
+	MaxDelay           int
+}
+
+type MailSettings struct {
+	Driver    string
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	Directory string
+}
+
+// AccountSettings token times are in minutes.
+type AccountSettings struct {
+	BaseUrl                  string
+	RequireEmailVerification bool
+	ResetTokenTime           int
+	VerificationTokenTime    int
+	InvitationTokenTime      int
+}
+
+// PasswordSettings Hasher is "bcrypt" or "argon2id", Argon2Memory is in KiB.
+type PasswordSettings struct {
+	MinLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BreachedListFile string
+	Hasher           string
+	BcryptCost       int
+	Argon2Time       uint32
+	Argon2Memory     uint32
+	Argon2Threads    uint8
+	Argon2KeyLength  uint32
+}
+
+// ApiKeySettings lifetimes are in days.
+type ApiKeySettings struct {
+	DefaultLifetime int
+	MaxLifetime     int
+}
+
+type OidcSettings struct {
+	Enabled      bool
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+	GroupsClaim  string
+	GroupRoles   []GroupRole
+	DefaultRole  string
+	AllowSignup  bool
+}
+
+// EventSettings choose the event bus with Bus: "redis-streams", "redis-pubsub",
+// "nats" or "memory". The memory bus only reaches subscribers of its own process, so
+// it needs InProcessConsumer, which runs the consumer inside the api command.
+type EventSettings struct {
+	Bus               string
+	InProcessConsumer bool
+}
+
+// ConsumerSettings control the consumers. A handler is tried MaxAttempts times
+// before its event is moved to the DeadLetterStream. Every consumer handles its
+// events on Workers goroutines, keeping the order of events about the same document
+// or user. On shutdown in-flight events get DrainTimeout to finish. RetryBase,
+// MaxRetryDelay, RestartDelay, the pause before a failed consumer loop is restarted,
+// and DrainTimeout are in seconds.
+type ConsumerSettings struct {
+	MaxAttempts      int
+	RetryBase        int
+	MaxRetryDelay    int
+	DeadLetterStream string
+	RestartDelay     int
+	Workers          int
+	DrainTimeout     int
+}
+
+// NatsSettings locate the JetStream server and Stream, the stream holding the events
+// published under Subject, e.g. "doc-system.events.document.created". MaxAge is in
+// hours.
+type NatsSettings struct {
+	Url     string
+	Stream  string
+	Subject string
+	MaxAge  int
+}
+
+// OutboxSettings control the relay that delivers the outbox. PollInterval,
+// RetryBase, MaxRetryDelay and ClaimTimeout are in seconds. An entry claimed by a
+// relay that died is delivered again once ClaimTimeout has passed. Delivered entries
+// are deleted RetentionHours after their delivery; 0 keeps them.
+type OutboxSettings struct {
+	PollInterval   int
+	BatchSize      int
+	MaxAttempts    int
+	RetryBase      int
+	MaxRetryDelay  int
+	ClaimTimeout   int
+	RetentionHours int
+}
+
+// WebhookSettings control the webhook deliveries, queued by the bus group
+// ConsumerGroup. A delivery is tried MaxAttempts times and a webhook is disabled
+// after DisableAfter deliveries in a row failed. A delivery claimed by a dispatcher
+// that died is sent again once ClaimTimeout has passed. PollInterval, RetryBase,
+// MaxRetryDelay, Timeout and ClaimTimeout are in seconds.
+type WebhookSettings struct {
+	ConsumerGroup string
+	PollInterval  int
+	BatchSize     int
+	MaxAttempts   int
+	RetryBase     int
+	MaxRetryDelay int
+	Timeout       int
+	DisableAfter  int
+	ClaimTimeout  int
+}
+
+// StreamSettings configure the live event streams of the api. They tail
+// RedisSettings.Stream, which only the "redis-streams" bus writes to, so the api
+// refuses to start with any other bus. Heartbeat is the interval in seconds of SSE
+// keep-alive comments and WebSocket pings; a WebSocket that does not answer two
+// pings in a row is closed. A client that falls more than Buffer events behind is
+// disconnected and has to resume with its last event id.
+type StreamSettings struct {
+	Heartbeat int
+	Buffer    int
+}
+
+// NotificationSettings control the notifications, made from events by the bus group
+// ConsumerGroup. Users who asked for a digest get their unread notifications by
+// e-mail every DigestInterval hours; due digests are looked for every
+// DigestPollInterval minutes.
+type NotificationSettings struct {
+	ConsumerGroup      string
+	DigestInterval     int
+	DigestPollInterval int
+}
+
+// TenantSettings keep the organizations apart. Isolation is "field" to keep them all
+// in shared indexes and collections, or "separate" for an index and collection each.
+// SystemAdmins are the ids of the users who manage all organizations and the dead
+// letters; belonging to the default organization grants nothing.
+type TenantSettings struct {
+	DefaultTenant string
+	Isolation     string
+	SystemAdmins  []string
+}
+
+// LdapSettings filters take the escaped login (UserFilter) or user dn (GroupFilter)
+// as their only %s argument, SyncInterval is in minutes.
+type LdapSettings struct {
+	Enabled            bool
+	Url                string
+	StartTls           bool
+	InsecureSkipVerify bool
+	BindDn             string
+	BindPassword       string
+	BaseDn             string
+	UserFilter         string
+	UsernameAttribute  string
+	EmailAttribute     string
+	GroupBaseDn        string
+	GroupFilter        string
+	GroupAttribute     string
+	GroupRoles         []GroupRole
+	DefaultRole        string
+	SyncInterval       int
+}
+
+type GroupRole struct {
+	Group string
+	Role  string
 }
 
 type configReader struct {
@@ -0,0 +1,87 @@
+package deadletter
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service      Service
+	systemAdmins []string
+}
+
+func (h Handler) getAllDeadLetters(c echo.Context) error {
+	var limit int64
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return appError.Validation("invalid_limit", "The limit must be a number").Wrap(err)
+		}
+		limit = parsed
+	}
+	result, err := h.service.GetAll(c.Request().Context(), limit)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getByIdDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.GetById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) replayDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.Replay(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) replayAllDeadLetters(c echo.Context) error {
+	result, err := h.service.ReplayAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) deleteDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.Delete(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func NewDeadLetterHandler(s Service, systemAdmins []string) Handler {
+	return Handler{service: s, systemAdmins: systemAdmins}
+}
+
+// RegisterDeadLetterHandlers is limited to system admins since dead letters hold the
+// events of every organization.
+func RegisterDeadLetterHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	// system admins may belong to any organization and have any role there
+	signedIn := authenticator.TokenHandlerMiddlewareFunc()
+	systemAdmin := middleware.SystemAdminMiddlewareFunc(h.systemAdmins)
+	instance.GET("api/dead-letters", h.getAllDeadLetters, signedIn, systemAdmin)
+	instance.GET("api/dead-letters/:id", h.getByIdDeadLetter, signedIn, systemAdmin)
+	instance.POST("api/dead-letters/replay", h.replayAllDeadLetters, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/dead-letters/:id/replay", h.replayDeadLetter, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.DELETE("api/dead-letters/:id", h.deleteDeadLetter, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+}
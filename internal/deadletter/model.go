@@ -0,0 +1,54 @@
+package deadletter
+
+import (
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/events"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+// DeadLetter is an event a consumer group gave up on after Attempts tries. ID is
+// the id of the entry in the dead-letter stream.
+type DeadLetter struct {
+	ID       string        `json:"-"`
+	Group    string        `json:"group"`
+	Event    *events.Event `json:"event"`
+	Error    string        `json:"error"`
+	Attempts int           `json:"attempts"`
+	FailedAt string        `json:"failed_at"`
+}
+
+type DeadLetterResponse struct {
+	ID       string        `json:"id"`
+	Group    string        `json:"group"`
+	Event    *events.Event `json:"event"`
+	Error    string        `json:"error"`
+	Attempts int           `json:"attempts"`
+	FailedAt string        `json:"failed_at"`
+}
+
+type ReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+func NewDeadLetter(group string, event *events.Event, err error, attempts int) *DeadLetter {
+	return &DeadLetter{
+		Group:    group,
+		Event:    event,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now().Format(timeLayout),
+	}
+}
+
+func (d *DeadLetter) ToDeadLetterResponse() *DeadLetterResponse {
+	return &DeadLetterResponse{
+		ID:       d.ID,
+		Group:    d.Group,
+		Event:    d.Event,
+		Error:    d.Error,
+		Attempts: d.Attempts,
+		FailedAt: d.FailedAt,
+	}
+}
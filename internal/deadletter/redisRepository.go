@@ -0,0 +1,73 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	"github.com/pkg/errors"
+)
+
+// redisRepository keeps dead letters in a Redis Stream of their own, which is never
+// trimmed so nothing is lost before an admin looked at it.
+type redisRepository struct {
+	redis  *redisClient.RedisClient
+	stream string
+}
+
+func (r redisRepository) Create(ctx context.Context, deadLetter *DeadLetter) (string, error) {
+	id, err := r.redis.AddToStream(r.stream, 0, deadLetter)
+	if err != nil {
+		return "", err
+	}
+	deadLetter.ID = id
+	return id, nil
+}
+
+func (r redisRepository) GetAll(ctx context.Context, limit int64) ([]DeadLetter, error) {
+	messages, err := r.redis.RangeStream(r.stream, "-", limit)
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := make([]DeadLetter, 0, len(messages))
+	for _, message := range messages {
+		deadLetter, err := decode(message)
+		if err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, *deadLetter)
+	}
+	return deadLetters, nil
+}
+
+func (r redisRepository) GetById(ctx context.Context, id string) (*DeadLetter, error) {
+	messages, err := r.redis.RangeStream(r.stream, id, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 || messages[0].ID != id {
+		return nil, errors.New("Redis repository: dead letter not found")
+	}
+	return decode(messages[0])
+}
+
+func (r redisRepository) Delete(ctx context.Context, id string) (bool, error) {
+	deleted, err := r.redis.DeleteFromStream(r.stream, id)
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
+func decode(message redisClient.StreamMessage) (*DeadLetter, error) {
+	deadLetter := new(DeadLetter)
+	if err := json.Unmarshal([]byte(message.Payload), deadLetter); err != nil {
+		return nil, err
+	}
+	deadLetter.ID = message.ID
+	return deadLetter, nil
+}
+
+func NewRedisRepository(redis *redisClient.RedisClient, stream string) Repository {
+	return &redisRepository{redis: redis, stream: stream}
+}
@@ -0,0 +1,13 @@
+package deadletter
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, deadLetter *DeadLetter) (string, error)
+	// GetAll returns up to limit dead letters, oldest first.
+	GetAll(ctx context.Context, limit int64) ([]DeadLetter, error)
+	GetById(ctx context.Context, id string) (*DeadLetter, error)
+	Delete(ctx context.Context, id string) (bool, error)
+}
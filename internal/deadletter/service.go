@@ -0,0 +1,107 @@
+package deadletter
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+)
+
+type Service interface {
+	GetAll(ctx context.Context, limit int64) ([]DeadLetterResponse, error)
+	GetById(ctx context.Context, id string) (*DeadLetterResponse, error)
+	Replay(ctx context.Context, id string) (bool, error)
+	ReplayAll(ctx context.Context) (*ReplayResponse, error)
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+const (
+	defaultLimit = 100
+	// replayBatch bounds how many dead letters ReplayAll moves per call.
+	replayBatch = 1000
+)
+
+var errDeadLetterNotFound = appError.NotFound("dead_letter_not_found", "Service: dead letter not found")
+
+type deadLetterService struct {
+	repository Repository
+	publisher  events.Publisher
+}
+
+func (d deadLetterService) GetAll(ctx context.Context, limit int64) ([]DeadLetterResponse, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	deadLetters, err := d.repository.GetAll(ctx, limit)
+	if err != nil {
+		return nil, appError.Unavailable("dead_letters_unavailable", "Service: failed to read dead letters").Wrap(err)
+	}
+	deadLetterResponses := make([]DeadLetterResponse, 0)
+	for i := 0; i < len(deadLetters); i++ {
+		deadLetterResponses = append(deadLetterResponses, *deadLetters[i].ToDeadLetterResponse())
+	}
+	return deadLetterResponses, nil
+}
+
+func (d deadLetterService) GetById(ctx context.Context, id string) (*DeadLetterResponse, error) {
+	deadLetter, err := d.repository.GetById(ctx, id)
+	if err != nil {
+		return nil, errDeadLetterNotFound.Wrap(err)
+	}
+	return deadLetter.ToDeadLetterResponse(), nil
+}
+
+// Replay publishes the event again and drops the dead letter. Every consumer group
+// receives the replayed event, so handlers have to tolerate events they already
+// handled.
+func (d deadLetterService) Replay(ctx context.Context, id string) (bool, error) {
+	deadLetter, err := d.repository.GetById(ctx, id)
+	if err != nil {
+		return false, errDeadLetterNotFound.Wrap(err)
+	}
+	if err := d.replay(ctx, deadLetter); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d deadLetterService) ReplayAll(ctx context.Context) (*ReplayResponse, error) {
+	deadLetters, err := d.repository.GetAll(ctx, replayBatch)
+	if err != nil {
+		return nil, appError.Unavailable("dead_letters_unavailable", "Service: failed to read dead letters").Wrap(err)
+	}
+	response := &ReplayResponse{}
+	for i := range deadLetters {
+		if err := d.replay(ctx, &deadLetters[i]); err != nil {
+			return response, err
+		}
+		response.Replayed++
+	}
+	return response, nil
+}
+
+func (d deadLetterService) replay(ctx context.Context, deadLetter *DeadLetter) error {
+	if err := d.publisher.Publish(ctx, deadLetter.Event); err != nil {
+		return appError.Unavailable("dead_letter_not_replayed", "Service: failed to replay dead letter").Wrap(err)
+	}
+	if _, err := d.repository.Delete(ctx, deadLetter.ID); err != nil {
+		return appError.Unavailable("dead_letter_not_deleted", "Service: failed to delete replayed dead letter").Wrap(err)
+	}
+	return nil
+}
+
+func (d deadLetterService) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := d.repository.Delete(ctx, id)
+	if err != nil {
+		return false, appError.Unavailable("dead_letter_not_deleted", "Service: failed to delete dead letter").Wrap(err)
+	}
+	if !result {
+		return false, errDeadLetterNotFound
+	}
+	return true, nil
+}
+
+// NewDeadLetterService replays through the publisher, the outbox in the api.
+func NewDeadLetterService(repo Repository, publisher events.Publisher) Service {
+	return &deadLetterService{repository: repo, publisher: publisher}
+}
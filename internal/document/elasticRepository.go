@@ -7,28 +7,32 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hasanbakirci/doc-system/internal/config"
 	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/pkg/errors"
 )
 
 type elasticRepository struct {
 	client *elasticsearch.Client
-	index  string
-	alias  string
+	scope  elasticclient.TenantScope
 }
 
 // Create implements Repository
 func (e *elasticRepository) Create(ctx context.Context, document *Document) (string, error) {
-	exists, err := e.client.Indices.Exists([]string{e.index})
+	index, alias := e.scope.WriteIndex(document.TenantId)
+	exists, err := e.client.Indices.Exists([]string{index})
+	if err != nil {
+		return "", err
+	}
 	if exists.StatusCode != 200 {
 		settings := map[string]interface{}{
 			"aliases": map[string]interface{}{
-				e.alias: map[string]interface{}{},
+				alias: map[string]interface{}{},
 			},
 			"settings": map[string]interface{}{
 				"number_of_shards":   3,
@@ -40,42 +44,50 @@ func (e *elasticRepository) Create(ctx context.Context, document *Document) (str
 			return "", err
 		}
 		req := esapi.IndicesCreateRequest{
-			Index: e.index,
+			Index: index,
 			Body:  bytes.NewReader(dataBytes),
 		}
 		res, err := req.Do(ctx, e.client)
-		if err != nil || res.StatusCode > 201 {
-			errorHandler.Panic(400, err.Error())
+		if err != nil {
+			return "", err
 		}
-		log.Infof("Elastic repositroy: %s index created, %s alias added.", e.index, e.alias)
-	}
-	if err != nil {
-		errorHandler.Panic(404, err.Error())
+		res.Body.Close()
+		if res.IsError() {
+			return "", errors.Wrap(errors.New(res.String()), "esClient.IndicesCreate error")
+		}
+		log.Infof("Elastic repositroy: %s index created, %s alias added.", index, alias)
 	}
 	document.Create()
 	doc, _ := json.Marshal(document)
-	req := esapi.IndexRequest{Index: e.index, DocumentID: document.ID, Body: bytes.NewReader(doc)}
+	req := esapi.IndexRequest{Index: index, DocumentID: document.ID, Body: bytes.NewReader(doc)}
 	res, err := req.Do(ctx, e.client)
-	if err != nil || res.StatusCode > 201 {
-		errorHandler.Panic(400, err.Error())
+	if err != nil {
+		return "", err
 	}
 	defer res.Body.Close()
+	if res.IsError() {
+		return "", errors.Wrap(errors.New(res.String()), "esClient.Index error")
+	}
 
-	return document.ID, err
+	return document.ID, nil
 }
 
 // Delete implements Repository
 func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error) {
 	shouldFilter := map[string]interface{}{"match": map[string]interface{}{"ID.keyword": id}}
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return false, err
+	}
 	query := map[string]interface{}{
-		"query": shouldFilter,
+		"query": scoped,
 	}
 	dataBytes, err := json.Marshal(&query)
 	if err != nil {
 		return false, err
 	}
 	reader := bytes.NewReader(dataBytes)
-	res, err := e.client.DeleteByQuery([]string{e.index}, reader,
+	res, err := e.client.DeleteByQuery([]string{index}, reader,
 		e.client.DeleteByQuery.WithContext(ctx),
 		e.client.DeleteByQuery.WithPretty(),
 		e.client.DeleteByQuery.WithHuman(),
@@ -92,7 +104,7 @@ func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error)
 
 	deleted := elasticclient.ElasticResultResponse{}
 	if err := json.NewDecoder(res.Body).Decode(&deleted); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return false, err
 	}
 	if deleted.Deleted < 1 {
 		return false, errors.New("id not found")
@@ -103,9 +115,21 @@ func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error)
 
 // GetAll implements Repository
 func (e *elasticRepository) GetAll(ctx context.Context) ([]Document, error) {
+	index, scoped, err := e.scope.Query(ctx, map[string]interface{}{"match_all": map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	query := map[string]interface{}{
+		"query": scoped,
+	}
+	dataBytes, err := json.Marshal(&query)
+	if err != nil {
+		return nil, err
+	}
 	res, err := e.client.Search(
 		e.client.Search.WithContext(ctx),
-		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithIndex(index),
+		e.client.Search.WithBody(bytes.NewReader(dataBytes)),
 		e.client.Search.WithPretty(),
 		e.client.Search.WithHuman(),
 		e.client.Search.WithTimeout(5*time.Second),
@@ -121,7 +145,7 @@ func (e *elasticRepository) GetAll(ctx context.Context) ([]Document, error) {
 
 	hits := elasticclient.ElasticResponse[Document]{}
 	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return nil, err
 	}
 	hitsize := len(hits.Hits.Hits)
 	if hitsize > 0 {
@@ -137,8 +161,12 @@ func (e *elasticRepository) GetAll(ctx context.Context) ([]Document, error) {
 // GetById implements Repository
 func (e *elasticRepository) GetById(ctx context.Context, id string) (*Document, error) {
 	shouldFilter := map[string]interface{}{"match": map[string]interface{}{"ID.keyword": id}}
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return nil, err
+	}
 	query := map[string]interface{}{
-		"query": shouldFilter,
+		"query": scoped,
 	}
 	dataBytes, err := json.Marshal(&query)
 	if err != nil {
@@ -146,7 +174,7 @@ func (e *elasticRepository) GetById(ctx context.Context, id string) (*Document,
 	}
 	res, err := e.client.Search(
 		e.client.Search.WithContext(ctx),
-		e.client.Search.WithIndex(e.index),
+		e.client.Search.WithIndex(index),
 		e.client.Search.WithBody(bytes.NewReader(dataBytes)),
 		e.client.Search.WithPretty(),
 		e.client.Search.WithHuman(),
@@ -163,7 +191,7 @@ func (e *elasticRepository) GetById(ctx context.Context, id string) (*Document,
 
 	hits := elasticclient.ElasticResponse[Document]{}
 	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
-		errorHandler.Panic(404, err.Error())
+		return nil, err
 	}
 	hitsize := len(hits.Hits.Hits)
 	if hitsize < 1 {
@@ -185,16 +213,20 @@ func (e *elasticRepository) Update(ctx context.Context, id string, document *Doc
 
 	shouldFilter := map[string]interface{}{"match_phrase": map[string]interface{}{"ID.keyword": id}}
 
+	index, scoped, err := e.scope.Query(ctx, shouldFilter)
+	if err != nil {
+		return false, err
+	}
 	updateRequest := map[string]interface{}{
 		"script": updateField,
-		"query":  shouldFilter,
+		"query":  scoped,
 	}
 	dataBytes, err := json.Marshal(&updateRequest)
 	if err != nil {
 		return false, err
 	}
 
-	res, err := e.client.UpdateByQuery([]string{e.index},
+	res, err := e.client.UpdateByQuery([]string{index},
 		e.client.UpdateByQuery.WithBody(bytes.NewReader(dataBytes)),
 		e.client.UpdateByQuery.WithContext(ctx),
 		e.client.UpdateByQuery.WithPretty(),
@@ -207,7 +239,7 @@ func (e *elasticRepository) Update(ctx context.Context, id string, document *Doc
 
 	updated := elasticclient.ElasticResultResponse{}
 	if err := json.NewDecoder(res.Body).Decode(&updated); err != nil {
-		errorHandler.Panic(400, err.Error())
+		return false, err
 	}
 	if updated.Updated < 1 {
 		return false, errors.New("id not found")
@@ -216,6 +248,49 @@ func (e *elasticRepository) Update(ctx context.Context, id string, document *Doc
 
 }
 
-func NewElasticRepository(elastic *elasticsearch.Client) Repository {
-	return &elasticRepository{client: elastic, index: "documents_19092022", alias: "documents"}
+// GetAllByOwner implements Repository
+func (e *elasticRepository) GetAllByOwner(ctx context.Context, ownerId string) ([]Document, error) {
+	index, scoped, err := e.scope.Query(ctx, elasticclient.Term("OwnerId", ownerId))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Document](ctx, e.client, index, map[string]interface{}{
+		"size":  10000,
+		"query": scoped,
+	})
+}
+
+// UpdateOwner implements Repository
+func (e *elasticRepository) UpdateOwner(ctx context.Context, ownerId string, newOwnerId string) (int64, error) {
+	index, scoped, err := e.scope.Query(ctx, elasticclient.Term("OwnerId", ownerId))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.UpdateByQuery(ctx, e.client, index, scoped,
+		map[string]interface{}{"OwnerId": newOwnerId, "UpdatedAt": time.Now().Format("2006-01-02-15-04-05")})
+}
+
+// DeleteAllByOwner implements Repository
+func (e *elasticRepository) DeleteAllByOwner(ctx context.Context, ownerId string) (int64, error) {
+	index, scoped, err := e.scope.Query(ctx, elasticclient.Term("OwnerId", ownerId))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.DeleteByQuery(ctx, e.client, index, scoped)
+}
+
+// AssignTenant implements Repository
+func (e *elasticRepository) AssignTenant(ctx context.Context, tenantId string) (int64, error) {
+	if !helpers.AllTenants(ctx) {
+		return 0, helpers.ErrAllTenantsRequired
+	}
+	query := map[string]interface{}{"bool": map[string]interface{}{
+		"must_not": []interface{}{map[string]interface{}{"wildcard": map[string]interface{}{"TenantId.keyword": "?*"}}},
+	}}
+	return elasticclient.UpdateByQuery(ctx, e.client, e.scope.Index, query, map[string]interface{}{"TenantId": tenantId})
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client, settings config.TenantSettings) Repository {
+	scope := elasticclient.NewTenantScope("documents_19092022", "documents", settings.Isolation == "separate")
+	return &elasticRepository{client: elastic, scope: scope}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/hasanbakirci/doc-system/pkg/helpers"
 	"github.com/hasanbakirci/doc-system/pkg/middleware"
@@ -20,9 +21,12 @@ func (h Handler) createDocument(c echo.Context) error {
 	file, err := c.FormFile("file")
 	if err != nil {
 		//return c.JSON(http.StatusBadRequest, err)
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return appError.Validation("file_missing", "A file is required").Wrap(err)
+	}
+	fileResult, err := helpers.AddFile(file, "text/plain; charset=utf-8")
+	if err != nil {
+		return err
 	}
-	fileResult := helpers.AddFile(file, "text/plain; charset=utf-8")
 	//if err := c.Bind(request); err != nil {
 	//	return c.JSON(http.StatusBadRequest, err.Error())
 	//}
@@ -35,7 +39,7 @@ func (h Handler) createDocument(c echo.Context) error {
 	}, fmt.Sprintf("%v", uid))
 
 	if err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 	//return c.JSON(http.StatusCreated, result)
 	return errorHandler.Success(c, http.StatusCreated, result, "Success")
@@ -51,9 +55,12 @@ func (h Handler) updateDocument(c echo.Context) error {
 	//}
 	file, err := c.FormFile("file")
 	if err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return appError.Validation("file_missing", "A file is required").Wrap(err)
+	}
+	fileResult, err := helpers.AddFile(file, "text/plain; charset=utf-8")
+	if err != nil {
+		return err
 	}
-	fileResult := helpers.AddFile(file, "text/plain; charset=utf-8")
 
 	result, err := h.service.Update(c.Request().Context(), id, UpdateDocumentRequest{
 		Name:        fileResult.FileName,
@@ -62,8 +69,8 @@ func (h Handler) updateDocument(c echo.Context) error {
 		Path:        fileResult.Path,
 		MimeType:    fileResult.MimeType,
 	})
-	if !result {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+	if err != nil {
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, result, "Success")
 }
@@ -72,8 +79,8 @@ func (h Handler) deleteDocument(c echo.Context) error {
 	id := c.Param("id")
 
 	result, err := h.service.Delete(c.Request().Context(), id)
-	if !result {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+	if err != nil {
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, result, "Success")
 }
@@ -82,7 +89,7 @@ func (h Handler) getAllDocuments(c echo.Context) error {
 	documents, err := h.service.GetAll(c.Request().Context())
 	if err != nil {
 		//return c.JSON(http.StatusNotFound, err)
-		return errorHandler.Error(c, http.StatusNotFound, err.Error())
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, documents, "Success")
 }
@@ -92,18 +99,31 @@ func (h Handler) getByIdDocument(c echo.Context) error {
 
 	document, err := h.service.GetById(c.Request().Context(), id)
 	if err != nil {
-		return errorHandler.Error(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 	return errorHandler.Success(c, http.StatusOK, document, "Success")
 }
+func (h Handler) downloadDocument(c echo.Context) error {
+	id := c.Param("id")
+
+	document, err := h.service.Download(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.Attachment(document.Path, document.Name)
+}
+
 func NewDocumentHandler(s Service) Handler {
 	return Handler{service: s}
 }
 
-func RegisterDocumentHandlers(instance *echo.Echo, h Handler, secret string) {
-	instance.POST("api/documents", h.createDocument, middleware.TokenHandlerMiddlewareFunc(secret, "user", "admin"))
-	instance.PUT("api/documents/:id", h.updateDocument)
-	instance.DELETE("api/documents/:id", h.deleteDocument)
-	instance.GET("api/documents", h.getAllDocuments, middleware.TokenHandlerMiddlewareFunc(secret, "user", "admin"))
-	instance.GET("api/documents/:id", h.getByIdDocument)
+func RegisterDocumentHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	read := authenticator.ScopedMiddlewareFunc(helpers.DocumentsRead, "user", "admin")
+	write := authenticator.ScopedMiddlewareFunc(helpers.DocumentsWrite, "user", "admin")
+	instance.POST("api/documents", h.createDocument, write)
+	instance.PUT("api/documents/:id", h.updateDocument, write)
+	instance.DELETE("api/documents/:id", h.deleteDocument, write)
+	instance.GET("api/documents", h.getAllDocuments, read)
+	instance.GET("api/documents/:id", h.getByIdDocument, read)
+	instance.GET("api/documents/:id/download", h.downloadDocument, read)
 }
@@ -13,6 +13,8 @@ type Document struct {
 	Extension   string `bson:"extension"`
 	Path        string `bson:"path"`
 	MimeType    string `bson:"mime_type"`
+	OwnerId     string `bson:"owner_id"`
+	TenantId    string `bson:"tenant_id"`
 	CreatedAt   string `bson:"created_at"`
 	UpdatedAt   string `bson:"updated_at"`
 }
@@ -77,6 +79,7 @@ type DocumentResponse struct {
 	Extension   string `json:"extension"`
 	Path        string `json:"path"`
 	MimeType    string `json:"mime_type"`
+	OwnerId     string `json:"owner_id"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
@@ -89,6 +92,7 @@ func (d *Document) ToDocumentResponse() *DocumentResponse {
 		Extension:   d.Extension,
 		Path:        d.Path,
 		MimeType:    d.MimeType,
+		OwnerId:     d.OwnerId,
 		CreatedAt:   d.CreatedAt,
 		UpdatedAt:   d.UpdatedAt,
 	}
@@ -102,6 +106,8 @@ type DocumentLog struct {
 	Path        string `json:"path"`
 	MimeType    string `json:"mime_type"`
 	UserId      string `json:"user_id"`
+	OwnerId     string `json:"owner_id"`
+	TenantId    string `json:"tenant_id"`
 }
 
 func CreateDocumentLog(doc *Document, uid string) *DocumentLog {
@@ -113,5 +119,7 @@ func CreateDocumentLog(doc *Document, uid string) *DocumentLog {
 		Path:        doc.Path,
 		MimeType:    doc.MimeType,
 		UserId:      uid,
+		OwnerId:     doc.OwnerId,
+		TenantId:    doc.TenantId,
 	}
 }
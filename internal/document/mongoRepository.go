@@ -4,16 +4,19 @@ import (
 	"context"
 	"time"
 
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type documentRepository struct {
-	collection *mongo.Collection
+	collections mongoClient.TenantCollections
 }
 
 func (d documentRepository) Create(ctx context.Context, document *Document) (string, error) {
-	result, err := d.collection.InsertOne(ctx, document)
+	result, err := d.collections.Collection(document.TenantId).InsertOne(ctx, document)
 	if result.InsertedID == nil {
 		return "", err
 	}
@@ -30,39 +33,61 @@ func (d documentRepository) Update(ctx context.Context, id string, document *Doc
 		"mime_type":   document.Path,
 		"updated_at":  time.Now().Format("2006-01-02-15-04-05"),
 	}}
-	updateResult, err := d.collection.UpdateOne(ctx, filter, update)
-	if updateResult.ModifiedCount < 1 {
+	updateResult, err := d.collections.UpdateOne(ctx, filter, update)
+	if err != nil || updateResult.ModifiedCount < 1 {
 		return false, err
 	}
 	return true, nil
 }
 
 func (d documentRepository) Delete(ctx context.Context, id string) (bool, error) {
-	deleteResult, err := d.collection.DeleteOne(ctx, bson.M{"_id": id})
-	if deleteResult.DeletedCount < 1 {
+	deleteResult, err := d.collections.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil || deleteResult.DeletedCount < 1 {
 		return false, err
 	}
 	return true, nil
 }
 
 func (d documentRepository) GetAll(ctx context.Context) ([]Document, error) {
-	cursor, err := d.collection.Find(ctx, bson.M{})
-	documents := make([]Document, 0)
-	if err = cursor.All(ctx, &documents); err != nil {
-		return nil, err
-	}
-	return documents, nil
+	return mongoClient.FindAll[Document](ctx, d.collections, bson.M{})
 }
 
 func (d documentRepository) GetById(ctx context.Context, id string) (*Document, error) {
 	document := new(Document)
-	if err := d.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&document); err != nil {
+	if err := d.collections.FindOne(ctx, bson.M{"_id": id}, document); err != nil {
 		return nil, err
 	}
 	return document, nil
 }
 
-func NewDocumentRepository(db *mongo.Database) Repository {
-	col := db.Collection("documents")
-	return &documentRepository{collection: col}
+func (d documentRepository) GetAllByOwner(ctx context.Context, ownerId string) ([]Document, error) {
+	return mongoClient.FindAll[Document](ctx, d.collections, bson.M{"owner_id": ownerId})
+}
+
+func (d documentRepository) UpdateOwner(ctx context.Context, ownerId string, newOwnerId string) (int64, error) {
+	return d.collections.UpdateMany(ctx, bson.M{"owner_id": ownerId}, bson.M{"$set": bson.M{
+		"owner_id":   newOwnerId,
+		"updated_at": time.Now().Format("2006-01-02-15-04-05"),
+	}})
+}
+
+func (d documentRepository) DeleteAllByOwner(ctx context.Context, ownerId string) (int64, error) {
+	return d.collections.DeleteMany(ctx, bson.M{"owner_id": ownerId})
+}
+
+func (d documentRepository) AssignTenant(ctx context.Context, tenantId string) (int64, error) {
+	if !helpers.AllTenants(ctx) {
+		return 0, helpers.ErrAllTenantsRequired
+	}
+	filter := bson.M{"tenant_id": bson.M{"$in": bson.A{nil, ""}}}
+	updateResult, err := d.collections.Collection("").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"tenant_id": tenantId}})
+	if err != nil {
+		return 0, err
+	}
+	return updateResult.ModifiedCount, nil
+}
+
+func NewDocumentRepository(db *mongo.Database, settings config.TenantSettings) Repository {
+	collections := mongoClient.NewTenantCollections(db, "documents", settings.Isolation == "separate")
+	return &documentRepository{collections: collections}
 }
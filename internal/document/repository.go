@@ -10,4 +10,10 @@ type Repository interface {
 	Delete(ctx context.Context, id string) (bool, error)
 	GetAll(ctx context.Context) ([]Document, error)
 	GetById(ctx context.Context, id string) (*Document, error)
+	GetAllByOwner(ctx context.Context, ownerId string) ([]Document, error)
+	UpdateOwner(ctx context.Context, ownerId string, newOwnerId string) (int64, error)
+	DeleteAllByOwner(ctx context.Context, ownerId string) (int64, error)
+	// AssignTenant moves records created before organizations existed into the tenant.
+	// With separate isolation they stay in the shared index or collection.
+	AssignTenant(ctx context.Context, tenantId string) (int64, error)
 }
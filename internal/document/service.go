@@ -3,8 +3,10 @@ package document
 import (
 	"context"
 
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
-	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
 )
 
 type Service interface {
@@ -13,45 +15,107 @@ type Service interface {
 	Delete(ctx context.Context, id string) (bool, error)
 	GetAll(ctx context.Context) ([]DocumentResponse, error)
 	GetById(ctx context.Context, id string) (*DocumentResponse, error)
+	Download(ctx context.Context, id string) (*DocumentResponse, error)
+	GetAllByOwner(ctx context.Context, ownerId string) ([]DocumentResponse, error)
+	TransferOwnership(ctx context.Context, ownerId string, newOwnerId string) (int64, error)
+	DeleteAllByOwner(ctx context.Context, ownerId string) (int64, error)
 }
 
+var errDocumentNotFound = appError.NotFound("document_not_found", "Service: document id not found")
+
 type documentService struct {
 	repository Repository
-	redis      *redisClient.RedisClient
+	publisher  events.Publisher
 }
 
 func (d documentService) Create(ctx context.Context, request CreateDocumentRequest, uid string) (string, error) {
 	document := request.ToDocument()
+	document.TenantId = helpers.Tenant(ctx)
+	document.OwnerId = uid
 	id, err := d.repository.Create(ctx, document)
 	if err != nil {
-		errorHandler.Panic(404, "Service: failed to create document")
+		return "", appError.Unavailable("document_not_created", "Service: failed to create document").Wrap(err)
 	}
 
-	d.redis.Publish("doc-system", CreateDocumentLog(document, uid))
+	document.ID = id
+	if err := d.publishChange(ctx, events.DocumentCreated, document, uid, func() error {
+		if _, err := d.repository.Delete(ctx, id); err != nil {
+			return err
+		}
+		return helpers.RemoveFile(document.Path)
+	}); err != nil {
+		return "", err
+	}
 	return id, nil
 }
 
 func (d documentService) Update(ctx context.Context, id string, request UpdateDocumentRequest) (bool, error) {
+	// the stored document restores the update when its event can not be stored
+	stored, err := d.repository.GetById(ctx, id)
+	if err != nil {
+		return false, errDocumentNotFound.Wrap(err)
+	}
 	document := request.ToDocument()
-	result, _ := d.repository.Update(ctx, id, document)
+	result, err := d.repository.Update(ctx, id, document)
 	if !result {
-		errorHandler.Panic(404, "Service: failed to update document")
+		return false, errDocumentNotFound.Wrap(err)
+	}
+	document.ID = id
+	document.TenantId = helpers.Tenant(ctx)
+	document.OwnerId = stored.OwnerId
+	if err := d.publishChange(ctx, events.DocumentUpdated, document, helpers.Actor(ctx), func() error {
+		if _, err := d.repository.Update(ctx, id, stored); err != nil {
+			return err
+		}
+		// the restored document points at the old file again, so the upload is dropped
+		if document.Path == stored.Path {
+			return nil
+		}
+		return helpers.RemoveFile(document.Path)
+	}); err != nil {
+		return false, err
 	}
 	return result, nil
 }
 
 func (d documentService) Delete(ctx context.Context, id string) (bool, error) {
-	result, _ := d.repository.Delete(ctx, id)
+	document, err := d.repository.GetById(ctx, id)
+	if err != nil {
+		return false, errDocumentNotFound.Wrap(err)
+	}
+	result, err := d.repository.Delete(ctx, id)
 	if !result {
-		errorHandler.Panic(404, "Service: failed to delete document")
+		return false, errDocumentNotFound.Wrap(err)
+	}
+	if err := d.publishChange(ctx, events.DocumentDeleted, document, helpers.Actor(ctx), func() error {
+		_, err := d.repository.Create(ctx, document)
+		return err
+	}); err != nil {
+		return false, err
 	}
 	return result, nil
 }
 
+// publishChange stores the event of a change in the outbox. When that fails the
+// change is undone and the request fails, so no change is kept without its event.
+func (d documentService) publishChange(ctx context.Context, eventType string, document *Document, actor string, undo func() error) error {
+	err := events.PublishChange(ctx, d.publisher, eventType, document.ID, actor, CreateDocumentLog(document, actor))
+	if err == nil {
+		return nil
+	}
+	if undoErr := undo(); undoErr != nil {
+		log.Errorf("Service: failed to undo the change of document %s after its %s event was lost: %v", document.ID, eventType, undoErr)
+	}
+	return appError.Unavailable("document_event_not_stored", "Service: failed to store the document event").Wrap(err)
+}
+
 func (d documentService) GetAll(ctx context.Context) ([]DocumentResponse, error) {
 	documents, err := d.repository.GetAll(ctx)
+	if err != nil {
+		return nil, appError.NotFound("documents_not_found", err.Error())
+	}
 	if len(documents) < 1 {
-		errorHandler.Panic(404, err.Error())
+		return nil, appError.NotFound("documents_not_found", "Service: no documents found")
 	}
 	documentResponses := make([]DocumentResponse, 0)
 	for i := 0; i < len(documents); i++ {
@@ -64,12 +128,59 @@ func (d documentService) GetAll(ctx context.Context) ([]DocumentResponse, error)
 func (d documentService) GetById(ctx context.Context, id string) (*DocumentResponse, error) {
 	document, err := d.repository.GetById(ctx, id)
 	if err != nil {
-		errorHandler.Panic(404, "Service: document id not found")
+		return nil, errDocumentNotFound.Wrap(err)
 	}
 	result := document.ToDocumentResponse()
 	return result, nil
 }
 
-func NewDocumentService(repo Repository, redis *redisClient.RedisClient) Service {
-	return &documentService{repository: repo, redis: redis}
+// Download returns the document whose file is about to be sent to the client.
+func (d documentService) Download(ctx context.Context, id string) (*DocumentResponse, error) {
+	document, err := d.repository.GetById(ctx, id)
+	if err != nil {
+		return nil, errDocumentNotFound.Wrap(err)
+	}
+	events.Publish(ctx, d.publisher, events.DocumentDownloaded, id, helpers.Actor(ctx), CreateDocumentLog(document, helpers.Actor(ctx)))
+	return document.ToDocumentResponse(), nil
+}
+
+// GetAllByOwner returns an empty list instead of failing when the user owns nothing.
+func (d documentService) GetAllByOwner(ctx context.Context, ownerId string) ([]DocumentResponse, error) {
+	documents, err := d.repository.GetAllByOwner(ctx, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	documentResponses := make([]DocumentResponse, 0)
+	for i := 0; i < len(documents); i++ {
+		documentResponses = append(documentResponses, *documents[i].ToDocumentResponse())
+	}
+	return documentResponses, nil
+}
+
+func (d documentService) TransferOwnership(ctx context.Context, ownerId string, newOwnerId string) (int64, error) {
+	return d.repository.UpdateOwner(ctx, ownerId, newOwnerId)
+}
+
+// DeleteAllByOwner removes the user's documents together with their uploaded files.
+// Files that are already gone are skipped.
+func (d documentService) DeleteAllByOwner(ctx context.Context, ownerId string) (int64, error) {
+	documents, err := d.repository.GetAllByOwner(ctx, ownerId)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := d.repository.DeleteAllByOwner(ctx, ownerId)
+	if err != nil {
+		return deleted, err
+	}
+	for _, document := range documents {
+		if err := helpers.RemoveFile(document.Path); err != nil {
+			log.Errorf("Service: failed to remove file of document %s: %v", document.ID, err)
+		}
+		events.Publish(ctx, d.publisher, events.DocumentDeleted, document.ID, helpers.Actor(ctx), CreateDocumentLog(&document, helpers.Actor(ctx)))
+	}
+	return deleted, nil
+}
+
+func NewDocumentService(repo Repository, publisher events.Publisher) Service {
+	return &documentService{repository: repo, publisher: publisher}
 }
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	log "github.com/sirupsen/logrus"
+)
+
+// Publisher hands events to downstream consumers.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// Handler processes a delivered event. Buses that can redeliver do so when the
+// handler returns an error.
+type Handler func(ctx context.Context, event *Event) error
+
+// Bus carries events from the outbox relay to the consumers.
+type Bus interface {
+	Publisher
+	// Subscribe delivers events to the handler until the context is cancelled or
+	// the subscription fails. Subscribers of the same group share the events between
+	// them while every group receives all events. Events are handled concurrently by
+	// ConsumerSettings.Workers, in order per Key, and Subscribe returns once the
+	// events it already received are handled.
+	Subscribe(ctx context.Context, group string, handler Handler) error
+	Close() error
+}
+
+const (
+	RedisStreamsBus = "redis-streams"
+	RedisPubSubBus  = "redis-pubsub"
+	NatsBus         = "nats"
+	MemoryBus       = "memory"
+)
+
+// NewBus creates the bus selected by EventSettings.Bus, redis streams by default.
+// source identifies the publishing process, e.g. "doc-system/api".
+func NewBus(settings config.Configuration, redis *redisClient.RedisClient, source string) (Bus, error) {
+	switch settings.EventSettings.Bus {
+	case RedisStreamsBus, "":
+		redisSettings := settings.RedisSettings
+		if redisSettings.Consumer == "" {
+			redisSettings.Consumer = consumerName()
+		}
+		return NewRedisStreamBus(redis, redisSettings, settings.ConsumerSettings, source), nil
+	case RedisPubSubBus:
+		return NewRedisPubSubBus(redis, settings.RedisSettings.Channel, settings.ConsumerSettings, source), nil
+	case NatsBus:
+		return NewNatsBus(settings.NatsSettings, settings.ConsumerSettings, source)
+	case MemoryBus:
+		if !settings.EventSettings.InProcessConsumer {
+			log.Warn("Events: the memory bus has no consumers without InProcessConsumer")
+		}
+		return NewMemoryBus(settings.ConsumerSettings, source), nil
+	}
+	return nil, fmt.Errorf("unknown event bus %q", settings.EventSettings.Bus)
+}
+
+// consumerName tells the instances of a consumer group apart by host and process.
+func consumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "consumer"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Publish builds and publishes an event, logging failures instead of returning them
+// since the change the event describes has already been stored.
+func Publish(ctx context.Context, publisher Publisher, eventType, subject, actor string, data interface{}) {
+	if err := PublishChange(ctx, publisher, eventType, subject, actor, data); err != nil {
+		log.Errorf("Service: failed to publish %s event for %s: %v", eventType, subject, err)
+	}
+}
+
+// PublishChange builds and publishes an event and returns the failure, for services
+// that undo their change rather than keep it without its event.
+func PublishChange(ctx context.Context, publisher Publisher, eventType, subject, actor string, data interface{}) error {
+	event, err := NewEvent(ctx, eventType, subject, actor, data)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(ctx, event)
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+// groupBuffer is how many events a group may fall behind before publishing fails.
+const groupBuffer = 1024
+
+var errBusFull = errors.New("events: memory bus is full")
+
+type memoryBus struct {
+	mutex    sync.RWMutex
+	groups   map[string]chan *Event
+	consumer config.ConsumerSettings
+	source   string
+}
+
+// Publish queues the event for every subscribed group. It fails instead of blocking
+// when a group is too far behind, which leaves the event in the outbox for a retry.
+func (m *memoryBus) Publish(ctx context.Context, event *Event) error {
+	stamp(event, m.source)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, queue := range m.groups {
+		select {
+		case queue <- event:
+		default:
+			return errBusFull
+		}
+	}
+	return nil
+}
+
+// Subscribe handles the events of the group until the context is cancelled. Events
+// are not redelivered, so handler errors are only logged.
+func (m *memoryBus) Subscribe(ctx context.Context, group string, handler Handler) error {
+	queue := m.queue(group)
+	workers := newPool(m.consumer, handler)
+	defer workers.drain()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-queue:
+			if err := workers.submit(ctx, event, logFailure(group, event)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (m *memoryBus) queue(group string) chan *Event {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	queue, found := m.groups[group]
+	if !found {
+		queue = make(chan *Event, groupBuffer)
+		m.groups[group] = queue
+	}
+	return queue
+}
+
+func (m *memoryBus) Close() error {
+	return nil
+}
+
+// NewMemoryBus passes events between goroutines of one process, for single binary
+// deployments and tests. Events published before a group subscribed are not
+// delivered to it.
+func NewMemoryBus(consumer config.ConsumerSettings, source string) Bus {
+	return &memoryBus{groups: make(map[string]chan *Event), consumer: consumer, source: source}
+}
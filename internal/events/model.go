@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+)
+
+// Event is the envelope of everything published to downstream consumers. It follows
+// the CloudEvents attributes: Type names what happened, Subject the document or user
+// it happened to and Data carries the payload in the DataVersion of its type.
+// Consumers should ignore fields they do not know and check DataVersion before
+// decoding Data.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	Time            string          `json:"time"`
+	Subject         string          `json:"subject"`
+	Actor           string          `json:"actor,omitempty"`
+	TenantId        string          `json:"tenantid,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	DataVersion     string          `json:"dataversion"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+const (
+	SpecVersion = "1.0"
+	DataVersion = "1"
+)
+
+const (
+	DocumentCreated    = "document.created"
+	DocumentUpdated    = "document.updated"
+	DocumentDeleted    = "document.deleted"
+	DocumentDownloaded = "document.downloaded"
+	// DocumentRestored is reserved for restoring deleted documents, which the API
+	// does not offer yet; consumers can already subscribe to it.
+	DocumentRestored = "document.restored"
+	UserCreated      = "user.created"
+	UserLogin        = "user.login"
+	UserLoginFailed  = "user.login_failed"
+	UserDeleted      = "user.deleted"
+)
+
+// NewEvent builds an event of the context's organization. The actor is the user who
+// caused the event, empty for anonymous ones such as failed logins.
+func NewEvent(ctx context.Context, eventType, subject, actor string, data interface{}) (*Event, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.New().String(),
+		Type:            eventType,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		Subject:         subject,
+		Actor:           actor,
+		TenantId:        helpers.Tenant(ctx),
+		DataContentType: "application/json",
+		DataVersion:     DataVersion,
+		Data:            body,
+	}, nil
+}
+
+// Decode unmarshals the payload of the event.
+func (e *Event) Decode(data interface{}) error {
+	return json.Unmarshal(e.Data, data)
+}
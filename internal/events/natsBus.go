@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+)
+
+// fetchWait bounds a pull so a cancelled subscription is noticed.
+const fetchWait = 5 * time.Second
+
+type natsBus struct {
+	connection *nats.Conn
+	jetStream  nats.JetStreamContext
+	settings   config.NatsSettings
+	consumer   config.ConsumerSettings
+	source     string
+}
+
+// Publish stores the event in the stream under Subject and the event type. The
+// event id doubles as message id, so JetStream drops a relayed duplicate.
+func (n natsBus) Publish(ctx context.Context, event *Event) error {
+	stamp(event, n.source)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = n.jetStream.Publish(n.settings.Subject+"."+event.Type, body, nats.MsgId(event.ID))
+	return err
+}
+
+// Subscribe pulls events through a durable consumer named after the group, which
+// every subscriber of the group shares, and hands them to the worker pool. Events
+// the handler fails are redelivered.
+func (n natsBus) Subscribe(ctx context.Context, group string, handler Handler) error {
+	sub, err := n.jetStream.PullSubscribe(n.settings.Subject+".>", group, nats.ManualAck(), nats.DeliverAll())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the durable consumer outlives the subscription, so only the interest is dropped
+		if err := sub.Unsubscribe(); err != nil {
+			log.Errorf("Events: failed to unsubscribe %s: %v", group, err)
+		}
+	}()
+	workers := newPool(n.consumer, handler)
+	defer workers.drain()
+	for ctx.Err() == nil {
+		messages, err := sub.Fetch(10, nats.MaxWait(fetchWait))
+		if err == nats.ErrTimeout || err == context.DeadlineExceeded {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		for _, msg := range messages {
+			event, ok := decode(string(msg.Data))
+			if !ok {
+				_ = msg.Term()
+				continue
+			}
+			if err := workers.submit(ctx, event, n.acknowledge(group, msg)); err != nil {
+				// cancelled, the rest is redelivered after the ack wait
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (n natsBus) acknowledge(group string, msg *nats.Msg) func(err error) {
+	return func(err error) {
+		if err != nil {
+			log.Errorf("Events: %s failed to handle an event: %v", group, err)
+			_ = msg.Nak()
+			return
+		}
+		if err := msg.Ack(); err != nil {
+			log.Errorf("Events: %s failed to acknowledge an event: %v", group, err)
+		}
+	}
+}
+
+func (n natsBus) Close() error {
+	return n.connection.Drain()
+}
+
+// NewNatsBus connects to a NATS server with JetStream enabled, e.g. one started
+// next to the api with `nats-server -js`, and creates the stream if it is missing.
+func NewNatsBus(settings config.NatsSettings, consumer config.ConsumerSettings, source string) (Bus, error) {
+	connection, err := nats.Connect(settings.Url)
+	if err != nil {
+		return nil, err
+	}
+	jetStream, err := connection.JetStream()
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+	if _, err := jetStream.StreamInfo(settings.Stream); err != nil {
+		_, err = jetStream.AddStream(&nats.StreamConfig{
+			Name:     settings.Stream,
+			Subjects: []string{settings.Subject + ".>"},
+			MaxAge:   time.Duration(settings.MaxAge) * time.Hour,
+			Storage:  nats.FileStorage,
+		})
+		if err != nil {
+			connection.Close()
+			return nil, err
+		}
+	}
+	return &natsBus{connection: connection, jetStream: jetStream, settings: settings, consumer: consumer, source: source}, nil
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// workerQueue is how many events may wait for each worker before reading the
+	// bus blocks.
+	workerQueue         = 16
+	defaultDrainTimeout = 30 * time.Second
+)
+
+var errPanic = errors.New("events: handler panicked")
+
+// Key returns the ordering key of an event. Events of the same document or user
+// share their subject and are handled one after the other; events without a
+// subject are spread over the workers.
+func Key(event *Event) string {
+	if event.Subject != "" {
+		return event.Subject
+	}
+	return event.ID
+}
+
+type job struct {
+	event *Event
+	done  func(err error)
+}
+
+// pool hands the events of a subscription to a fixed number of workers. Events
+// with the same key always go to the same worker, so they keep their order while
+// events of different keys are handled concurrently. Handlers run on a context of
+// their own, so cancelling the subscription lets in-flight events finish.
+type pool struct {
+	queues       []chan job
+	handler      Handler
+	work         context.Context
+	cancel       context.CancelFunc
+	drainTimeout time.Duration
+	wg           sync.WaitGroup
+}
+
+func newPool(settings config.ConsumerSettings, handler Handler) *pool {
+	workers := settings.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	drainTimeout := time.Duration(settings.DrainTimeout) * time.Second
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	work, cancel := context.WithCancel(context.Background())
+	p := &pool{queues: make([]chan job, workers), handler: handler, work: work, cancel: cancel, drainTimeout: drainTimeout}
+	for i := range p.queues {
+		p.queues[i] = make(chan job, workerQueue)
+		p.wg.Add(1)
+		go p.run(p.queues[i])
+	}
+	return p
+}
+
+func (p *pool) run(queue chan job) {
+	defer p.wg.Done()
+	for j := range queue {
+		j.done(p.handle(j.event))
+	}
+}
+
+// handle keeps a panicking handler from taking the worker down with it.
+func (p *pool) handle(event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Events: handler panicked on %s: %v", event.ID, r)
+			err = errPanic
+		}
+	}()
+	return p.handler(p.work, event)
+}
+
+// submit queues the event on the worker of its key and calls done with the result
+// of the handler. It blocks while that worker is busy and gives up when ctx is
+// cancelled, leaving the event unhandled.
+func (p *pool) submit(ctx context.Context, event *Event, done func(err error)) error {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(Key(event)))
+	select {
+	case p.queues[hash.Sum32()%uint32(len(p.queues))] <- job{event: event, done: done}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain stops accepting events and waits for the queued ones to be handled. After
+// the drain timeout the context of the handlers is cancelled and drain waits for
+// them to return.
+func (p *pool) drain() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(p.drainTimeout):
+		log.Warnf("Events: in-flight events did not finish within %s, cancelling them", p.drainTimeout)
+		p.cancel()
+		<-drained
+	}
+	p.cancel()
+}
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// blockTime bounds a stream read so pending entries are reclaimed even when the
+	// stream is quiet.
+	blockTime        = 5 * time.Second
+	defaultClaimIdle = time.Minute
+)
+
+type redisStreamBus struct {
+	redis    *redisClient.RedisClient
+	settings config.RedisSettings
+	consumer config.ConsumerSettings
+	source   string
+}
+
+// Publish appends the event to the stream, trimmed to roughly MaxLen entries.
+func (r redisStreamBus) Publish(ctx context.Context, event *Event) error {
+	stamp(event, r.source)
+	_, err := r.redis.AddToStream(r.settings.Stream, r.settings.MaxLen, event)
+	return err
+}
+
+// Subscribe reads the stream as a member of the consumer group and hands the
+// entries to the worker pool. Entries are acknowledged once handled; entries other
+// consumers left unacknowledged for ClaimIdle seconds are taken over, so events
+// survive a crashed consumer. Cancelling the context stops reading and waits for
+// the entries already read to be handled.
+func (r redisStreamBus) Subscribe(ctx context.Context, group string, handler Handler) error {
+	stream, consumer := r.settings.Stream, r.settings.Consumer
+	if err := r.redis.CreateGroup(stream, group); err != nil {
+		return err
+	}
+	claimIdle := time.Duration(r.settings.ClaimIdle) * time.Second
+	if claimIdle <= 0 {
+		// claiming without an idle time would take entries other consumers are handling
+		claimIdle = defaultClaimIdle
+	}
+	workers := newPool(r.consumer, handler)
+	defer workers.drain()
+	inFlight := newInFlight()
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= claimIdle {
+			claimed, err := r.redis.ClaimPending(ctx, stream, group, consumer, claimIdle, r.settings.BatchSize)
+			if err != nil {
+				log.Errorf("Events: failed to claim pending events of %s: %v", group, err)
+			}
+			r.handle(ctx, group, claimed, workers, inFlight)
+			lastClaim = time.Now()
+		}
+		messages, err := r.redis.ReadGroup(ctx, stream, group, consumer, r.settings.BatchSize, blockTime)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		r.handle(ctx, group, messages, workers, inFlight)
+	}
+	return nil
+}
+
+// handle leaves entries that failed unacknowledged, so they are retried once
+// claimed. Entries still queued in the pool are not queued again when claimed.
+func (r redisStreamBus) handle(ctx context.Context, group string, messages []redisClient.StreamMessage, workers *pool, inFlight *inFlight) {
+	for _, msg := range messages {
+		id := msg.ID
+		if !inFlight.add(id) {
+			continue
+		}
+		event, ok := decode(msg.Payload)
+		if !ok {
+			r.ack(group, id)
+			inFlight.remove(id)
+			continue
+		}
+		err := workers.submit(ctx, event, func(err error) {
+			defer inFlight.remove(id)
+			if err != nil {
+				log.Errorf("Events: %s failed to handle %s: %v", group, id, err)
+				return
+			}
+			r.ack(group, id)
+		})
+		if err != nil {
+			// cancelled, the rest stays pending until it is claimed
+			inFlight.remove(id)
+			return
+		}
+	}
+}
+
+func (r redisStreamBus) ack(group string, id string) {
+	if err := r.redis.Ack(r.settings.Stream, group, id); err != nil {
+		log.Errorf("Events: %s failed to acknowledge %s: %v", group, id, err)
+	}
+}
+
+func (r redisStreamBus) Close() error {
+	return nil
+}
+
+// NewRedisStreamBus keeps events in a Redis Stream read through consumer groups.
+func NewRedisStreamBus(redis *redisClient.RedisClient, settings config.RedisSettings, consumer config.ConsumerSettings, source string) Bus {
+	return &redisStreamBus{redis: redis, settings: settings, consumer: consumer, source: source}
+}
+
+type redisPubSubBus struct {
+	redis    *redisClient.RedisClient
+	channel  string
+	consumer config.ConsumerSettings
+	source   string
+}
+
+func (r redisPubSubBus) Publish(ctx context.Context, event *Event) error {
+	stamp(event, r.source)
+	return r.redis.Publish(r.channel, event)
+}
+
+// Subscribe receives the events published while it is subscribed. Pub/sub neither
+// stores nor redelivers events and every subscriber receives all of them, so the
+// group is ignored and handler errors are only logged.
+func (r redisPubSubBus) Subscribe(ctx context.Context, group string, handler Handler) error {
+	subs := r.redis.Subscribe(r.channel)
+	defer subs.Close()
+	workers := newPool(r.consumer, handler)
+	defer workers.drain()
+	for {
+		msg, err := subs.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		event, ok := decode(msg.Payload)
+		if !ok {
+			continue
+		}
+		if err := workers.submit(ctx, event, logFailure(group, event)); err != nil {
+			return nil
+		}
+	}
+}
+
+func (r redisPubSubBus) Close() error {
+	return nil
+}
+
+// NewRedisPubSubBus publishes events to a Redis channel. Events published while no
+// consumer is subscribed are lost.
+func NewRedisPubSubBus(redis *redisClient.RedisClient, channel string, consumer config.ConsumerSettings, source string) Bus {
+	return &redisPubSubBus{redis: redis, channel: channel, consumer: consumer, source: source}
+}
+
+// decode unmarshals a published event. Payloads that can not be decoded never will
+// be, so they are dropped instead of failing forever.
+func decode(payload string) (*Event, bool) {
+	event := new(Event)
+	if err := json.Unmarshal([]byte(payload), event); err != nil {
+		log.Errorf("Events: dropping malformed event: %v", err)
+		return nil, false
+	}
+	return event, true
+}
+
+// logFailure reports the result of a bus that does not redeliver.
+func logFailure(group string, event *Event) func(err error) {
+	return func(err error) {
+		if err != nil {
+			log.Errorf("Events: %s failed to handle %s: %v", group, event.ID, err)
+		}
+	}
+}
+
+// inFlight tracks the stream entries queued in a pool.
+type inFlight struct {
+	lock sync.Mutex
+	ids  map[string]struct{}
+}
+
+func newInFlight() *inFlight {
+	return &inFlight{ids: make(map[string]struct{})}
+}
+
+// add reports false when the entry is already in flight.
+func (f *inFlight) add(id string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, found := f.ids[id]; found {
+		return false
+	}
+	f.ids[id] = struct{}{}
+	return true
+}
+
+func (f *inFlight) remove(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.ids, id)
+}
+
+// stamp sets the source of events published without one.
+func stamp(event *Event, source string) {
+	if event.Source == "" {
+		event.Source = source
+	}
+}
@@ -0,0 +1,93 @@
+package group
+
+import (
+	"context"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/pkg/errors"
+)
+
+// elasticRepository keeps all organizations in one index, told apart by TenantId.
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, group *Group) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, group.ID, group); err != nil {
+		return "", err
+	}
+	return group.ID, nil
+}
+
+// Update implements Repository
+func (e *elasticRepository) Update(ctx context.Context, group *Group) (bool, error) {
+	if _, err := e.GetById(ctx, group.ID); err != nil {
+		return false, err
+	}
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, group.ID, group); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete implements Repository
+func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", id))
+	if err != nil {
+		return false, err
+	}
+	deleted, err := elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+	return deleted > 0, err
+}
+
+// GetAll implements Repository
+func (e *elasticRepository) GetAll(ctx context.Context) ([]Group, error) {
+	scoped, err := e.scope.Filter(ctx, map[string]interface{}{"match_all": map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Group](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+// GetById implements Repository
+func (e *elasticRepository) GetById(ctx context.Context, id string) (*Group, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", id))
+	if err != nil {
+		return nil, err
+	}
+	groups, err := elasticclient.Search[Group](ctx, e.client, e.index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) < 1 {
+		return nil, errors.New("Elastic repository: group not found")
+	}
+	return &groups[0], nil
+}
+
+// GetAllByMember implements Repository
+func (e *elasticRepository) GetAllByMember(ctx context.Context, userId string) ([]Group, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("Members.UserId", userId))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Group](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	index, alias := "groups_19092022", "groups"
+	return &elasticRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
@@ -0,0 +1,126 @@
+package group
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func actorOf(c echo.Context) Actor {
+	return Actor{ID: fmt.Sprintf("%v", c.Get("id")), Role: fmt.Sprintf("%v", c.Get("role"))}
+}
+
+func (h Handler) createGroup(c echo.Context) error {
+	request := new(CreateGroupRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Create(c.Request().Context(), actorOf(c), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Success")
+}
+
+func (h Handler) updateGroup(c echo.Context) error {
+	id := c.Param("id")
+	request := new(UpdateGroupRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Update(c.Request().Context(), actorOf(c), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) deleteGroup(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.Delete(c.Request().Context(), actorOf(c), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getAllGroups(c echo.Context) error {
+	result, err := h.service.GetAll(c.Request().Context(), actorOf(c))
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getByIdGroup(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.GetById(c.Request().Context(), actorOf(c), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) addMember(c echo.Context) error {
+	id := c.Param("id")
+	request := new(MemberRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.AddMember(c.Request().Context(), actorOf(c), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Success")
+}
+
+func (h Handler) updateMember(c echo.Context) error {
+	id := c.Param("id")
+	userId := c.Param("userId")
+	request := new(MemberRoleRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.UpdateMember(c.Request().Context(), actorOf(c), id, userId, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) removeMember(c echo.Context) error {
+	id := c.Param("id")
+	userId := c.Param("userId")
+
+	result, err := h.service.RemoveMember(c.Request().Context(), actorOf(c), id, userId)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func NewGroupHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+func RegisterGroupHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	token := authenticator.TokenHandlerMiddlewareFunc("user", "admin")
+	instance.POST("api/groups", h.createGroup, token)
+	instance.GET("api/groups", h.getAllGroups, token)
+	instance.GET("api/groups/:id", h.getByIdGroup, token)
+	instance.PUT("api/groups/:id", h.updateGroup, token)
+	instance.DELETE("api/groups/:id", h.deleteGroup, token)
+	instance.POST("api/groups/:id/members", h.addMember, token)
+	instance.PUT("api/groups/:id/members/:userId", h.updateMember, token)
+	instance.DELETE("api/groups/:id/members/:userId", h.removeMember, token)
+}
@@ -0,0 +1,139 @@
+package group
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+// Member roles inside a group. Group admins manage the group and its members
+// without needing the system admin role.
+const (
+	MemberRole = "member"
+	AdminRole  = "admin"
+)
+
+type Group struct {
+	ID          string   `bson:"_id"`
+	Name        string   `bson:"name"`
+	Description string   `bson:"description"`
+	Members     []Member `bson:"members"`
+	TenantId    string   `bson:"tenant_id"`
+	CreatedBy   string   `bson:"created_by"`
+	CreatedAt   string   `bson:"created_at"`
+	UpdatedAt   string   `bson:"updated_at"`
+}
+
+type Member struct {
+	UserId  string `bson:"user_id"`
+	Role    string `bson:"role"`
+	AddedAt string `bson:"added_at"`
+}
+
+// Actor is the authenticated user a group operation is performed for.
+type Actor struct {
+	ID   string
+	Role string
+}
+
+// GroupPrincipal names the group as a subject that can be granted access.
+func GroupPrincipal(groupId string) string {
+	return "group:" + groupId
+}
+
+func (g *Group) member(userId string) *Member {
+	for i := range g.Members {
+		if g.Members[i].UserId == userId {
+			return &g.Members[i]
+		}
+	}
+	return nil
+}
+
+func (g *Group) removeMember(userId string) {
+	members := make([]Member, 0, len(g.Members))
+	for _, m := range g.Members {
+		if m.UserId != userId {
+			members = append(members, m)
+		}
+	}
+	g.Members = members
+}
+
+func (g *Group) adminCount() int {
+	count := 0
+	for _, m := range g.Members {
+		if m.Role == AdminRole {
+			count++
+		}
+	}
+	return count
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+}
+
+func (receiver *CreateGroupRequest) ToGroup(creatorId string) *Group {
+	now := time.Now().Format(timeLayout)
+	return &Group{
+		ID:          uuid.New().String(),
+		Name:        receiver.Name,
+		Description: receiver.Description,
+		Members:     []Member{{UserId: creatorId, Role: AdminRole, AddedAt: now}},
+		CreatedBy:   creatorId,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+type UpdateGroupRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+}
+
+type MemberRequest struct {
+	UserId string `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required,oneof=member admin"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=member admin"`
+}
+
+type GroupResponse struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Principal   string           `json:"principal"`
+	Members     []MemberResponse `json:"members"`
+	CreatedBy   string           `json:"created_by"`
+	CreatedAt   string           `json:"created_at"`
+	UpdatedAt   string           `json:"updated_at"`
+}
+
+type MemberResponse struct {
+	UserId  string `json:"user_id"`
+	Role    string `json:"role"`
+	AddedAt string `json:"added_at"`
+}
+
+func (g *Group) ToGroupResponse() *GroupResponse {
+	members := make([]MemberResponse, 0)
+	for _, m := range g.Members {
+		members = append(members, MemberResponse{UserId: m.UserId, Role: m.Role, AddedAt: m.AddedAt})
+	}
+	return &GroupResponse{
+		ID:          g.ID,
+		Name:        g.Name,
+		Description: g.Description,
+		Principal:   GroupPrincipal(g.ID),
+		Members:     members,
+		CreatedBy:   g.CreatedBy,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+}
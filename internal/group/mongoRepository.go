@@ -0,0 +1,67 @@
+package group
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// groupRepository keeps all organizations in one collection, told apart by tenant_id.
+type groupRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (g groupRepository) Create(ctx context.Context, group *Group) (string, error) {
+	if _, err := g.collection.InsertOne(ctx, group); err != nil {
+		return "", err
+	}
+	return group.ID, nil
+}
+
+func (g groupRepository) Update(ctx context.Context, group *Group) (bool, error) {
+	scoped, err := g.collections.Filter(ctx, bson.M{"_id": group.ID})
+	if err != nil {
+		return false, err
+	}
+	result, err := g.collection.ReplaceOne(ctx, scoped, group)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (g groupRepository) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := g.collections.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (g groupRepository) GetAll(ctx context.Context) ([]Group, error) {
+	return g.find(ctx, bson.M{})
+}
+
+func (g groupRepository) GetById(ctx context.Context, id string) (*Group, error) {
+	group := new(Group)
+	if err := g.collections.FindOne(ctx, bson.M{"_id": id}, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (g groupRepository) GetAllByMember(ctx context.Context, userId string) ([]Group, error) {
+	return g.find(ctx, bson.M{"members.user_id": userId})
+}
+
+func (g groupRepository) find(ctx context.Context, filter bson.M) ([]Group, error) {
+	return mongoClient.FindAll[Group](ctx, g.collections, filter)
+}
+
+func NewGroupRepository(db *mongo.Database) Repository {
+	col := db.Collection("groups")
+	return &groupRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "groups", false)}
+}
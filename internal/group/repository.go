@@ -0,0 +1,14 @@
+package group
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, group *Group) (string, error)
+	Update(ctx context.Context, group *Group) (bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	GetAll(ctx context.Context) ([]Group, error)
+	GetById(ctx context.Context, id string) (*Group, error)
+	GetAllByMember(ctx context.Context, userId string) ([]Group, error)
+}
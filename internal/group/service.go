@@ -0,0 +1,290 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	membershipKey = "doc-system:user-groups:"
+	membershipTtl = 5 * time.Minute
+)
+
+type Service interface {
+	Create(ctx context.Context, actor Actor, request CreateGroupRequest) (string, error)
+	Update(ctx context.Context, actor Actor, id string, request UpdateGroupRequest) (bool, error)
+	Delete(ctx context.Context, actor Actor, id string) (bool, error)
+	GetAll(ctx context.Context, actor Actor) ([]GroupResponse, error)
+	GetById(ctx context.Context, actor Actor, id string) (*GroupResponse, error)
+	AddMember(ctx context.Context, actor Actor, id string, request MemberRequest) (bool, error)
+	UpdateMember(ctx context.Context, actor Actor, id string, userId string, request MemberRoleRequest) (bool, error)
+	RemoveMember(ctx context.Context, actor Actor, id string, userId string) (bool, error)
+	AddInvitedMember(ctx context.Context, id string, userId string) error
+	IsGroupAdmin(ctx context.Context, id string, userId string) bool
+	RemoveUser(ctx context.Context, userId string) error
+	GroupIds(ctx context.Context, userId string) ([]string, error)
+}
+
+var (
+	errGroupNotFound  = appError.NotFound("group_not_found", "Service: group id not found")
+	errNotGroupMember = appError.NotFound("not_group_member", "Service: user is not a member of the group")
+	errLastGroupAdmin = appError.Conflict("last_group_admin", "Service: a group needs at least one admin")
+)
+
+type groupService struct {
+	repository Repository
+	users      auth.Repository
+	redis      *redisClient.RedisClient
+}
+
+func (g groupService) Create(ctx context.Context, actor Actor, request CreateGroupRequest) (string, error) {
+	group := request.ToGroup(actor.ID)
+	group.TenantId = helpers.Tenant(ctx)
+	id, err := g.repository.Create(ctx, group)
+	if err != nil {
+		return "", appError.Unavailable("group_not_created", "Service: failed to create group").Wrap(err)
+	}
+	g.invalidate(actor.ID)
+	return id, nil
+}
+
+func (g groupService) Update(ctx context.Context, actor Actor, id string, request UpdateGroupRequest) (bool, error) {
+	group, err := g.managedGroup(ctx, actor, id)
+	if err != nil {
+		return false, err
+	}
+	group.Name = request.Name
+	group.Description = request.Description
+	if err := g.save(ctx, group); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g groupService) Delete(ctx context.Context, actor Actor, id string) (bool, error) {
+	group, err := g.managedGroup(ctx, actor, id)
+	if err != nil {
+		return false, err
+	}
+	if result, err := g.repository.Delete(ctx, group.ID); err != nil || !result {
+		return false, errGroupNotFound.Wrap(err)
+	}
+	for _, m := range group.Members {
+		g.invalidate(m.UserId)
+	}
+	return true, nil
+}
+
+// GetAll lists every group of the organization for its admins and the actor's own
+// groups otherwise.
+func (g groupService) GetAll(ctx context.Context, actor Actor) ([]GroupResponse, error) {
+	var groups []Group
+	var err error
+	if actor.Role == "admin" {
+		groups, err = g.repository.GetAll(ctx)
+	} else {
+		groups, err = g.repository.GetAllByMember(ctx, actor.ID)
+	}
+	if err != nil {
+		return nil, appError.NotFound("groups_not_found", err.Error())
+	}
+	groupResponses := make([]GroupResponse, 0)
+	for i := 0; i < len(groups); i++ {
+		groupResponses = append(groupResponses, *groups[i].ToGroupResponse())
+	}
+	return groupResponses, nil
+}
+
+func (g groupService) GetById(ctx context.Context, actor Actor, id string) (*GroupResponse, error) {
+	group, err := g.group(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != "admin" && group.member(actor.ID) == nil {
+		return nil, errGroupNotFound
+	}
+	return group.ToGroupResponse(), nil
+}
+
+func (g groupService) AddMember(ctx context.Context, actor Actor, id string, request MemberRequest) (bool, error) {
+	group, err := g.managedGroup(ctx, actor, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := g.users.GetById(ctx, request.UserId); err != nil {
+		return false, appError.NotFound("user_not_found", "Service: user id not found").Wrap(err)
+	}
+	if group.member(request.UserId) != nil {
+		return false, appError.Conflict("already_group_member", "Service: user is already a member of the group")
+	}
+	group.Members = append(group.Members, Member{
+		UserId:  request.UserId,
+		Role:    request.Role,
+		AddedAt: time.Now().Format(timeLayout),
+	})
+	if err := g.save(ctx, group); err != nil {
+		return false, err
+	}
+	g.invalidate(request.UserId)
+	return true, nil
+}
+
+func (g groupService) UpdateMember(ctx context.Context, actor Actor, id string, userId string, request MemberRoleRequest) (bool, error) {
+	group, err := g.managedGroup(ctx, actor, id)
+	if err != nil {
+		return false, err
+	}
+	member := group.member(userId)
+	if member == nil {
+		return false, errNotGroupMember
+	}
+	if member.Role == AdminRole && request.Role != AdminRole && group.adminCount() == 1 {
+		return false, errLastGroupAdmin
+	}
+	member.Role = request.Role
+	if err := g.save(ctx, group); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveMember is allowed to group admins and to members leaving on their own.
+func (g groupService) RemoveMember(ctx context.Context, actor Actor, id string, userId string) (bool, error) {
+	var group *Group
+	var err error
+	if actor.ID == userId {
+		group, err = g.group(ctx, id)
+	} else {
+		group, err = g.managedGroup(ctx, actor, id)
+	}
+	if err != nil {
+		return false, err
+	}
+	member := group.member(userId)
+	if member == nil {
+		return false, errNotGroupMember
+	}
+	if member.Role == AdminRole && group.adminCount() == 1 {
+		return false, errLastGroupAdmin
+	}
+	group.removeMember(userId)
+	if err := g.save(ctx, group); err != nil {
+		return false, err
+	}
+	g.invalidate(userId)
+	return true, nil
+}
+
+// AddInvitedMember adds a user who accepted an invitation into the group. The
+// inviter's right to manage the group was checked when the invitation was sent.
+func (g groupService) AddInvitedMember(ctx context.Context, id string, userId string) error {
+	group, err := g.repository.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if group.member(userId) == nil {
+		group.Members = append(group.Members, Member{UserId: userId, Role: MemberRole, AddedAt: time.Now().Format(timeLayout)})
+		group.UpdatedAt = time.Now().Format(timeLayout)
+		if _, err := g.repository.Update(ctx, group); err != nil {
+			return err
+		}
+	}
+	g.invalidate(userId)
+	return nil
+}
+
+func (g groupService) IsGroupAdmin(ctx context.Context, id string, userId string) bool {
+	group, err := g.repository.GetById(ctx, id)
+	if err != nil {
+		return false
+	}
+	member := group.member(userId)
+	return member != nil && member.Role == AdminRole
+}
+
+// RemoveUser drops the user from every group, for accounts that are erased. Groups
+// left without a group admin stay manageable by the organization's admins.
+func (g groupService) RemoveUser(ctx context.Context, userId string) error {
+	groups, err := g.repository.GetAllByMember(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for i := range groups {
+		groups[i].removeMember(userId)
+		groups[i].UpdatedAt = time.Now().Format(timeLayout)
+		if _, err := g.repository.Update(ctx, &groups[i]); err != nil {
+			return err
+		}
+	}
+	g.invalidate(userId)
+	return nil
+}
+
+// GroupIds returns the groups the user belongs to. The list is cached in redis and
+// dropped whenever the user's memberships change.
+func (g groupService) GroupIds(ctx context.Context, userId string) ([]string, error) {
+	ids := make([]string, 0)
+	if found, err := g.redis.Get(membershipKey+userId, &ids); err == nil && found {
+		return ids, nil
+	}
+	groups, err := g.repository.GetAllByMember(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		ids = append(ids, group.ID)
+	}
+	if err := g.redis.SetWithExpiration(membershipKey+userId, ids, membershipTtl); err != nil {
+		log.Errorf("Service: failed to cache group membership: %v", err)
+	}
+	return ids, nil
+}
+
+func (g groupService) group(ctx context.Context, id string) (*Group, error) {
+	group, err := g.repository.GetById(ctx, id)
+	if err != nil {
+		return nil, errGroupNotFound.Wrap(err)
+	}
+	return group, nil
+}
+
+// managedGroup loads a group the actor may change: organization admins manage every
+// group, everyone else only the groups they are a group admin of.
+func (g groupService) managedGroup(ctx context.Context, actor Actor, id string) (*Group, error) {
+	group, err := g.group(ctx, id)
+	if err != nil || actor.Role == "admin" {
+		return group, err
+	}
+	member := group.member(actor.ID)
+	if member == nil {
+		return nil, errGroupNotFound
+	}
+	if member.Role != AdminRole {
+		return nil, appError.Forbidden("not_group_admin", "Service: only group admins can manage the group")
+	}
+	return group, nil
+}
+
+func (g groupService) save(ctx context.Context, group *Group) error {
+	group.UpdatedAt = time.Now().Format(timeLayout)
+	if result, err := g.repository.Update(ctx, group); err != nil || !result {
+		return appError.Unavailable("group_not_updated", "Service: failed to update group").Wrap(err)
+	}
+	return nil
+}
+
+func (g groupService) invalidate(userId string) {
+	if err := g.redis.Delete(membershipKey + userId); err != nil {
+		log.Errorf("Service: failed to drop cached group membership: %v", err)
+	}
+}
+
+func NewGroupService(repo Repository, users auth.Repository, redis *redisClient.RedisClient) Service {
+	return &groupService{repository: repo, users: users, redis: redis}
+}
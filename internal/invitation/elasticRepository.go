@@ -0,0 +1,124 @@
+package invitation
+
+import (
+	"context"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/pkg/errors"
+)
+
+// elasticRepository keeps all organizations in one index, told apart by TenantId.
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+var notClosed = []interface{}{
+	map[string]interface{}{"wildcard": map[string]interface{}{"AcceptedAt.keyword": "?*"}},
+	map[string]interface{}{"wildcard": map[string]interface{}{"RevokedAt.keyword": "?*"}},
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, invitation *Invitation) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, invitation.ID, invitation); err != nil {
+		return "", err
+	}
+	return invitation.ID, nil
+}
+
+// GetById implements Repository
+func (e *elasticRepository) GetById(ctx context.Context, id string) (*Invitation, error) {
+	return e.first(ctx, elasticclient.Term("ID", id))
+}
+
+// GetByTokenHash implements Repository
+func (e *elasticRepository) GetByTokenHash(ctx context.Context, hash string) (*Invitation, error) {
+	return e.first(ctx, elasticclient.Term("TokenHash", hash))
+}
+
+// GetAll implements Repository
+func (e *elasticRepository) GetAll(ctx context.Context) ([]Invitation, error) {
+	scoped, err := e.scope.Filter(ctx, map[string]interface{}{"match_all": map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Invitation](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+// GetAllByInviter implements Repository
+func (e *elasticRepository) GetAllByInviter(ctx context.Context, inviterId string) ([]Invitation, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("InvitedBy", inviterId))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Invitation](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+// MarkAccepted implements Repository
+func (e *elasticRepository) MarkAccepted(ctx context.Context, id string) (bool, error) {
+	return e.closePending(ctx, id, "AcceptedAt")
+}
+
+// Revoke implements Repository
+func (e *elasticRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	return e.closePending(ctx, id, "RevokedAt")
+}
+
+// Reopen implements Repository
+func (e *elasticRepository) Reopen(ctx context.Context, id string) (bool, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Must(
+		elasticclient.Term("ID", id),
+		map[string]interface{}{"wildcard": map[string]interface{}{"AcceptedAt.keyword": "?*"}},
+	))
+	if err != nil {
+		return false, err
+	}
+	updated, err := elasticclient.UpdateByQuery(ctx, e.client, e.index, scoped, map[string]interface{}{"AcceptedAt": ""})
+	return updated > 0, err
+}
+
+func (e *elasticRepository) closePending(ctx context.Context, id string, field string) (bool, error) {
+	scoped, err := e.scope.Filter(ctx, map[string]interface{}{"bool": map[string]interface{}{
+		"must":     []interface{}{elasticclient.Term("ID", id)},
+		"must_not": notClosed,
+	}})
+	if err != nil {
+		return false, err
+	}
+	updated, err := elasticclient.UpdateByQuery(ctx, e.client, e.index, scoped, map[string]interface{}{
+		field: time.Now().Format(timeLayout),
+	})
+	return updated > 0, err
+}
+
+func (e *elasticRepository) first(ctx context.Context, query map[string]interface{}) (*Invitation, error) {
+	scoped, err := e.scope.Filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := elasticclient.Search[Invitation](ctx, e.client, e.index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) < 1 {
+		return nil, errors.New("Elastic repository: invitation not found")
+	}
+	return &invitations[0], nil
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	index, alias := "invitations_19092022", "invitations"
+	return &elasticRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
@@ -0,0 +1,77 @@
+package invitation
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func inviterOf(c echo.Context) Inviter {
+	return Inviter{
+		ID:       fmt.Sprintf("%v", c.Get("id")),
+		Role:     fmt.Sprintf("%v", c.Get("role")),
+		TenantId: fmt.Sprintf("%v", c.Get("tenant")),
+	}
+}
+
+func (h Handler) createInvitation(c echo.Context) error {
+	request := new(CreateInvitationRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Create(c.Request().Context(), inviterOf(c), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Success")
+}
+
+func (h Handler) getAllInvitations(c echo.Context) error {
+	result, err := h.service.GetAll(c.Request().Context(), inviterOf(c))
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) revokeInvitation(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.Revoke(c.Request().Context(), inviterOf(c), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) acceptInvitation(c echo.Context) error {
+	request := new(AcceptInvitationRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Accept(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Success")
+}
+
+func NewInvitationHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+func RegisterInvitationHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	token := authenticator.TokenHandlerMiddlewareFunc("user", "admin")
+	instance.POST("api/invitations", h.createInvitation, token, middleware.NotImpersonatedMiddlewareFunc)
+	instance.GET("api/invitations", h.getAllInvitations, token)
+	instance.DELETE("api/invitations/:id", h.revokeInvitation, token, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/invitations/accept", h.acceptInvitation)
+}
@@ -0,0 +1,115 @@
+package invitation
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+type Invitation struct {
+	ID         string `bson:"_id"`
+	Email      string `bson:"email"`
+	Role       string `bson:"role"`
+	GroupId    string `bson:"group_id"`
+	TenantId   string `bson:"tenant_id"`
+	InvitedBy  string `bson:"invited_by"`
+	TokenHash  string `bson:"token_hash"`
+	ExpiresAt  string `bson:"expires_at"`
+	AcceptedAt string `bson:"accepted_at"`
+	RevokedAt  string `bson:"revoked_at"`
+	CreatedAt  string `bson:"created_at"`
+}
+
+// Status is derived from the timestamps: pending, accepted, revoked or expired.
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != "":
+		return "accepted"
+	case i.RevokedAt != "":
+		return "revoked"
+	case i.Expired(now):
+		return "expired"
+	}
+	return "pending"
+}
+
+func (i *Invitation) Expired(now time.Time) bool {
+	expiresAt, err := time.ParseInLocation(timeLayout, i.ExpiresAt, time.Local)
+	return err != nil || now.After(expiresAt)
+}
+
+// Inviter is the authenticated user sending or managing invitations.
+type Inviter struct {
+	ID       string
+	Role     string
+	TenantId string
+}
+
+type CreateInvitationRequest struct {
+	Email   string `json:"email" validate:"required,email"`
+	Role    string `json:"role" validate:"required,oneof=user admin"`
+	GroupId string `json:"group_id"`
+}
+
+// ToInvitation returns the invitation together with its token, which is only
+// stored as a hash.
+func (receiver *CreateInvitationRequest) ToInvitation(inviter Inviter, ttl time.Duration) (*Invitation, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(raw)
+	now := time.Now()
+	return &Invitation{
+		ID:        uuid.New().String(),
+		Email:     strings.ToLower(strings.TrimSpace(receiver.Email)),
+		Role:      receiver.Role,
+		GroupId:   receiver.GroupId,
+		TenantId:  inviter.TenantId,
+		InvitedBy: inviter.ID,
+		TokenHash: HashToken(token),
+		ExpiresAt: now.Add(ttl).Format(timeLayout),
+		CreatedAt: now.Format(timeLayout),
+	}, token, nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type InvitationResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	GroupId   string `json:"group_id,omitempty"`
+	InvitedBy string `json:"invited_by"`
+	Status    string `json:"status"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (i *Invitation) ToInvitationResponse() *InvitationResponse {
+	return &InvitationResponse{
+		ID:        i.ID,
+		Email:     i.Email,
+		Role:      i.Role,
+		GroupId:   i.GroupId,
+		InvitedBy: i.InvitedBy,
+		Status:    i.Status(time.Now()),
+		ExpiresAt: i.ExpiresAt,
+		CreatedAt: i.CreatedAt,
+	}
+}
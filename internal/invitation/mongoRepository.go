@@ -0,0 +1,78 @@
+package invitation
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type invitationRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (i invitationRepository) Create(ctx context.Context, invitation *Invitation) (string, error) {
+	if _, err := i.collection.InsertOne(ctx, invitation); err != nil {
+		return "", err
+	}
+	return invitation.ID, nil
+}
+
+func (i invitationRepository) GetById(ctx context.Context, id string) (*Invitation, error) {
+	invitation := new(Invitation)
+	if err := i.collections.FindOne(ctx, bson.M{"_id": id}, invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (i invitationRepository) GetByTokenHash(ctx context.Context, hash string) (*Invitation, error) {
+	invitation := new(Invitation)
+	if err := i.collections.FindOne(ctx, bson.M{"token_hash": hash}, invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (i invitationRepository) GetAll(ctx context.Context) ([]Invitation, error) {
+	return mongoClient.FindAll[Invitation](ctx, i.collections, bson.M{})
+}
+
+func (i invitationRepository) GetAllByInviter(ctx context.Context, inviterId string) ([]Invitation, error) {
+	return mongoClient.FindAll[Invitation](ctx, i.collections, bson.M{"invited_by": inviterId})
+}
+
+func (i invitationRepository) MarkAccepted(ctx context.Context, id string) (bool, error) {
+	return i.closePending(ctx, id, "accepted_at")
+}
+
+func (i invitationRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	return i.closePending(ctx, id, "revoked_at")
+}
+
+func (i invitationRepository) Reopen(ctx context.Context, id string) (bool, error) {
+	filter := bson.M{"_id": id, "accepted_at": bson.M{"$ne": ""}}
+	updateResult, err := i.collections.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"accepted_at": ""}})
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount > 0, nil
+}
+
+func (i invitationRepository) closePending(ctx context.Context, id string, field string) (bool, error) {
+	filter := bson.M{"_id": id, "accepted_at": "", "revoked_at": ""}
+	update := bson.M{"$set": bson.M{field: time.Now().Format(timeLayout)}}
+	updateResult, err := i.collections.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount > 0, nil
+}
+
+func NewInvitationRepository(db *mongo.Database) Repository {
+	col := db.Collection("invitations")
+	return &invitationRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "invitations", false)}
+}
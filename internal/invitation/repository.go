@@ -0,0 +1,19 @@
+package invitation
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, invitation *Invitation) (string, error)
+	GetById(ctx context.Context, id string) (*Invitation, error)
+	GetByTokenHash(ctx context.Context, hash string) (*Invitation, error)
+	GetAll(ctx context.Context) ([]Invitation, error)
+	GetAllByInviter(ctx context.Context, inviterId string) ([]Invitation, error)
+	// MarkAccepted and Revoke only change pending invitations, so a token is used once.
+	MarkAccepted(ctx context.Context, id string) (bool, error)
+	Revoke(ctx context.Context, id string) (bool, error)
+	// Reopen turns an accepted invitation back into a pending one when the account
+	// could not be created from it.
+	Reopen(ctx context.Context, id string) (bool, error)
+}
@@ -0,0 +1,165 @@
+package invitation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/audit"
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/group"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Create(ctx context.Context, inviter Inviter, request CreateInvitationRequest) (*InvitationResponse, error)
+	GetAll(ctx context.Context, inviter Inviter) ([]InvitationResponse, error)
+	Revoke(ctx context.Context, inviter Inviter, id string) (bool, error)
+	Accept(ctx context.Context, request AcceptInvitationRequest) (string, error)
+}
+
+type invitationService struct {
+	repository Repository
+	users      auth.Repository
+	accounts   auth.Service
+	groups     group.Service
+	audit      audit.Service
+	mail       mail.Sender
+	settings   config.AccountSettings
+}
+
+// Create invites an e-mail address into the inviter's organization. Admins may
+// invite with any role; group admins only invite users into a group they manage.
+func (i invitationService) Create(ctx context.Context, inviter Inviter, request CreateInvitationRequest) (*InvitationResponse, error) {
+	if inviter.Role != "admin" {
+		if request.GroupId == "" || !i.groups.IsGroupAdmin(ctx, request.GroupId, inviter.ID) {
+			return nil, appError.Forbidden("invitation_not_allowed", "Service: only admins and group admins can invite users")
+		}
+		if request.Role != "user" {
+			return nil, appError.Forbidden("invitation_role_not_allowed", "Service: group admins can only invite users")
+		}
+	} else if request.GroupId != "" {
+		if _, err := i.groups.GetById(ctx, group.Actor{ID: inviter.ID, Role: inviter.Role}, request.GroupId); err != nil {
+			return nil, err
+		}
+	}
+	if exists, _ := i.users.CheckEmail(helpers.WithAllTenants(ctx), request.Email); exists {
+		return nil, appError.Conflict("email_exists", "Service: a user with this e-mail address already exists")
+	}
+
+	ttl := time.Duration(i.settings.InvitationTokenTime) * time.Minute
+	invitation, token, err := request.ToInvitation(inviter, ttl)
+	if err != nil {
+		return nil, appError.Internal("invitation_token_not_generated", "Service: failed to generate invitation token").Wrap(err)
+	}
+	if _, err := i.repository.Create(ctx, invitation); err != nil {
+		return nil, appError.Unavailable("invitation_not_created", "Service: failed to create invitation").Wrap(err)
+	}
+	i.audit.Record(ctx, audit.NewEvent(audit.UserInvited, inviter.ID, invitation.ID, "", map[string]string{
+		"email": invitation.Email,
+		"role":  invitation.Role,
+	}))
+
+	message := mail.Message{
+		To:      []string{invitation.Email},
+		Subject: "You are invited to doc-system",
+		Body: fmt.Sprintf("Hello,\n\nYou have been invited to doc-system. Choose your user name and password with the link below before %s.\n\n%s/accept-invitation?token=%s\n",
+			invitation.ExpiresAt, i.settings.BaseUrl, token),
+	}
+	if err := i.mail.Send(ctx, message); err != nil {
+		log.Errorf("Service: failed to send invitation e-mail: %v", err)
+	}
+	return invitation.ToInvitationResponse(), nil
+}
+
+// GetAll lists the organization's invitations for admins and the inviter's own ones
+// for group admins.
+func (i invitationService) GetAll(ctx context.Context, inviter Inviter) ([]InvitationResponse, error) {
+	var invitations []Invitation
+	var err error
+	if inviter.Role == "admin" {
+		invitations, err = i.repository.GetAll(ctx)
+	} else {
+		invitations, err = i.repository.GetAllByInviter(ctx, inviter.ID)
+	}
+	if err != nil {
+		return nil, appError.NotFound("invitations_not_found", err.Error())
+	}
+	invitationResponses := make([]InvitationResponse, 0)
+	for j := 0; j < len(invitations); j++ {
+		invitationResponses = append(invitationResponses, *invitations[j].ToInvitationResponse())
+	}
+	return invitationResponses, nil
+}
+
+func (i invitationService) Revoke(ctx context.Context, inviter Inviter, id string) (bool, error) {
+	invitation, err := i.repository.GetById(ctx, id)
+	if err != nil || (inviter.Role != "admin" && invitation.InvitedBy != inviter.ID) {
+		return false, appError.NotFound("invitation_not_found", "Service: invitation not found")
+	}
+	result, _ := i.repository.Revoke(ctx, id)
+	if !result {
+		return false, appError.Conflict("invitation_not_pending", "Service: invitation is no longer pending")
+	}
+	return true, nil
+}
+
+// Accept creates the invited account with the invitee's own password. The address
+// counts as verified since the token was delivered to it. The invitation is claimed
+// before the account is created, so concurrent accepts create one account, and is
+// reopened when the account can not be created.
+func (i invitationService) Accept(ctx context.Context, request AcceptInvitationRequest) (string, error) {
+	invitation, err := i.repository.GetByTokenHash(helpers.WithAllTenants(ctx), HashToken(request.Token))
+	if err != nil || invitation.Status(time.Now()) != "pending" {
+		return "", appError.Validation("invitation_invalid", "Service: invitation is invalid or expired")
+	}
+
+	tenantCtx := helpers.WithTenant(ctx, invitation.TenantId)
+	claimed, err := i.repository.MarkAccepted(tenantCtx, invitation.ID)
+	if err != nil {
+		return "", appError.Unavailable("invitation_not_accepted", "Service: failed to accept invitation").Wrap(err)
+	}
+	if !claimed {
+		return "", appError.Validation("invitation_invalid", "Service: invitation is invalid or expired")
+	}
+	id, err := i.accounts.Create(tenantCtx, auth.CreateUserRequest{
+		Username:      request.Username,
+		Password:      request.Password,
+		Email:         invitation.Email,
+		Role:          invitation.Role,
+		EmailVerified: true,
+	})
+	if err != nil {
+		if _, reopenErr := i.repository.Reopen(tenantCtx, invitation.ID); reopenErr != nil {
+			log.Errorf("Service: failed to reopen invitation %s: %v", invitation.ID, reopenErr)
+		}
+		return "", err
+	}
+	if invitation.GroupId != "" {
+		if err := i.groups.AddInvitedMember(tenantCtx, invitation.GroupId, id); err != nil {
+			log.Errorf("Service: failed to add invited user %s to group %s: %v", id, invitation.GroupId, err)
+		}
+	}
+	i.audit.Record(ctx, audit.NewEvent(audit.InvitationAccepted, id, invitation.ID, "", map[string]string{
+		"email":      invitation.Email,
+		"invited_by": invitation.InvitedBy,
+	}))
+	return id, nil
+}
+
+func NewInvitationService(repo Repository, users auth.Repository, accounts auth.Service, groups group.Service,
+	auditService audit.Service, sender mail.Sender, settings config.AccountSettings) Service {
+	return &invitationService{
+		repository: repo,
+		users:      users,
+		accounts:   accounts,
+		groups:     groups,
+		audit:      auditService,
+		mail:       sender,
+		settings:   settings,
+	}
+}
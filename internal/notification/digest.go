@@ -0,0 +1,39 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Digester sends the due notification digests on every poll until the context is
+// cancelled.
+type Digester struct {
+	service  Service
+	interval time.Duration
+}
+
+func (d Digester) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		sent, err := d.service.SendDigests(ctx)
+		if err != nil {
+			log.Errorf("Digester: failed to read the due digests: %v", err)
+		} else if sent > 0 {
+			log.Infof("Digester: sent %d digests", sent)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewDigester polls every DigestPollInterval minutes.
+func NewDigester(s Service, settings config.NotificationSettings) Digester {
+	return Digester{service: s, interval: digestPoll(settings)}
+}
@@ -0,0 +1,262 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/pkg/errors"
+)
+
+// elasticRepository keeps all organizations in one index, told apart by TenantId.
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, notification *Notification) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, notification.ID, notification); err != nil {
+		return "", err
+	}
+	return notification.ID, nil
+}
+
+// GetById implements Repository
+func (e *elasticRepository) GetById(ctx context.Context, id string) (*Notification, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", id))
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := elasticclient.Search[Notification](ctx, e.client, e.index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) < 1 {
+		return nil, errors.New("Elastic repository: notification not found")
+	}
+	return &notifications[0], nil
+}
+
+// GetAll implements Repository
+func (e *elasticRepository) GetAll(ctx context.Context, filter Filter) ([]Notification, error) {
+	scoped, err := e.scope.Filter(ctx, e.inbox(filter))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Notification](ctx, e.client, e.index, map[string]interface{}{
+		"size":  filter.Limit,
+		"sort":  []interface{}{map[string]interface{}{"CreatedAt.keyword": "desc"}},
+		"query": scoped,
+	})
+}
+
+// CountUnread implements Repository
+func (e *elasticRepository) CountUnread(ctx context.Context, userId string) (int64, error) {
+	scoped, err := e.scope.Filter(ctx, e.inbox(Filter{UserId: userId, Unread: true}))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.Count(ctx, e.client, e.index, scoped)
+}
+
+// UpdateRead implements Repository
+func (e *elasticRepository) UpdateRead(ctx context.Context, userId string, id string, readAt string) (bool, error) {
+	query := elasticclient.Must(elasticclient.Term("ID", id), elasticclient.Term("UserId", userId))
+	scoped, err := e.scope.Filter(ctx, query)
+	if err != nil {
+		return false, err
+	}
+	updated, err := elasticclient.UpdateByQuery(ctx, e.client, e.index, scoped,
+		map[string]interface{}{"Read": readAt != "", "ReadAt": readAt})
+	return updated > 0, err
+}
+
+// MarkAllRead implements Repository
+func (e *elasticRepository) MarkAllRead(ctx context.Context, userId string, readAt string) (int64, error) {
+	scoped, err := e.scope.Filter(ctx, e.inbox(Filter{UserId: userId, Unread: true}))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.UpdateByQuery(ctx, e.client, e.index, scoped,
+		map[string]interface{}{"Read": true, "ReadAt": readAt})
+}
+
+// DeleteAllByUser implements Repository
+func (e *elasticRepository) DeleteAllByUser(ctx context.Context, userId string) (int64, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("UserId", userId))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+}
+
+func (e *elasticRepository) inbox(filter Filter) map[string]interface{} {
+	queries := []map[string]interface{}{
+		elasticclient.Term("UserId", filter.UserId),
+		{"term": map[string]interface{}{"InApp": true}},
+	}
+	if filter.Unread {
+		queries = append(queries, map[string]interface{}{"term": map[string]interface{}{"Read": false}})
+	}
+	if filter.Since != "" {
+		queries = append(queries, map[string]interface{}{"range": map[string]interface{}{"CreatedAt.keyword": map[string]interface{}{"gt": filter.Since}}})
+	}
+	return elasticclient.Must(queries...)
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	index, alias := "notifications_19092022", "notifications"
+	return &elasticRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
+
+type elasticPreferenceRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+// Get implements PreferenceRepository
+func (e *elasticPreferenceRepository) Get(ctx context.Context, userId string) (*Preferences, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", userId))
+	if err != nil {
+		return nil, err
+	}
+	preferences, err := elasticclient.Search[Preferences](ctx, e.client, e.index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil || len(preferences) < 1 {
+		return nil, err
+	}
+	return &preferences[0], nil
+}
+
+// Save implements PreferenceRepository
+func (e *elasticPreferenceRepository) Save(ctx context.Context, preferences *Preferences) (bool, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, preferences.ID, preferences); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetDigestDue implements PreferenceRepository
+func (e *elasticPreferenceRepository) GetDigestDue(ctx context.Context, before string, now string, limit int) ([]Preferences, error) {
+	if !helpers.AllTenants(ctx) {
+		return nil, helpers.ErrAllTenantsRequired
+	}
+	hits, err := elasticclient.SearchVersioned[Preferences](ctx, e.client, e.index, map[string]interface{}{
+		"size": limit,
+		"sort": []interface{}{map[string]interface{}{"LastDigestAt.keyword": "asc"}},
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"must": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{"Digest": true}},
+				map[string]interface{}{"range": map[string]interface{}{"LastDigestAt.keyword": map[string]interface{}{"lt": before}}},
+			},
+			"must_not": []interface{}{
+				map[string]interface{}{"range": map[string]interface{}{"DigestRetryAt.keyword": map[string]interface{}{"gt": now}}},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	due := make([]Preferences, len(hits))
+	for i, hit := range hits {
+		due[i] = hit.Source
+		due[i].SeqNo, due[i].PrimaryTerm = hit.SeqNo, hit.PrimaryTerm
+	}
+	return due, nil
+}
+
+// Claim implements PreferenceRepository
+func (e *elasticPreferenceRepository) Claim(ctx context.Context, preferences *Preferences, until string) (bool, error) {
+	claimed := *preferences
+	claimed.DigestRetryAt = until
+	ok, err := elasticclient.IndexIf(ctx, e.client, e.index, preferences.ID, preferences.SeqNo, preferences.PrimaryTerm, &claimed)
+	if ok {
+		preferences.DigestRetryAt = until
+	}
+	return ok, err
+}
+
+// Delete implements PreferenceRepository
+func (e *elasticPreferenceRepository) Delete(ctx context.Context, userId string) (bool, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", userId))
+	if err != nil {
+		return false, err
+	}
+	deleted, err := elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+	return deleted > 0, err
+}
+
+func NewElasticPreferenceRepository(elastic *elasticsearch.Client) PreferenceRepository {
+	index, alias := "notification_preferences_19092022", "notification_preferences"
+	return &elasticPreferenceRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
+
+type elasticFollowRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+// Create implements FollowRepository
+func (e *elasticFollowRepository) Create(ctx context.Context, follow *Follow) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, follow.ID, follow); err != nil {
+		return "", err
+	}
+	return follow.ID, nil
+}
+
+// Delete implements FollowRepository
+func (e *elasticFollowRepository) Delete(ctx context.Context, userId string, documentId string) (bool, error) {
+	query := elasticclient.Must(elasticclient.Term("UserId", userId), elasticclient.Term("DocumentId", documentId))
+	scoped, err := e.scope.Filter(ctx, query)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+	return deleted > 0, err
+}
+
+// GetAllByDocument implements FollowRepository
+func (e *elasticFollowRepository) GetAllByDocument(ctx context.Context, documentId string) ([]Follow, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("DocumentId", documentId))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Follow](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+// DeleteAllByDocument implements FollowRepository
+func (e *elasticFollowRepository) DeleteAllByDocument(ctx context.Context, documentId string) (int64, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("DocumentId", documentId))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+}
+
+// DeleteAllByUser implements FollowRepository
+func (e *elasticFollowRepository) DeleteAllByUser(ctx context.Context, userId string) (int64, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("UserId", userId))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+}
+
+func NewElasticFollowRepository(elastic *elasticsearch.Client) FollowRepository {
+	index, alias := "document_follows_19092022", "document_follows"
+	return &elasticFollowRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func (h Handler) getAllNotifications(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	query := new(NotificationQuery)
+	if _, err := helpers.Validate(c, query); err != nil {
+		return err
+	}
+	result, err := h.service.GetAll(c.Request().Context(), uid, *query)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) markRead(c echo.Context) error {
+	return h.mark(c, true)
+}
+
+func (h Handler) markUnread(c echo.Context) error {
+	return h.mark(c, false)
+}
+
+func (h Handler) mark(c echo.Context, read bool) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	id := c.Param("id")
+
+	result, err := h.service.MarkRead(c.Request().Context(), uid, id, read)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) markAllRead(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.MarkAllRead(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getPreferences(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+
+	result, err := h.service.GetPreferences(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) updatePreferences(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	request := new(PreferencesRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.UpdatePreferences(c.Request().Context(), uid, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) followDocument(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	id := c.Param("id")
+
+	result, err := h.service.Follow(c.Request().Context(), uid, id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) unfollowDocument(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	id := c.Param("id")
+
+	result, err := h.service.Unfollow(c.Request().Context(), uid, id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func NewNotificationHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+// RegisterNotificationHandlers gives every user their inbox and preferences, and
+// lets those who can read documents follow them.
+func RegisterNotificationHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	token := authenticator.TokenHandlerMiddlewareFunc("user", "admin")
+	read := authenticator.ScopedMiddlewareFunc(helpers.DocumentsRead, "user", "admin")
+	instance.GET("api/notifications", h.getAllNotifications, token)
+	instance.POST("api/notifications/read", h.markAllRead, token)
+	instance.PUT("api/notifications/:id/read", h.markRead, token)
+	instance.PUT("api/notifications/:id/unread", h.markUnread, token)
+	instance.GET("api/notifications/preferences", h.getPreferences, token)
+	instance.PUT("api/notifications/preferences", h.updatePreferences, token)
+	instance.POST("api/documents/:id/follow", h.followDocument, read)
+	instance.DELETE("api/documents/:id/follow", h.unfollowDocument, read)
+}
@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+// Notification tells a user about an event that concerns them. InApp is false for
+// notifications the user only wanted by e-mail; they are kept so that a redelivered
+// event is not mailed twice, but stay out of the inbox.
+type Notification struct {
+	ID           string `bson:"_id"`
+	UserId       string `bson:"user_id"`
+	TenantId     string `bson:"tenant_id"`
+	EventId      string `bson:"event_id"`
+	Type         string `bson:"type"`
+	DocumentId   string `bson:"document_id"`
+	DocumentName string `bson:"document_name"`
+	Actor        string `bson:"actor"`
+	Message      string `bson:"message"`
+	InApp        bool   `bson:"in_app"`
+	Read         bool   `bson:"read"`
+	ReadAt       string `bson:"read_at"`
+	CreatedAt    string `bson:"created_at"`
+}
+
+// Filter narrows the inbox of a user; zero fields do not filter.
+type Filter struct {
+	UserId string
+	Unread bool
+	Since  string
+	Limit  int
+}
+
+// Preferences of a user. Types without a TypePreference use the defaults, which
+// show every notification in the inbox and send no e-mail. With Digest the unread
+// notifications are also mailed in one message every digest interval.
+type Preferences struct {
+	ID           string           `bson:"_id"`
+	TenantId     string           `bson:"tenant_id"`
+	Types        []TypePreference `bson:"types"`
+	Digest       bool             `bson:"digest"`
+	LastDigestAt string           `bson:"last_digest_at"`
+	// a digest that could not be mailed is retried with backoff from DigestRetryAt
+	DigestFailures int    `bson:"digest_failures"`
+	DigestRetryAt  string `bson:"digest_retry_at"`
+	UpdatedAt      string `bson:"updated_at"`
+	// SeqNo and PrimaryTerm are the elastic version the preferences were read at
+	SeqNo       int `json:"-" bson:"-"`
+	PrimaryTerm int `json:"-" bson:"-"`
+}
+
+type TypePreference struct {
+	Type  string `bson:"type" json:"type" validate:"required"`
+	InApp bool   `bson:"in_app" json:"in_app"`
+	Email bool   `bson:"email" json:"email"`
+}
+
+// Follow subscribes a user to the changes of a document they do not own.
+type Follow struct {
+	ID         string `bson:"_id"`
+	UserId     string `bson:"user_id"`
+	DocumentId string `bson:"document_id"`
+	TenantId   string `bson:"tenant_id"`
+	CreatedAt  string `bson:"created_at"`
+}
+
+type NotificationQuery struct {
+	Unread bool `query:"unread"`
+	Limit  int  `query:"limit" validate:"min=0,max=1000"`
+}
+
+type PreferencesRequest struct {
+	Types  []TypePreference `json:"types" validate:"dive"`
+	Digest bool             `json:"digest"`
+}
+
+type NotificationResponse struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	DocumentId   string `json:"document_id"`
+	DocumentName string `json:"document_name"`
+	Actor        string `json:"actor"`
+	Message      string `json:"message"`
+	Read         bool   `json:"read"`
+	ReadAt       string `json:"read_at"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type InboxResponse struct {
+	Unread        int64                  `json:"unread"`
+	Notifications []NotificationResponse `json:"notifications"`
+}
+
+// PreferencesResponse lists every notification type with its effective setting.
+type PreferencesResponse struct {
+	Types        []TypePreference `json:"types"`
+	Digest       bool             `json:"digest"`
+	LastDigestAt string           `json:"last_digest_at"`
+}
+
+// NewNotification derives the id from the event and the user, so a redelivered
+// event notifies once.
+func NewNotification(userId, eventId, eventType string) *Notification {
+	return &Notification{
+		ID:        uuid.NewSHA1(uuid.NameSpaceURL, []byte(eventId+"/"+userId)).String(),
+		UserId:    userId,
+		EventId:   eventId,
+		Type:      eventType,
+		CreatedAt: time.Now().Format(timeLayout),
+	}
+}
+
+func NewFollow(userId, documentId, tenantId string) *Follow {
+	return &Follow{
+		ID:         uuid.NewSHA1(uuid.NameSpaceURL, []byte(documentId+"/"+userId)).String(),
+		UserId:     userId,
+		DocumentId: documentId,
+		TenantId:   tenantId,
+		CreatedAt:  time.Now().Format(timeLayout),
+	}
+}
+
+// DefaultPreferences are used for users who never changed theirs.
+func DefaultPreferences(userId, tenantId string) *Preferences {
+	return &Preferences{ID: userId, TenantId: tenantId}
+}
+
+// For returns the setting of the notification type.
+func (p *Preferences) For(notificationType string) TypePreference {
+	for _, t := range p.Types {
+		if t.Type == notificationType {
+			return t
+		}
+	}
+	return TypePreference{Type: notificationType, InApp: true}
+}
+
+func (n *Notification) ToNotificationResponse() *NotificationResponse {
+	return &NotificationResponse{
+		ID:           n.ID,
+		Type:         n.Type,
+		DocumentId:   n.DocumentId,
+		DocumentName: n.DocumentName,
+		Actor:        n.Actor,
+		Message:      n.Message,
+		Read:         n.Read,
+		ReadAt:       n.ReadAt,
+		CreatedAt:    n.CreatedAt,
+	}
+}
+
+func (p *Preferences) ToPreferencesResponse(types []string) *PreferencesResponse {
+	preferences := make([]TypePreference, 0, len(types))
+	for _, t := range types {
+		preferences = append(preferences, p.For(t))
+	}
+	return &PreferencesResponse{Types: preferences, Digest: p.Digest, LastDigestAt: p.LastDigestAt}
+}
@@ -0,0 +1,194 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// notificationRepository keeps all organizations in one collection, told apart by tenant_id.
+type notificationRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (n notificationRepository) Create(ctx context.Context, notification *Notification) (string, error) {
+	_, err := n.collection.ReplaceOne(ctx, bson.M{"_id": notification.ID}, notification, options.Replace().SetUpsert(true))
+	if err != nil {
+		return "", err
+	}
+	return notification.ID, nil
+}
+
+func (n notificationRepository) GetById(ctx context.Context, id string) (*Notification, error) {
+	notification := new(Notification)
+	if err := n.collections.FindOne(ctx, bson.M{"_id": id}, notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+func (n notificationRepository) GetAll(ctx context.Context, filter Filter) ([]Notification, error) {
+	scoped, err := n.collections.Filter(ctx, inbox(filter))
+	if err != nil {
+		return nil, err
+	}
+	findOptions := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(int64(filter.Limit))
+	cursor, err := n.collection.Find(ctx, scoped, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]Notification, 0)
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (n notificationRepository) CountUnread(ctx context.Context, userId string) (int64, error) {
+	return n.collections.CountDocuments(ctx, inbox(Filter{UserId: userId, Unread: true}))
+}
+
+func (n notificationRepository) UpdateRead(ctx context.Context, userId string, id string, readAt string) (bool, error) {
+	update := bson.M{"$set": bson.M{"read": readAt != "", "read_at": readAt}}
+	result, err := n.collections.UpdateOne(ctx, bson.M{"_id": id, "user_id": userId}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (n notificationRepository) MarkAllRead(ctx context.Context, userId string, readAt string) (int64, error) {
+	return n.collections.UpdateMany(ctx, inbox(Filter{UserId: userId, Unread: true}), bson.M{"$set": bson.M{"read": true, "read_at": readAt}})
+}
+
+func (n notificationRepository) DeleteAllByUser(ctx context.Context, userId string) (int64, error) {
+	return n.collections.DeleteMany(ctx, bson.M{"user_id": userId})
+}
+
+func inbox(filter Filter) bson.M {
+	query := bson.M{"user_id": filter.UserId, "in_app": true}
+	if filter.Unread {
+		query["read"] = false
+	}
+	if filter.Since != "" {
+		query["created_at"] = bson.M{"$gt": filter.Since}
+	}
+	return query
+}
+
+func NewNotificationRepository(db *mongo.Database) Repository {
+	col := db.Collection("notifications")
+	return &notificationRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "notifications", false)}
+}
+
+type preferenceRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (p preferenceRepository) Get(ctx context.Context, userId string) (*Preferences, error) {
+	preferences := new(Preferences)
+	err := p.collections.FindOne(ctx, bson.M{"_id": userId}, preferences)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (p preferenceRepository) Save(ctx context.Context, preferences *Preferences) (bool, error) {
+	_, err := p.collection.ReplaceOne(ctx, bson.M{"_id": preferences.ID}, preferences, options.Replace().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p preferenceRepository) GetDigestDue(ctx context.Context, before string, now string, limit int) ([]Preferences, error) {
+	if !helpers.AllTenants(ctx) {
+		return nil, helpers.ErrAllTenantsRequired
+	}
+	findOptions := options.Find().SetSort(bson.M{"last_digest_at": 1}).SetLimit(int64(limit))
+	filter := bson.M{"digest": true, "last_digest_at": bson.M{"$lt": before}, "digest_retry_at": bson.M{"$not": bson.M{"$gt": now}}}
+	cursor, err := p.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	preferences := make([]Preferences, 0)
+	if err := cursor.All(ctx, &preferences); err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (p preferenceRepository) Claim(ctx context.Context, preferences *Preferences, until string) (bool, error) {
+	var retryAt interface{} = preferences.DigestRetryAt
+	if preferences.DigestRetryAt == "" {
+		retryAt = bson.M{"$in": bson.A{nil, ""}}
+	}
+	filter := bson.M{"_id": preferences.ID, "last_digest_at": preferences.LastDigestAt, "digest_retry_at": retryAt}
+	result, err := p.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"digest_retry_at": until}})
+	if err != nil || result.MatchedCount == 0 {
+		return false, err
+	}
+	preferences.DigestRetryAt = until
+	return true, nil
+}
+
+func (p preferenceRepository) Delete(ctx context.Context, userId string) (bool, error) {
+	result, err := p.collections.DeleteOne(ctx, bson.M{"_id": userId})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func NewPreferenceRepository(db *mongo.Database) PreferenceRepository {
+	col := db.Collection("notification_preferences")
+	return &preferenceRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "notification_preferences", false)}
+}
+
+type followRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (f followRepository) Create(ctx context.Context, follow *Follow) (string, error) {
+	_, err := f.collection.ReplaceOne(ctx, bson.M{"_id": follow.ID}, follow, options.Replace().SetUpsert(true))
+	if err != nil {
+		return "", err
+	}
+	return follow.ID, nil
+}
+
+func (f followRepository) Delete(ctx context.Context, userId string, documentId string) (bool, error) {
+	result, err := f.collections.DeleteOne(ctx, bson.M{"user_id": userId, "document_id": documentId})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (f followRepository) GetAllByDocument(ctx context.Context, documentId string) ([]Follow, error) {
+	return mongoClient.FindAll[Follow](ctx, f.collections, bson.M{"document_id": documentId})
+}
+
+func (f followRepository) DeleteAllByDocument(ctx context.Context, documentId string) (int64, error) {
+	return f.collections.DeleteMany(ctx, bson.M{"document_id": documentId})
+}
+
+func (f followRepository) DeleteAllByUser(ctx context.Context, userId string) (int64, error) {
+	return f.collections.DeleteMany(ctx, bson.M{"user_id": userId})
+}
+
+func NewFollowRepository(db *mongo.Database) FollowRepository {
+	col := db.Collection("document_follows")
+	return &followRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "document_follows", false)}
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+)
+
+type Repository interface {
+	// Create stores the notification under its id, so a redelivered event is
+	// stored once.
+	Create(ctx context.Context, notification *Notification) (string, error)
+	GetById(ctx context.Context, id string) (*Notification, error)
+	// GetAll returns the inbox notifications of the user, newest first.
+	GetAll(ctx context.Context, filter Filter) ([]Notification, error)
+	CountUnread(ctx context.Context, userId string) (int64, error)
+	// UpdateRead marks a notification of the user read at readAt, or unread when
+	// readAt is empty.
+	UpdateRead(ctx context.Context, userId string, id string, readAt string) (bool, error)
+	MarkAllRead(ctx context.Context, userId string, readAt string) (int64, error)
+	DeleteAllByUser(ctx context.Context, userId string) (int64, error)
+}
+
+type PreferenceRepository interface {
+	// Get returns the preferences of the user, or nil when they never set any.
+	Get(ctx context.Context, userId string) (*Preferences, error)
+	Save(ctx context.Context, preferences *Preferences) (bool, error)
+	// GetDigestDue returns up to limit preferences of all organizations with a
+	// digest that was last sent before the given time and is not waiting for a
+	// retry after now.
+	GetDigestDue(ctx context.Context, before string, now string, limit int) ([]Preferences, error)
+	// Claim holds back the digest until the given time, unless another digester
+	// changed the preferences since they were read, and reports whether it did.
+	Claim(ctx context.Context, preferences *Preferences, until string) (bool, error)
+	Delete(ctx context.Context, userId string) (bool, error)
+}
+
+type FollowRepository interface {
+	// Create stores the follow under its id, so following twice is harmless.
+	Create(ctx context.Context, follow *Follow) (string, error)
+	Delete(ctx context.Context, userId string, documentId string) (bool, error)
+	GetAllByDocument(ctx context.Context, documentId string) ([]Follow, error)
+	DeleteAllByDocument(ctx context.Context, documentId string) (int64, error)
+	DeleteAllByUser(ctx context.Context, userId string) (int64, error)
+}
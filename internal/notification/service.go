@@ -0,0 +1,372 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/document"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	GetAll(ctx context.Context, userId string, query NotificationQuery) (*InboxResponse, error)
+	MarkRead(ctx context.Context, userId string, id string, read bool) (bool, error)
+	MarkAllRead(ctx context.Context, userId string) (int64, error)
+	GetPreferences(ctx context.Context, userId string) (*PreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, userId string, request PreferencesRequest) (bool, error)
+	Follow(ctx context.Context, userId string, documentId string) (bool, error)
+	Unfollow(ctx context.Context, userId string, documentId string) (bool, error)
+	Notify(ctx context.Context, event *events.Event) error
+	SendDigests(ctx context.Context) (int, error)
+	Forget(ctx context.Context, userId string) error
+}
+
+// Types are the event types users are notified about.
+var Types = []string{events.DocumentUpdated, events.DocumentDeleted, events.DocumentDownloaded}
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+	digestBatch  = 100
+	// defaultDigestPoll is used when DigestPollInterval is not set
+	defaultDigestPoll = 15 * time.Minute
+)
+
+var verbs = map[string]string{
+	events.DocumentUpdated:    "updated",
+	events.DocumentDeleted:    "deleted",
+	events.DocumentDownloaded: "downloaded",
+}
+
+var errNotificationNotFound = appError.NotFound("notification_not_found", "Service: notification id not found")
+
+type notificationService struct {
+	repository  Repository
+	preferences PreferenceRepository
+	follows     FollowRepository
+	documents   document.Repository
+	users       auth.Repository
+	mail        mail.Sender
+	config      config.Configuration
+}
+
+// GetAll returns the inbox of the user, newest first, with the number of unread
+// notifications.
+func (n notificationService) GetAll(ctx context.Context, userId string, query NotificationQuery) (*InboxResponse, error) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	notifications, err := n.repository.GetAll(ctx, Filter{UserId: userId, Unread: query.Unread, Limit: limit})
+	if err != nil {
+		return nil, appError.Unavailable("notifications_unavailable", "Service: failed to read notifications").Wrap(err)
+	}
+	unread, err := n.repository.CountUnread(ctx, userId)
+	if err != nil {
+		return nil, appError.Unavailable("notifications_unavailable", "Service: failed to count notifications").Wrap(err)
+	}
+	responses := make([]NotificationResponse, 0)
+	for i := 0; i < len(notifications); i++ {
+		responses = append(responses, *notifications[i].ToNotificationResponse())
+	}
+	return &InboxResponse{Unread: unread, Notifications: responses}, nil
+}
+
+func (n notificationService) MarkRead(ctx context.Context, userId string, id string, read bool) (bool, error) {
+	readAt := ""
+	if read {
+		readAt = time.Now().Format(timeLayout)
+	}
+	result, err := n.repository.UpdateRead(ctx, userId, id, readAt)
+	if err != nil || !result {
+		return false, errNotificationNotFound.Wrap(err)
+	}
+	return true, nil
+}
+
+func (n notificationService) MarkAllRead(ctx context.Context, userId string) (int64, error) {
+	updated, err := n.repository.MarkAllRead(ctx, userId, time.Now().Format(timeLayout))
+	if err != nil {
+		return 0, appError.Unavailable("notifications_not_updated", "Service: failed to mark notifications read").Wrap(err)
+	}
+	return updated, nil
+}
+
+func (n notificationService) GetPreferences(ctx context.Context, userId string) (*PreferencesResponse, error) {
+	preferences, err := n.preferencesOf(ctx, userId)
+	if err != nil {
+		return nil, appError.Unavailable("preferences_unavailable", "Service: failed to read notification preferences").Wrap(err)
+	}
+	return preferences.ToPreferencesResponse(Types), nil
+}
+
+// UpdatePreferences replaces the settings of the listed types; other types keep
+// theirs. Turning the digest on starts its first interval now.
+func (n notificationService) UpdatePreferences(ctx context.Context, userId string, request PreferencesRequest) (bool, error) {
+	preferences, err := n.preferencesOf(ctx, userId)
+	if err != nil {
+		return false, appError.Unavailable("preferences_unavailable", "Service: failed to read notification preferences").Wrap(err)
+	}
+	for _, t := range request.Types {
+		if _, ok := verbs[t.Type]; !ok {
+			return false, appError.Validation("unknown_notification_type", "Service: unknown notification type: "+t.Type)
+		}
+		preferences.set(t)
+	}
+	now := time.Now().Format(timeLayout)
+	if request.Digest && !preferences.Digest {
+		preferences.LastDigestAt = now
+	}
+	preferences.Digest = request.Digest
+	preferences.UpdatedAt = now
+	if _, err := n.preferences.Save(ctx, preferences); err != nil {
+		return false, appError.Unavailable("preferences_not_updated", "Service: failed to store notification preferences").Wrap(err)
+	}
+	return true, nil
+}
+
+// Follow notifies the user about changes of a document they do not own.
+func (n notificationService) Follow(ctx context.Context, userId string, documentId string) (bool, error) {
+	if _, err := n.documents.GetById(ctx, documentId); err != nil {
+		return false, appError.NotFound("document_not_found", "Service: document id not found").Wrap(err)
+	}
+	if _, err := n.follows.Create(ctx, NewFollow(userId, documentId, helpers.Tenant(ctx))); err != nil {
+		return false, appError.Unavailable("follow_not_created", "Service: failed to follow document").Wrap(err)
+	}
+	return true, nil
+}
+
+func (n notificationService) Unfollow(ctx context.Context, userId string, documentId string) (bool, error) {
+	result, err := n.follows.Delete(ctx, userId, documentId)
+	if err != nil || !result {
+		return false, appError.NotFound("follow_not_found", "Service: document is not followed").Wrap(err)
+	}
+	return true, nil
+}
+
+// Notify tells the owner and the followers of a document what someone else did to
+// it, and forgets everything about deleted users.
+func (n notificationService) Notify(ctx context.Context, event *events.Event) error {
+	ctx = helpers.WithTenant(ctx, event.TenantId)
+	if event.Type == events.UserDeleted {
+		return n.Forget(ctx, event.Subject)
+	}
+	if _, ok := verbs[event.Type]; !ok {
+		return nil
+	}
+	data := new(document.DocumentLog)
+	if err := event.Decode(data); err != nil {
+		return err
+	}
+	recipients, err := n.recipients(ctx, event, data)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("%s %s %s", n.actorName(ctx, event.Actor), verbs[event.Type], data.Name)
+	for _, userId := range recipients {
+		if err := n.notify(ctx, userId, event, data, message); err != nil {
+			return err
+		}
+	}
+	if event.Type == events.DocumentDeleted {
+		if _, err := n.follows.DeleteAllByDocument(ctx, event.Subject); err != nil {
+			log.Errorf("Service: failed to remove the followers of document %s: %v", event.Subject, err)
+		}
+	}
+	return nil
+}
+
+func (n notificationService) recipients(ctx context.Context, event *events.Event, data *document.DocumentLog) ([]string, error) {
+	userIds := []string{data.OwnerId}
+	follows, err := n.follows.GetAllByDocument(ctx, event.Subject)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range follows {
+		userIds = append(userIds, f.UserId)
+	}
+	recipients := make([]string, 0, len(userIds))
+	seen := map[string]bool{}
+	for _, userId := range userIds {
+		// nobody is told about what they did themselves
+		if userId == "" || userId == event.Actor || seen[userId] {
+			continue
+		}
+		seen[userId] = true
+		recipients = append(recipients, userId)
+	}
+	return recipients, nil
+}
+
+// notify stores the notification and mails it right away when the user asked for
+// it. An event seen before is skipped, so nobody is mailed twice.
+func (n notificationService) notify(ctx context.Context, userId string, event *events.Event, data *document.DocumentLog, message string) error {
+	preferences, err := n.preferencesOf(ctx, userId)
+	if err != nil {
+		return err
+	}
+	preference := preferences.For(event.Type)
+	if !preference.InApp && !preference.Email {
+		return nil
+	}
+	notification := NewNotification(userId, event.ID, event.Type)
+	if _, err := n.repository.GetById(ctx, notification.ID); err == nil {
+		return nil
+	}
+	notification.TenantId = event.TenantId
+	notification.DocumentId = event.Subject
+	notification.DocumentName = data.Name
+	notification.Actor = event.Actor
+	notification.Message = message
+	notification.InApp = preference.InApp
+	if _, err := n.repository.Create(ctx, notification); err != nil {
+		return err
+	}
+	if preference.Email {
+		n.sendMail(ctx, userId, "doc-system: "+message, fmt.Sprintf("%s.\n\n%s/documents/%s\n",
+			message, n.config.AccountSettings.BaseUrl, event.Subject))
+	}
+	return nil
+}
+
+// SendDigests mails the unread notifications since their last digest to every
+// user whose digest is due, and returns how many digests were sent. A digest that
+// can not be mailed is retried with backoff, so users whose mail keeps failing do
+// not hold back the digests of everyone else. Digesters of several replicas claim
+// a digest before sending it, so each digest is mailed once.
+func (n notificationService) SendDigests(ctx context.Context) (int, error) {
+	now := time.Now()
+	interval := time.Duration(n.config.NotificationSettings.DigestInterval) * time.Hour
+	due, err := n.preferences.GetDigestDue(helpers.WithAllTenants(ctx), now.Add(-interval).Format(timeLayout), now.Format(timeLayout), digestBatch)
+	if err != nil {
+		return 0, err
+	}
+	// a digest claimed by a digester that died is sent again after one poll interval
+	until := now.Add(digestPoll(n.config.NotificationSettings)).Format(timeLayout)
+	sent := 0
+	for i := range due {
+		preferences := &due[i]
+		tenantCtx := helpers.WithTenant(ctx, preferences.TenantId)
+		claimed, err := n.preferences.Claim(tenantCtx, preferences, until)
+		if err != nil {
+			log.Errorf("Service: failed to claim the digest of %s: %v", preferences.ID, err)
+			continue
+		}
+		// another digester got to the digest first
+		if !claimed {
+			continue
+		}
+		notifications, err := n.repository.GetAll(tenantCtx, Filter{UserId: preferences.ID, Unread: true, Since: preferences.LastDigestAt, Limit: maxLimit})
+		if err != nil {
+			log.Errorf("Service: failed to read the digest of %s: %v", preferences.ID, err)
+			continue
+		}
+		if len(notifications) > 0 {
+			lines := make([]string, 0, len(notifications))
+			for _, notification := range notifications {
+				lines = append(lines, "- "+notification.Message)
+			}
+			if !n.sendMail(tenantCtx, preferences.ID, fmt.Sprintf("doc-system: %d unread notifications", len(notifications)),
+				fmt.Sprintf("%s\n\n%s/notifications\n", strings.Join(lines, "\n"), n.config.AccountSettings.BaseUrl)) {
+				n.digestFailed(tenantCtx, preferences, now)
+				continue
+			}
+			sent++
+		}
+		preferences.LastDigestAt = now.Format(timeLayout)
+		preferences.DigestFailures = 0
+		preferences.DigestRetryAt = ""
+		if _, err := n.preferences.Save(tenantCtx, preferences); err != nil {
+			log.Errorf("Service: failed to store the digest time of %s: %v", preferences.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// digestFailed postpones the digest, waiting one poll interval after the first
+// failure and doubling the wait up to the digest interval.
+func (n notificationService) digestFailed(ctx context.Context, preferences *Preferences, now time.Time) {
+	settings := n.config.NotificationSettings
+	preferences.DigestFailures++
+	preferences.DigestRetryAt = now.Add(helpers.Backoff(preferences.DigestFailures,
+		digestPoll(settings), time.Duration(settings.DigestInterval)*time.Hour)).Format(timeLayout)
+	if _, err := n.preferences.Save(ctx, preferences); err != nil {
+		log.Errorf("Service: failed to store the digest retry of %s: %v", preferences.ID, err)
+	}
+}
+
+// digestPoll is how often due digests are looked for.
+func digestPoll(settings config.NotificationSettings) time.Duration {
+	if settings.DigestPollInterval <= 0 {
+		return defaultDigestPoll
+	}
+	return time.Duration(settings.DigestPollInterval) * time.Minute
+}
+
+// Forget removes the notifications, preferences and follows of a deleted or erased user.
+func (n notificationService) Forget(ctx context.Context, userId string) error {
+	if _, err := n.repository.DeleteAllByUser(ctx, userId); err != nil {
+		return err
+	}
+	if _, err := n.follows.DeleteAllByUser(ctx, userId); err != nil {
+		return err
+	}
+	_, err := n.preferences.Delete(ctx, userId)
+	return err
+}
+
+func (n notificationService) preferencesOf(ctx context.Context, userId string) (*Preferences, error) {
+	preferences, err := n.preferences.Get(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if preferences == nil {
+		return DefaultPreferences(userId, helpers.Tenant(ctx)), nil
+	}
+	return preferences, nil
+}
+
+func (n notificationService) actorName(ctx context.Context, actor string) string {
+	if user, err := n.users.GetById(ctx, actor); err == nil && user.Username != "" {
+		return user.Username
+	}
+	return "Someone"
+}
+
+func (n notificationService) sendMail(ctx context.Context, userId, subject, body string) bool {
+	user, err := n.users.GetById(ctx, userId)
+	if err != nil || user.Email == "" {
+		log.Errorf("Service: no e-mail address for notifying %s: %v", userId, err)
+		return false
+	}
+	if err := n.mail.Send(ctx, mail.Message{To: []string{user.Email}, Subject: subject, Body: body}); err != nil {
+		log.Errorf("Service: failed to send %q e-mail: %v", subject, err)
+		return false
+	}
+	return true
+}
+
+func (p *Preferences) set(preference TypePreference) {
+	for i := range p.Types {
+		if p.Types[i].Type == preference.Type {
+			p.Types[i] = preference
+			return
+		}
+	}
+	p.Types = append(p.Types, preference)
+}
+
+func NewNotificationService(repo Repository, preferences PreferenceRepository, follows FollowRepository, documents document.Repository, users auth.Repository, sender mail.Sender, cfg config.Configuration) Service {
+	return &notificationService{repository: repo, preferences: preferences, follows: follows, documents: documents, users: users, mail: sender, config: cfg}
+}
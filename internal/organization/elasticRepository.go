@@ -0,0 +1,66 @@
+package organization
+
+import (
+	"context"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/pkg/errors"
+)
+
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, organization *Organization) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, organization.ID, organization); err != nil {
+		return "", err
+	}
+	return organization.ID, nil
+}
+
+// Update implements Repository
+func (e *elasticRepository) Update(ctx context.Context, organization *Organization) (bool, error) {
+	if _, err := e.GetById(ctx, organization.ID); err != nil {
+		return false, err
+	}
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, organization.ID, organization); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete implements Repository
+func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error) {
+	deleted, err := elasticclient.DeleteByQuery(ctx, e.client, e.index, elasticclient.Term("ID", id))
+	return deleted > 0, err
+}
+
+// GetAll implements Repository
+func (e *elasticRepository) GetAll(ctx context.Context) ([]Organization, error) {
+	return elasticclient.Search[Organization](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+	})
+}
+
+// GetById implements Repository
+func (e *elasticRepository) GetById(ctx context.Context, id string) (*Organization, error) {
+	organizations, err := elasticclient.Search[Organization](ctx, e.client, e.index, map[string]interface{}{
+		"query": elasticclient.Term("ID", id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(organizations) < 1 {
+		return nil, errors.New("Elastic repository: organization not found")
+	}
+	return &organizations[0], nil
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	return &elasticRepository{client: elastic, index: "organizations_19092022", alias: "organizations"}
+}
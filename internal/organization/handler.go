@@ -0,0 +1,110 @@
+package organization
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service      Service
+	systemAdmins []string
+}
+
+func (h Handler) createOrganization(c echo.Context) error {
+	request := new(CreateOrganizationRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Create(c.Request().Context(), *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Success")
+}
+
+func (h Handler) updateOrganization(c echo.Context) error {
+	id := c.Param("id")
+	request := new(UpdateOrganizationRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Update(c.Request().Context(), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) deleteOrganization(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.Delete(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getAllOrganizations(c echo.Context) error {
+	result, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getByIdOrganization(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.GetById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getCurrentOrganization(c echo.Context) error {
+	id := fmt.Sprintf("%v", c.Get("tenant"))
+
+	result, err := h.service.GetById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) createOrganizationUser(c echo.Context) error {
+	id := c.Param("id")
+	request := new(auth.CreateUserRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.CreateUser(c.Request().Context(), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Success")
+}
+
+func NewOrganizationHandler(s Service, systemAdmins []string) Handler {
+	return Handler{service: s, systemAdmins: systemAdmins}
+}
+
+func RegisterOrganizationHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	// system admins may belong to any organization and have any role there
+	signedIn := authenticator.TokenHandlerMiddlewareFunc()
+	systemAdmin := middleware.SystemAdminMiddlewareFunc(h.systemAdmins)
+	instance.GET("api/organizations/current", h.getCurrentOrganization, authenticator.TokenHandlerMiddlewareFunc("user", "admin"))
+	instance.POST("api/organizations", h.createOrganization, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.GET("api/organizations", h.getAllOrganizations, signedIn, systemAdmin)
+	instance.GET("api/organizations/:id", h.getByIdOrganization, signedIn, systemAdmin)
+	instance.PUT("api/organizations/:id", h.updateOrganization, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.DELETE("api/organizations/:id", h.deleteOrganization, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+	instance.POST("api/organizations/:id/users", h.createOrganizationUser, signedIn, systemAdmin, middleware.NotImpersonatedMiddlewareFunc)
+}
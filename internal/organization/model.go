@@ -0,0 +1,57 @@
+package organization
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+// Organization is a tenant. Its id is the tenant id stored on users, documents and
+// groups and carried in tokens.
+type Organization struct {
+	ID          string `bson:"_id"`
+	Name        string `bson:"name"`
+	Description string `bson:"description"`
+	CreatedAt   string `bson:"created_at"`
+	UpdatedAt   string `bson:"updated_at"`
+}
+
+type CreateOrganizationRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+}
+
+func (receiver *CreateOrganizationRequest) ToOrganization() *Organization {
+	return &Organization{
+		ID:          uuid.New().String(),
+		Name:        receiver.Name,
+		Description: receiver.Description,
+		CreatedAt:   time.Now().Format(timeLayout),
+		UpdatedAt:   time.Now().Format(timeLayout),
+	}
+}
+
+type UpdateOrganizationRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+}
+
+type OrganizationResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+func (o *Organization) ToOrganizationResponse() *OrganizationResponse {
+	return &OrganizationResponse{
+		ID:          o.ID,
+		Name:        o.Name,
+		Description: o.Description,
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+	}
+}
@@ -0,0 +1,60 @@
+package organization
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type organizationRepository struct {
+	collection *mongo.Collection
+}
+
+func (o organizationRepository) Create(ctx context.Context, organization *Organization) (string, error) {
+	if _, err := o.collection.InsertOne(ctx, organization); err != nil {
+		return "", err
+	}
+	return organization.ID, nil
+}
+
+func (o organizationRepository) Update(ctx context.Context, organization *Organization) (bool, error) {
+	result, err := o.collection.ReplaceOne(ctx, bson.M{"_id": organization.ID}, organization)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (o organizationRepository) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := o.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (o organizationRepository) GetAll(ctx context.Context) ([]Organization, error) {
+	cursor, err := o.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	organizations := make([]Organization, 0)
+	if err = cursor.All(ctx, &organizations); err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+func (o organizationRepository) GetById(ctx context.Context, id string) (*Organization, error) {
+	organization := new(Organization)
+	if err := o.collection.FindOne(ctx, bson.M{"_id": id}).Decode(organization); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+func NewOrganizationRepository(db *mongo.Database) Repository {
+	col := db.Collection("organizations")
+	return &organizationRepository{collection: col}
+}
@@ -0,0 +1,13 @@
+package organization
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, organization *Organization) (string, error)
+	Update(ctx context.Context, organization *Organization) (bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	GetAll(ctx context.Context) ([]Organization, error)
+	GetById(ctx context.Context, id string) (*Organization, error)
+}
@@ -0,0 +1,122 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Create(ctx context.Context, request CreateOrganizationRequest) (string, error)
+	Update(ctx context.Context, id string, request UpdateOrganizationRequest) (bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	GetAll(ctx context.Context) ([]OrganizationResponse, error)
+	GetById(ctx context.Context, id string) (*OrganizationResponse, error)
+	CreateUser(ctx context.Context, id string, request auth.CreateUserRequest) (string, error)
+	EnsureDefault(ctx context.Context) error
+}
+
+type organizationService struct {
+	repository Repository
+	users      auth.Repository
+	accounts   auth.Service
+	settings   config.TenantSettings
+}
+
+func (o organizationService) Create(ctx context.Context, request CreateOrganizationRequest) (string, error) {
+	id, err := o.repository.Create(ctx, request.ToOrganization())
+	if err != nil {
+		return "", appError.Unavailable("organization_not_created", "Service: failed to create organization")
+	}
+	return id, nil
+}
+
+func (o organizationService) Update(ctx context.Context, id string, request UpdateOrganizationRequest) (bool, error) {
+	organization, err := o.repository.GetById(ctx, id)
+	if err != nil {
+		return false, appError.NotFound("organization_not_found", "Service: organization id not found")
+	}
+	organization.Name = request.Name
+	organization.Description = request.Description
+	organization.UpdatedAt = time.Now().Format(timeLayout)
+	result, _ := o.repository.Update(ctx, organization)
+	if !result {
+		return false, appError.Unavailable("organization_not_updated", "Service: failed to update organization")
+	}
+	return true, nil
+}
+
+// Delete removes an empty organization. The default organization is kept since
+// sign-ups and records created before organizations existed belong to it.
+func (o organizationService) Delete(ctx context.Context, id string) (bool, error) {
+	if id == o.settings.DefaultTenant {
+		return false, appError.Conflict("default_organization_protected", "Service: the default organization cannot be deleted")
+	}
+	users, err := o.users.GetAll(helpers.WithTenant(ctx, id))
+	if err != nil {
+		return false, appError.Unavailable("organization_users_unavailable", "Service: failed to check the users of the organization").Wrap(err)
+	}
+	if len(users) > 0 {
+		return false, appError.Conflict("organization_not_empty", "Service: organization still has users")
+	}
+	result, _ := o.repository.Delete(ctx, id)
+	if !result {
+		return false, appError.NotFound("organization_not_deleted", "Service: failed to delete organization")
+	}
+	return true, nil
+}
+
+func (o organizationService) GetAll(ctx context.Context) ([]OrganizationResponse, error) {
+	organizations, err := o.repository.GetAll(ctx)
+	if err != nil {
+		return nil, appError.Unavailable("organizations_not_found", err.Error())
+	}
+	organizationResponses := make([]OrganizationResponse, 0)
+	for i := 0; i < len(organizations); i++ {
+		organizationResponses = append(organizationResponses, *organizations[i].ToOrganizationResponse())
+	}
+	return organizationResponses, nil
+}
+
+func (o organizationService) GetById(ctx context.Context, id string) (*OrganizationResponse, error) {
+	organization, err := o.repository.GetById(ctx, id)
+	if err != nil {
+		return nil, appError.NotFound("organization_not_found", "Service: organization id not found")
+	}
+	return organization.ToOrganizationResponse(), nil
+}
+
+// CreateUser adds a user to the organization, e.g. its first admin.
+func (o organizationService) CreateUser(ctx context.Context, id string, request auth.CreateUserRequest) (string, error) {
+	if _, err := o.repository.GetById(ctx, id); err != nil {
+		return "", appError.NotFound("organization_not_found", "Service: organization id not found")
+	}
+	return o.accounts.Create(helpers.WithTenant(ctx, id), request)
+}
+
+// EnsureDefault creates the default organization on first start.
+func (o organizationService) EnsureDefault(ctx context.Context) error {
+	if _, err := o.repository.GetById(ctx, o.settings.DefaultTenant); err == nil {
+		return nil
+	}
+	now := time.Now().Format(timeLayout)
+	_, err := o.repository.Create(ctx, &Organization{
+		ID:        o.settings.DefaultTenant,
+		Name:      o.settings.DefaultTenant,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err == nil {
+		log.Infof("Service: created default organization %s", o.settings.DefaultTenant)
+	}
+	return err
+}
+
+func NewOrganizationService(repo Repository, users auth.Repository, accounts auth.Service, settings config.TenantSettings) Service {
+	return &organizationService{repository: repo, users: users, accounts: accounts, settings: settings}
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+)
+
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, entry *Entry) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, entry.ID, entry); err != nil {
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+// Update implements Repository
+func (e *elasticRepository) Update(ctx context.Context, entry *Entry) (bool, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, entry.ID, entry); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetPending implements Repository
+func (e *elasticRepository) GetPending(ctx context.Context, due string, limit int) ([]Entry, error) {
+	hits, err := elasticclient.SearchVersioned[Entry](ctx, e.client, e.alias, map[string]interface{}{
+		"size": limit,
+		"sort": []interface{}{map[string]interface{}{"CreatedAt.keyword": "asc"}},
+		"query": elasticclient.Must(
+			elasticclient.Term("Status", Pending),
+			map[string]interface{}{"range": map[string]interface{}{"NextAttemptAt.keyword": map[string]interface{}{"lte": due}}},
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(hits))
+	for i, hit := range hits {
+		entries[i] = hit.Source
+		entries[i].SeqNo, entries[i].PrimaryTerm = hit.SeqNo, hit.PrimaryTerm
+	}
+	return entries, nil
+}
+
+// Claim implements Repository
+func (e *elasticRepository) Claim(ctx context.Context, entry *Entry, until string) (bool, error) {
+	claimed := *entry
+	claimed.NextAttemptAt = until
+	ok, err := elasticclient.IndexIf(ctx, e.client, e.index, entry.ID, entry.SeqNo, entry.PrimaryTerm, &claimed)
+	if ok {
+		entry.NextAttemptAt = until
+	}
+	return ok, err
+}
+
+// DeleteDelivered implements Repository
+func (e *elasticRepository) DeleteDelivered(ctx context.Context, before string) (int64, error) {
+	return elasticclient.DeleteByQuery(ctx, e.client, e.index, elasticclient.Must(
+		elasticclient.Term("Status", Delivered),
+		map[string]interface{}{"range": map[string]interface{}{"DeliveredAt.keyword": map[string]interface{}{"lt": before}}},
+	))
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	return &elasticRepository{client: elastic, index: "outbox_19092022", alias: "outbox"}
+}
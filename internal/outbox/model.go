@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/events"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+const (
+	Pending   = "pending"
+	Delivered = "delivered"
+	Failed    = "failed"
+)
+
+// Entry is an event waiting in the outbox. The event is kept serialized in Payload
+// so that its data does not shape the mapping of the index. Entries are retried
+// until delivered or until they fail MaxAttempts times.
+type Entry struct {
+	ID            string `bson:"_id"`
+	Type          string `bson:"type"`
+	Subject       string `bson:"subject"`
+	TenantId      string `bson:"tenant_id"`
+	Payload       string `bson:"payload"`
+	Status        string `bson:"status"`
+	Attempts      int    `bson:"attempts"`
+	LastError     string `bson:"last_error"`
+	NextAttemptAt string `bson:"next_attempt_at"`
+	CreatedAt     string `bson:"created_at"`
+	DeliveredAt   string `bson:"delivered_at"`
+	// SeqNo and PrimaryTerm are the elastic version the entry was read at
+	SeqNo       int `json:"-" bson:"-"`
+	PrimaryTerm int `json:"-" bson:"-"`
+}
+
+func NewEntry(event *events.Event) (*Entry, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(timeLayout)
+	return &Entry{
+		ID:            event.ID,
+		Type:          event.Type,
+		Subject:       event.Subject,
+		TenantId:      event.TenantId,
+		Payload:       string(payload),
+		Status:        Pending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+func (e *Entry) Event() (*events.Event, error) {
+	event := new(events.Event)
+	if err := json.Unmarshal([]byte(e.Payload), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type outboxRepository struct {
+	collection *mongo.Collection
+}
+
+func (o outboxRepository) Create(ctx context.Context, entry *Entry) (string, error) {
+	// replayed events reuse their id, so a delivered entry is replaced
+	if _, err := o.collection.ReplaceOne(ctx, bson.M{"_id": entry.ID}, entry, options.Replace().SetUpsert(true)); err != nil {
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+func (o outboxRepository) Update(ctx context.Context, entry *Entry) (bool, error) {
+	result, err := o.collection.ReplaceOne(ctx, bson.M{"_id": entry.ID}, entry)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (o outboxRepository) GetPending(ctx context.Context, due string, limit int) ([]Entry, error) {
+	filter := bson.M{"status": Pending, "next_attempt_at": bson.M{"$lte": due}}
+	cursor, err := o.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (o outboxRepository) Claim(ctx context.Context, entry *Entry, until string) (bool, error) {
+	filter := bson.M{"_id": entry.ID, "status": Pending, "next_attempt_at": entry.NextAttemptAt}
+	result, err := o.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"next_attempt_at": until}})
+	if err != nil || result.MatchedCount == 0 {
+		return false, err
+	}
+	entry.NextAttemptAt = until
+	return true, nil
+}
+
+func (o outboxRepository) DeleteDelivered(ctx context.Context, before string) (int64, error) {
+	result, err := o.collection.DeleteMany(ctx, bson.M{"status": Delivered, "delivered_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func NewOutboxRepository(db *mongo.Database) Repository {
+	col := db.Collection("outbox")
+	return &outboxRepository{collection: col}
+}
@@ -0,0 +1,33 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/internal/events"
+)
+
+type outboxPublisher struct {
+	repository Repository
+	source     string
+}
+
+// Publish stores the event in the outbox for the relay to deliver. Services publish
+// right after storing the change the event describes, so an event is never written
+// for a change that failed, and a stored event survives the event bus being down.
+// Document changes are undone when their entry can not be stored.
+func (o outboxPublisher) Publish(ctx context.Context, event *events.Event) error {
+	if event.Source == "" {
+		event.Source = o.source
+	}
+	entry, err := NewEntry(event)
+	if err != nil {
+		return err
+	}
+	_, err = o.repository.Create(ctx, entry)
+	return err
+}
+
+// NewPublisher writes events to the outbox; source identifies the publishing process.
+func NewPublisher(repo Repository, source string) events.Publisher {
+	return &outboxPublisher{repository: repo, source: source}
+}
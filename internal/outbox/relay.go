@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+// Relay delivers pending outbox entries to the event bus. Relays of several
+// replicas claim an entry before publishing it, so each entry is published by one of
+// them. A failed delivery is retried with exponential backoff until MaxAttempts is
+// reached, after which the entry is marked failed. Delivery is at least once: an
+// entry published before its status could be stored is published again, so
+// consumers should skip event ids they have already seen.
+type Relay struct {
+	repository Repository
+	publisher  events.Publisher
+	settings   config.OutboxSettings
+}
+
+// sweepInterval is how often delivered entries past their retention are deleted.
+const sweepInterval = time.Hour
+
+// Run polls the outbox until the context is cancelled.
+func (r Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(r.settings.PollInterval) * time.Second)
+	defer ticker.Stop()
+	var swept time.Time
+	for {
+		if r.settings.RetentionHours > 0 && time.Since(swept) >= sweepInterval {
+			r.sweep(ctx)
+			swept = time.Now()
+		}
+		// a full batch means more entries are due, so the next batch is read right away
+		if r.relay(ctx) == r.settings.BatchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay delivers one batch of due entries and returns how many it claimed. Entries
+// that could not be claimed do not count, so failing claims wait for the next tick.
+func (r Relay) relay(ctx context.Context) int {
+	entries, err := r.repository.GetPending(ctx, time.Now().Format(timeLayout), r.settings.BatchSize)
+	if err != nil {
+		log.Errorf("Relay: failed to read the outbox: %v", err)
+		return 0
+	}
+	until := time.Now().Add(time.Duration(r.settings.ClaimTimeout) * time.Second).Format(timeLayout)
+	delivered := 0
+	for i := range entries {
+		claimed, err := r.repository.Claim(ctx, &entries[i], until)
+		if err != nil {
+			log.Errorf("Relay: failed to claim %s: %v", entries[i].ID, err)
+			continue
+		}
+		// another relay got to the entry first
+		if !claimed {
+			continue
+		}
+		r.deliver(ctx, &entries[i])
+		delivered++
+	}
+	return delivered
+}
+
+// sweep deletes the entries delivered longer than the retention ago.
+func (r Relay) sweep(ctx context.Context) {
+	before := time.Now().Add(-time.Duration(r.settings.RetentionHours) * time.Hour).Format(timeLayout)
+	deleted, err := r.repository.DeleteDelivered(ctx, before)
+	if err != nil {
+		log.Errorf("Relay: failed to delete delivered entries: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Infof("Relay: deleted %d delivered entries", deleted)
+	}
+}
+
+func (r Relay) deliver(ctx context.Context, entry *Entry) {
+	event, err := entry.Event()
+	if err == nil {
+		err = r.publisher.Publish(ctx, event)
+	}
+	now := time.Now()
+	entry.Attempts++
+	if err != nil {
+		entry.LastError = err.Error()
+		entry.NextAttemptAt = now.Add(helpers.Backoff(entry.Attempts,
+			time.Duration(r.settings.RetryBase)*time.Second,
+			time.Duration(r.settings.MaxRetryDelay)*time.Second)).Format(timeLayout)
+		if entry.Attempts >= r.settings.MaxAttempts {
+			entry.Status = Failed
+		}
+		log.Errorf("Relay: failed to deliver %s event %s (attempt %d): %v", entry.Type, entry.ID, entry.Attempts, err)
+	} else {
+		entry.Status = Delivered
+		entry.DeliveredAt = now.Format(timeLayout)
+		entry.LastError = ""
+	}
+	if _, err := r.repository.Update(ctx, entry); err != nil {
+		log.Errorf("Relay: failed to store the status of %s: %v", entry.ID, err)
+	}
+}
+
+func NewRelay(repo Repository, publisher events.Publisher, settings config.OutboxSettings) Relay {
+	return Relay{repository: repo, publisher: publisher, settings: settings}
+}
@@ -0,0 +1,17 @@
+package outbox
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, entry *Entry) (string, error)
+	Update(ctx context.Context, entry *Entry) (bool, error)
+	// GetPending returns up to limit pending entries due at the given time, oldest first.
+	GetPending(ctx context.Context, due string, limit int) ([]Entry, error)
+	// Claim postpones the next attempt of a pending entry to until, unless another
+	// relay changed the entry since it was read, and reports whether it did.
+	Claim(ctx context.Context, entry *Entry, until string) (bool, error)
+	// DeleteDelivered removes the entries delivered before the given time.
+	DeleteDelivered(ctx context.Context, before string) (int64, error)
+}
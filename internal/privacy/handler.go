@@ -0,0 +1,59 @@
+package privacy
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func (h Handler) exportUser(c echo.Context) error {
+	id := c.Param("id")
+	actor := Actor{ID: fmt.Sprintf("%v", c.Get("id")), Role: fmt.Sprintf("%v", c.Get("role"))}
+
+	result, err := h.service.Export(c.Request().Context(), actor, id)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(result.Name())
+	defer result.Close()
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "user-"+id+"-export.zip"))
+	return c.Stream(http.StatusOK, "application/zip", result)
+}
+
+func (h Handler) eraseUser(c echo.Context) error {
+	id := c.Param("id")
+	request := new(ErasureRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	request.ActorId = fmt.Sprintf("%v", c.Get("id"))
+	request.ClientIp = c.RealIP()
+
+	result, err := h.service.Erase(c.Request().Context(), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func NewPrivacyHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+// RegisterPrivacyHandlers also serves DELETE api/users/:id, so that removing a user
+// always goes through the erasure workflow instead of leaving documents behind.
+func RegisterPrivacyHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	member := authenticator.TokenHandlerMiddlewareFunc("user", "admin")
+	admin := authenticator.TokenHandlerMiddlewareFunc("admin")
+	instance.GET("api/users/:id/export", h.exportUser, member, middleware.NotImpersonatedMiddlewareFunc)
+	instance.DELETE("api/users/:id", h.eraseUser, admin, middleware.NotImpersonatedMiddlewareFunc)
+}
@@ -0,0 +1,28 @@
+package privacy
+
+const (
+	ReassignDocuments = "reassign"
+	DeleteDocuments   = "delete"
+)
+
+// ErasureRequest decides what happens to the erased user's documents: they are
+// either handed over to another user of the organization or deleted with their files.
+type ErasureRequest struct {
+	Documents  string `json:"documents" validate:"required,oneof=reassign delete"`
+	ReassignTo string `json:"reassign_to"`
+	ActorId    string `json:"-"`
+	ClientIp   string `json:"-"`
+}
+
+type ErasureResponse struct {
+	UserId                string `json:"user_id"`
+	DocumentsReassigned   int64  `json:"documents_reassigned"`
+	DocumentsDeleted      int64  `json:"documents_deleted"`
+	AuditEventsAnonymized int64  `json:"audit_events_anonymized"`
+	ActivitiesAnonymized  int64  `json:"activities_anonymized"`
+}
+
+type Actor struct {
+	ID   string
+	Role string
+}
@@ -0,0 +1,193 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hasanbakirci/doc-system/internal/activity"
+	"github.com/hasanbakirci/doc-system/internal/audit"
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/internal/document"
+	"github.com/hasanbakirci/doc-system/internal/group"
+	"github.com/hasanbakirci/doc-system/internal/notification"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Export(ctx context.Context, actor Actor, id string) (*os.File, error)
+	Erase(ctx context.Context, id string, request ErasureRequest) (*ErasureResponse, error)
+}
+
+type privacyService struct {
+	accounts      auth.Service
+	documents     document.Service
+	groups        group.Service
+	audit         audit.Service
+	activity      activity.Service
+	notifications notification.Service
+}
+
+// Export bundles the user's profile, document metadata with the uploaded files and
+// the audit events about the user into a ZIP archive. Users can export their own
+// data, admins that of everyone in their organization. The archive is written to a
+// temporary file, rewound for reading, which the caller closes and removes.
+func (p privacyService) Export(ctx context.Context, actor Actor, id string) (*os.File, error) {
+	if actor.Role != "admin" && actor.ID != id {
+		return nil, appError.Forbidden("export_not_allowed", "Service: users can only export their own data")
+	}
+	user, err := p.accounts.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	documents, err := p.documents.GetAllByOwner(ctx, id)
+	if err != nil {
+		return nil, appError.Unavailable("documents_unavailable", "Service: failed to load documents").Wrap(err)
+	}
+	events, err := p.audit.GetAllByUser(ctx, id)
+	if err != nil {
+		return nil, appError.Unavailable("activity_unavailable", "Service: failed to load activity").Wrap(err)
+	}
+
+	export, err := os.CreateTemp("", "user-export-*.zip")
+	if err != nil {
+		return nil, appError.Internal("export_not_created", "Service: failed to create export").Wrap(err)
+	}
+	if err := writeArchive(export, user, documents, events); err != nil {
+		_ = export.Close()
+		_ = os.Remove(export.Name())
+		return nil, appError.Internal("export_not_created", "Service: failed to create export").Wrap(err)
+	}
+
+	p.audit.Record(ctx, audit.NewEvent(audit.UserDataExported, actor.ID, id, "", map[string]string{
+		"documents": strconv.Itoa(len(documents)),
+	}))
+	return export, nil
+}
+
+// writeArchive zips the export into file and rewinds it. Document files are copied
+// one at a time, so large uploads are not held in memory.
+func writeArchive(file *os.File, user *auth.UserResponse, documents []document.DocumentResponse, events []audit.Event) error {
+	archive := zip.NewWriter(file)
+	if err := writeJson(archive, "profile.json", user); err != nil {
+		return err
+	}
+	if err := writeJson(archive, "documents.json", documents); err != nil {
+		return err
+	}
+	if err := writeJson(archive, "activity.json", events); err != nil {
+		return err
+	}
+	for _, doc := range documents {
+		if err := writeFile(archive, "files/"+doc.ID+"-"+filepath.Base(doc.Name), doc.Path); err != nil {
+			log.Errorf("Service: file of document %s is missing from the export: %v", doc.ID, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return err
+}
+
+func writeFile(archive *zip.Writer, name, path string) error {
+	content, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, content)
+	return err
+}
+
+func writeJson(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// Erase deletes the account after reassigning or deleting its documents, removing it
+// from its groups and dropping its notifications and follows, then anonymizes the audit events and the activity log entries
+// that name it. Deleting the account also ends its sessions.
+func (p privacyService) Erase(ctx context.Context, id string, request ErasureRequest) (*ErasureResponse, error) {
+	if id == request.ActorId {
+		return nil, appError.Validation("self_erasure_not_allowed", "Service: cannot erase yourself")
+	}
+	if _, err := p.accounts.GetById(ctx, id); err != nil {
+		return nil, err
+	}
+	response := &ErasureResponse{UserId: id}
+
+	if request.Documents == ReassignDocuments {
+		if request.ReassignTo == "" || request.ReassignTo == id {
+			return nil, appError.Validation("reassign_target_invalid", "Service: documents must be reassigned to another user")
+		}
+		if _, err := p.accounts.GetById(ctx, request.ReassignTo); err != nil {
+			return nil, err
+		}
+		reassigned, err := p.documents.TransferOwnership(ctx, id, request.ReassignTo)
+		if err != nil {
+			return nil, appError.Unavailable("documents_not_reassigned", "Service: failed to reassign documents").Wrap(err)
+		}
+		response.DocumentsReassigned = reassigned
+	} else {
+		deleted, err := p.documents.DeleteAllByOwner(ctx, id)
+		if err != nil {
+			return nil, appError.Unavailable("documents_not_deleted", "Service: failed to delete documents").Wrap(err)
+		}
+		response.DocumentsDeleted = deleted
+	}
+
+	if err := p.groups.RemoveUser(ctx, id); err != nil {
+		return nil, appError.Unavailable("group_memberships_not_removed", "Service: failed to remove user from groups").Wrap(err)
+	}
+	if err := p.notifications.Forget(ctx, id); err != nil {
+		return nil, appError.Unavailable("notifications_not_deleted", "Service: failed to delete notifications and follows").Wrap(err)
+	}
+	if _, err := p.accounts.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+
+	anonymized, err := p.audit.Anonymize(ctx, id)
+	if err != nil {
+		log.Errorf("Service: failed to anonymize audit events of erased user: %v", err)
+	}
+	response.AuditEventsAnonymized = anonymized
+
+	activities, err := p.activity.Anonymize(ctx, id)
+	if err != nil {
+		log.Errorf("Service: failed to anonymize activity of erased user: %v", err)
+	}
+	response.ActivitiesAnonymized = activities
+
+	// the erased user is left out so the event does not undo the anonymization
+	p.audit.Record(ctx, audit.NewEvent(audit.UserErased, request.ActorId, "", request.ClientIp, map[string]string{
+		"documents":               request.Documents,
+		"reassign_to":             request.ReassignTo,
+		"documents_reassigned":    strconv.FormatInt(response.DocumentsReassigned, 10),
+		"documents_deleted":       strconv.FormatInt(response.DocumentsDeleted, 10),
+		"audit_events_anonymized": strconv.FormatInt(anonymized, 10),
+		"activities_anonymized":   strconv.FormatInt(activities, 10),
+	}))
+	return response, nil
+}
+
+func NewPrivacyService(accounts auth.Service, documents document.Service, groups group.Service, auditService audit.Service,
+	activityService activity.Service, notificationService notification.Service) Service {
+	return &privacyService{accounts: accounts, documents: documents, groups: groups, audit: auditService,
+		activity: activityService, notifications: notificationService}
+}
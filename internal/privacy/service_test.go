@@ -0,0 +1,100 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hasanbakirci/doc-system/internal/activity"
+	"github.com/hasanbakirci/doc-system/internal/audit"
+	"github.com/hasanbakirci/doc-system/internal/auth"
+	"github.com/hasanbakirci/doc-system/internal/document"
+	"github.com/hasanbakirci/doc-system/internal/group"
+	"github.com/hasanbakirci/doc-system/internal/notification"
+)
+
+// The fakes embed the service interfaces, so calls that Erase should not make panic.
+
+type fakeAccounts struct {
+	auth.Service
+	deleted []string
+}
+
+func (f *fakeAccounts) GetById(ctx context.Context, id string) (*auth.UserResponse, error) {
+	return &auth.UserResponse{ID: id}, nil
+}
+
+func (f *fakeAccounts) Delete(ctx context.Context, id string) (bool, error) {
+	f.deleted = append(f.deleted, id)
+	return true, nil
+}
+
+type fakeDocuments struct{ document.Service }
+
+func (fakeDocuments) DeleteAllByOwner(ctx context.Context, ownerId string) (int64, error) {
+	return 0, nil
+}
+
+type fakeGroups struct{ group.Service }
+
+func (fakeGroups) RemoveUser(ctx context.Context, userId string) error {
+	return nil
+}
+
+type fakeAudit struct{ audit.Service }
+
+func (fakeAudit) Record(ctx context.Context, event *audit.Event) {}
+
+func (fakeAudit) Anonymize(ctx context.Context, userId string) (int64, error) {
+	return 0, nil
+}
+
+type fakeActivity struct{ activity.Service }
+
+func (fakeActivity) Anonymize(ctx context.Context, userId string) (int64, error) {
+	return 0, nil
+}
+
+type fakeNotifications struct {
+	notification.Service
+	forgotten []string
+	err       error
+}
+
+func (f *fakeNotifications) Forget(ctx context.Context, userId string) error {
+	f.forgotten = append(f.forgotten, userId)
+	return f.err
+}
+
+func newTestService(accounts *fakeAccounts, notifications *fakeNotifications) Service {
+	return NewPrivacyService(accounts, fakeDocuments{}, fakeGroups{}, fakeAudit{}, fakeActivity{}, notifications)
+}
+
+func TestEraseForgetsNotificationsAndFollows(t *testing.T) {
+	accounts := &fakeAccounts{}
+	notifications := &fakeNotifications{}
+	s := newTestService(accounts, notifications)
+
+	if _, err := s.Erase(context.Background(), "alice", ErasureRequest{Documents: DeleteDocuments, ActorId: "admin"}); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if len(notifications.forgotten) != 1 || notifications.forgotten[0] != "alice" {
+		t.Fatalf("Forget() calls = %v, want [alice]", notifications.forgotten)
+	}
+	if len(accounts.deleted) != 1 {
+		t.Fatalf("account deletes = %v, want one", accounts.deleted)
+	}
+}
+
+func TestEraseKeepsAccountWhenNotificationsRemain(t *testing.T) {
+	accounts := &fakeAccounts{}
+	notifications := &fakeNotifications{err: errors.New("elastic unavailable")}
+	s := newTestService(accounts, notifications)
+
+	if _, err := s.Erase(context.Background(), "alice", ErasureRequest{Documents: DeleteDocuments, ActorId: "admin"}); err == nil {
+		t.Fatal("Erase() succeeded although the notifications were not deleted")
+	}
+	if len(accounts.deleted) != 0 {
+		t.Fatalf("account was deleted although the notifications remain: %v", accounts.deleted)
+	}
+}
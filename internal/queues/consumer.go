@@ -0,0 +1,29 @@
+package queues
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/deadletter"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	log "github.com/sirupsen/logrus"
+)
+
+// Consumer handles the events of the bus as a member of Group. Every group receives
+// all events, so each consumer needs a group of its own.
+type Consumer struct {
+	Name    string
+	Group   string
+	Handler events.Handler
+}
+
+// Consume handles the events of the group until the context is cancelled or the
+// subscription fails. Failed events are retried and then dead-lettered.
+func (c Consumer) Consume(ctx context.Context, bus events.Bus, deadLetters deadletter.Repository, settings config.ConsumerSettings) error {
+	return bus.Subscribe(ctx, c.Group, WithRetry(c.Group, c.handle, deadLetters, settings))
+}
+
+func (c Consumer) handle(ctx context.Context, event *events.Event) error {
+	log.WithFields(log.Fields{"consumer": c.Name, "type": event.Type, "subject": event.Subject, "actor": event.Actor}).Info("Consumer: received event ", event.ID)
+	return c.Handler(ctx, event)
+}
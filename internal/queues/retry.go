@@ -0,0 +1,51 @@
+package queues
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/deadletter"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+// WithRetry tries the handler up to MaxAttempts times with exponential backoff and
+// then moves the event to the dead-letter stream, so a poison event does not block
+// the group. The event is only left to the bus for redelivery when it can not be
+// dead-lettered either.
+func WithRetry(group string, handler events.Handler, deadLetters deadletter.Repository, settings config.ConsumerSettings) events.Handler {
+	base := time.Duration(settings.RetryBase) * time.Second
+	maxDelay := time.Duration(settings.MaxRetryDelay) * time.Second
+	maxAttempts := settings.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return func(ctx context.Context, event *events.Event) error {
+		var err error
+		attempts := 0
+		for attempts < maxAttempts {
+			attempts++
+			if err = handler(ctx, event); err == nil {
+				return nil
+			}
+			if attempts == maxAttempts {
+				break
+			}
+			delay := helpers.Backoff(attempts, base, maxDelay)
+			log.Warnf("Consumer: %s failed to handle %s (attempt %d), retrying in %s: %v", group, event.ID, attempts, delay, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		log.Errorf("Consumer: %s gave up on %s after %d attempts: %v", group, event.ID, attempts, err)
+		if _, deadErr := deadLetters.Create(ctx, deadletter.NewDeadLetter(group, event, err, attempts)); deadErr != nil {
+			log.Errorf("Consumer: failed to dead-letter %s: %v", event.ID, deadErr)
+			return err
+		}
+		return nil
+	}
+}
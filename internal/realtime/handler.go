@@ -0,0 +1,206 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const writeTimeout = 10 * time.Second
+
+type Handler struct {
+	hub       *Hub
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+}
+
+// WebSocketMessage is the frame sent for every event.
+type WebSocketMessage struct {
+	ID    string          `json:"id"`
+	Event json.RawMessage `json:"event"`
+}
+
+// documentEvents lets a client see the document events of its own organization,
+// which everyone who can read documents is allowed to list.
+func documentEvents(tenantId string) Filter {
+	return func(event *events.Event) bool {
+		return event.TenantId == tenantId && strings.HasPrefix(event.Type, "document.")
+	}
+}
+
+// subscribe starts a subscription and collects the events the client missed since
+// lastEventId. Subscribing first makes sure nothing added during the replay is lost.
+func (h Handler) subscribe(c echo.Context, lastEventId string) (*Subscription, []Message, error) {
+	if lastEventId != "" && !ValidId(lastEventId) {
+		return nil, nil, appError.Validation("invalid_last_event_id", "Last-Event-ID is not an event id of this stream")
+	}
+	filter := documentEvents(helpers.Tenant(c.Request().Context()))
+	subscription := h.hub.Subscribe(filter)
+	if lastEventId == "" {
+		return subscription, nil, nil
+	}
+	missed, err := h.hub.Replay(c.Request().Context(), lastEventId, filter)
+	if err != nil {
+		subscription.Close()
+		return nil, nil, appError.Unavailable("events_unavailable", "The missed events could not be read").Wrap(err)
+	}
+	return subscription, missed, nil
+}
+
+// streamEvents pushes events as Server-Sent Events. Clients resume with the
+// Last-Event-ID header, which EventSource sends on its own when reconnecting.
+func (h Handler) streamEvents(c echo.Context) error {
+	lastEventId := c.Request().Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.QueryParam("last_event_id")
+	}
+	subscription, missed, err := h.subscribe(c, lastEventId)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	// keeps reverse proxies such as nginx from buffering the stream
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+
+	last := lastEventId
+	for _, message := range missed {
+		if _, err := fmt.Fprintf(response, "id: %s\nevent: %s\ndata: %s\n\n", message.ID, message.Event.Type, message.Data); err != nil {
+			return nil
+		}
+		last = message.ID
+	}
+	response.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(response, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case message, ok := <-subscription.Messages:
+			if !ok {
+				return nil
+			}
+			if last != "" && !After(message.ID, last) {
+				continue
+			}
+			if _, err := fmt.Fprintf(response, "id: %s\nevent: %s\ndata: %s\n\n", message.ID, message.Event.Type, message.Data); err != nil {
+				return nil
+			}
+			last = message.ID
+		}
+		response.Flush()
+	}
+}
+
+// streamWebSocket pushes events as WebSocketMessage frames. Clients resume with the
+// last_event_id query parameter. The connection is pinged every heartbeat and
+// closed when two pongs in a row are missing.
+func (h Handler) streamWebSocket(c echo.Context) error {
+	subscription, missed, err := h.subscribe(c, c.QueryParam("last_event_id"))
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	conn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// the upgrader has already answered the request
+		log.Errorf("Realtime: websocket upgrade failed: %v", err)
+		return nil
+	}
+	defer conn.Close()
+
+	// the reader handles pongs and close frames; clients have nothing else to send
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	last := c.QueryParam("last_event_id")
+	for _, message := range missed {
+		if err := h.send(conn, message); err != nil {
+			return nil
+		}
+		last = message.ID
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return nil
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return nil
+			}
+		case message, ok := <-subscription.Messages:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "resume with last_event_id"),
+					time.Now().Add(writeTimeout))
+				return nil
+			}
+			if last != "" && !After(message.ID, last) {
+				continue
+			}
+			if err := h.send(conn, message); err != nil {
+				return nil
+			}
+			last = message.ID
+		}
+	}
+}
+
+func (h Handler) send(conn *websocket.Conn, message Message) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return conn.WriteJSON(WebSocketMessage{ID: message.ID, Event: json.RawMessage(message.Data)})
+}
+
+func NewRealtimeHandler(hub *Hub, settings config.StreamSettings) Handler {
+	heartbeat := time.Duration(settings.Heartbeat) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return Handler{hub: hub, heartbeat: heartbeat}
+}
+
+// RegisterRealtimeHandlers streams the document events to everyone who can read
+// documents. Browsers pass their token as the access_token query parameter.
+func RegisterRealtimeHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	read := authenticator.ScopedMiddlewareFunc(helpers.DocumentsRead, "user", "admin")
+	instance.GET("api/events/stream", h.streamEvents, middleware.QueryTokenMiddlewareFunc, read)
+	instance.GET("api/events/ws", h.streamWebSocket, middleware.QueryTokenMiddlewareFunc, read)
+}
@@ -0,0 +1,207 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// blockTime bounds a stream read so a cancelled hub stops soon.
+	blockTime  = 5 * time.Second
+	retryDelay = 5 * time.Second
+	pageSize   = 100
+)
+
+// Message is an event of the stream. ID is the stream entry id, which clients send
+// back as Last-Event-ID to resume.
+type Message struct {
+	ID    string
+	Event *events.Event
+	Data  string
+}
+
+// Filter decides whether a subscriber receives an event.
+type Filter func(event *events.Event) bool
+
+// Subscription receives the matching events of the hub on Messages. The channel is
+// closed when the subscriber fell too far behind or the hub stopped.
+type Subscription struct {
+	Messages chan Message
+	filter   Filter
+	hub      *Hub
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub tails the event stream once per api instance and fans the events out to the
+// connected clients.
+type Hub struct {
+	redis       *redisClient.RedisClient
+	stream      string
+	buffer      int
+	lock        sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscribe starts receiving the events added to the stream from now on.
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	subscription := &Subscription{Messages: make(chan Message, h.buffer), filter: filter, hub: h}
+	h.lock.Lock()
+	h.subscribers[subscription] = struct{}{}
+	h.lock.Unlock()
+	return subscription
+}
+
+func (h *Hub) remove(subscription *Subscription) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.Messages)
+	}
+}
+
+// Run tails the stream until the context is cancelled. Read errors are retried
+// from the last entry seen, so no event is skipped while Redis is unavailable.
+func (h *Hub) Run(ctx context.Context) {
+	last := "$"
+	for ctx.Err() == nil {
+		messages, err := h.redis.ReadStream(ctx, h.stream, last, pageSize, blockTime)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Errorf("Realtime: failed to read the event stream, retrying in %s: %v", retryDelay, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+		for _, m := range messages {
+			last = m.ID
+			if message, ok := toMessage(m); ok {
+				h.broadcast(message)
+			}
+		}
+	}
+	h.lock.Lock()
+	for subscription := range h.subscribers {
+		delete(h.subscribers, subscription)
+		close(subscription.Messages)
+	}
+	h.lock.Unlock()
+}
+
+// broadcast never blocks the stream: a subscriber whose buffer is full is dropped.
+func (h *Hub) broadcast(message Message) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for subscription := range h.subscribers {
+		if !subscription.filter(message.Event) {
+			continue
+		}
+		select {
+		case subscription.Messages <- message:
+		default:
+			log.Warnf("Realtime: dropped a subscriber that fell behind at %s", message.ID)
+			delete(h.subscribers, subscription)
+			close(subscription.Messages)
+		}
+	}
+}
+
+// Replay returns the matching events added to the stream after the given entry id,
+// oldest first. Events trimmed from the stream can not be replayed.
+func (h *Hub) Replay(ctx context.Context, after string, filter Filter) ([]Message, error) {
+	replayed := make([]Message, 0)
+	start := after
+	for ctx.Err() == nil {
+		messages, err := h.redis.RangeStream(h.stream, start, pageSize+1)
+		if err != nil {
+			return nil, err
+		}
+		count := 0
+		for _, m := range messages {
+			// the range includes its start, which was already sent
+			if m.ID == start {
+				continue
+			}
+			count++
+			if message, ok := toMessage(m); ok && filter(message.Event) {
+				replayed = append(replayed, message)
+			}
+			start = m.ID
+		}
+		if count < pageSize {
+			break
+		}
+	}
+	return replayed, ctx.Err()
+}
+
+func toMessage(m redisClient.StreamMessage) (Message, bool) {
+	event := new(events.Event)
+	if err := json.Unmarshal([]byte(m.Payload), event); err != nil {
+		log.Errorf("Realtime: skipped malformed event %s: %v", m.ID, err)
+		return Message{}, false
+	}
+	return Message{ID: m.ID, Event: event, Data: m.Payload}, true
+}
+
+// ValidId reports whether id is a stream entry id such as "1760860800000-0".
+func ValidId(id string) bool {
+	parts := strings.Split(id, "-")
+	if len(parts) != 2 {
+		return false
+	}
+	for _, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// After reports whether the stream entry id a was added after b.
+func After(a, b string) bool {
+	aParts, bParts := strings.Split(a, "-"), strings.Split(b, "-")
+	aMs, _ := strconv.ParseUint(aParts[0], 10, 64)
+	bMs, _ := strconv.ParseUint(bParts[0], 10, 64)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	var aSeq, bSeq uint64
+	if len(aParts) > 1 {
+		aSeq, _ = strconv.ParseUint(aParts[1], 10, 64)
+	}
+	if len(bParts) > 1 {
+		bSeq, _ = strconv.ParseUint(bParts[1], 10, 64)
+	}
+	return aSeq > bSeq
+}
+
+func NewHub(redis *redisClient.RedisClient, bus string, stream string, settings config.StreamSettings) (*Hub, error) {
+	// other buses never write the stream, so the hub would stay silent
+	if bus != events.RedisStreamsBus && bus != "" {
+		return nil, fmt.Errorf("live event streams need the %q event bus, not %q", events.RedisStreamsBus, bus)
+	}
+	buffer := settings.Buffer
+	if buffer <= 0 {
+		buffer = pageSize
+	}
+	return &Hub{redis: redis, stream: stream, buffer: buffer, subscribers: make(map[*Subscription]struct{})}, nil
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func (h Handler) getAllSessions(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	sid := fmt.Sprintf("%v", c.Get("sid"))
+
+	result, err := h.service.GetAll(c.Request().Context(), uid, sid)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) deleteSession(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	id := c.Param("id")
+
+	result, err := h.service.Delete(c.Request().Context(), uid, id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) deleteOtherSessions(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	sid := fmt.Sprintf("%v", c.Get("sid"))
+
+	count, err := h.service.DeleteOthers(c.Request().Context(), uid, sid)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, count, "Success")
+}
+
+func NewSessionHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+func RegisterSessionHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	session := authenticator.TokenPurposeMiddlewareFunc("")
+	instance.GET("api/users/me/sessions", h.getAllSessions, session)
+	instance.DELETE("api/users/me/sessions", h.deleteOtherSessions, session, middleware.NotImpersonatedMiddlewareFunc)
+	instance.DELETE("api/users/me/sessions/:id", h.deleteSession, session, middleware.NotImpersonatedMiddlewareFunc)
+}
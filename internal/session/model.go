@@ -0,0 +1,81 @@
+package session
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+type Session struct {
+	ID         string `json:"id"`
+	UserId     string `json:"user_id"`
+	Device     string `json:"device"`
+	IpAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+}
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	IpAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
+
+func NewSession(userId, ipAddress, userAgent string) *Session {
+	now := time.Now().Format(timeLayout)
+	return &Session{
+		ID:         uuid.New().String(),
+		UserId:     userId,
+		Device:     deviceName(userAgent),
+		IpAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
+
+func (s *Session) ToSessionResponse(currentId string) *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		Device:     s.Device,
+		IpAddress:  s.IpAddress,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		Current:    s.ID == currentId,
+	}
+}
+
+// deviceName gives a rough "browser on platform" label for the session list.
+func deviceName(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	platform := "Unknown platform"
+	for _, p := range []struct{ token, name string }{
+		{"android", "Android"}, {"iphone", "iOS"}, {"ipad", "iPadOS"}, {"windows", "Windows"},
+		{"mac os", "macOS"}, {"linux", "Linux"},
+	} {
+		if strings.Contains(ua, p.token) {
+			platform = p.name
+			break
+		}
+	}
+	client := "Unknown client"
+	for _, c := range []struct{ token, name string }{
+		{"edg/", "Edge"}, {"opr/", "Opera"}, {"firefox/", "Firefox"}, {"chrome/", "Chrome"},
+		{"safari/", "Safari"}, {"curl/", "curl"}, {"postman", "Postman"}, {"go-http-client", "Go client"},
+	} {
+		if strings.Contains(ua, c.token) {
+			client = c.name
+			break
+		}
+	}
+	return client + " on " + platform
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/pkg/redisClient"
+	"github.com/pkg/errors"
+)
+
+// redisRepository keeps every session under its own key, expiring with the token,
+// plus a set of session ids per user for listing and bulk sign-out.
+type redisRepository struct {
+	redis *redisClient.RedisClient
+}
+
+func sessionKey(id string) string {
+	return "doc-system:session:" + id
+}
+
+func userSessionsKey(userId string) string {
+	return "doc-system:user-sessions:" + userId
+}
+
+func (r redisRepository) Create(ctx context.Context, session *Session, ttl time.Duration) error {
+	if err := r.redis.SetWithExpiration(sessionKey(session.ID), session, ttl); err != nil {
+		return err
+	}
+	return r.redis.AddToSet(userSessionsKey(session.UserId), session.ID, ttl)
+}
+
+func (r redisRepository) Get(ctx context.Context, id string) (*Session, error) {
+	session := new(Session)
+	found, err := r.redis.Get(sessionKey(id), session)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("Redis repository: session not found")
+	}
+	return session, nil
+}
+
+// GetAllByUser also prunes ids whose session already expired.
+func (r redisRepository) GetAllByUser(ctx context.Context, userId string) ([]Session, error) {
+	ids, err := r.redis.SetMembers(userSessionsKey(userId))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0)
+	expired := make([]string, 0)
+	for _, id := range ids {
+		session := Session{}
+		found, err := r.redis.Get(sessionKey(id), &session)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			expired = append(expired, id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(expired) > 0 {
+		if err := r.redis.RemoveFromSet(userSessionsKey(userId), expired...); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+func (r redisRepository) Touch(ctx context.Context, session *Session) error {
+	return r.redis.SetWithExpiration(sessionKey(session.ID), session, redisClient.KeepTTL)
+}
+
+func (r redisRepository) Delete(ctx context.Context, userId string, id string) (bool, error) {
+	if _, err := r.Get(ctx, id); err != nil {
+		return false, nil
+	}
+	if err := r.redis.Delete(sessionKey(id)); err != nil {
+		return false, err
+	}
+	return true, r.redis.RemoveFromSet(userSessionsKey(userId), id)
+}
+
+func (r redisRepository) DeleteAllByUser(ctx context.Context, userId string) (int, error) {
+	ids, err := r.redis.SetMembers(userSessionsKey(userId))
+	if err != nil {
+		return 0, err
+	}
+	keys := []string{userSessionsKey(userId)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	return len(ids), r.redis.Delete(keys...)
+}
+
+func NewRedisRepository(redis *redisClient.RedisClient) Repository {
+	return &redisRepository{redis: redis}
+}
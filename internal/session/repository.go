@@ -0,0 +1,15 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, session *Session, ttl time.Duration) error
+	Get(ctx context.Context, id string) (*Session, error)
+	GetAllByUser(ctx context.Context, userId string) ([]Session, error)
+	Touch(ctx context.Context, session *Session) error
+	Delete(ctx context.Context, userId string, id string) (bool, error)
+	DeleteAllByUser(ctx context.Context, userId string) (int, error)
+}
@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// touchInterval limits how often a request rewrites the session's last seen time.
+const touchInterval = time.Minute
+
+type Service interface {
+	Create(ctx context.Context, userId string, ipAddress string, userAgent string) (*Session, error)
+	GetAll(ctx context.Context, userId string, currentId string) ([]SessionResponse, error)
+	Delete(ctx context.Context, userId string, id string) (bool, error)
+	DeleteOthers(ctx context.Context, userId string, currentId string) (int, error)
+	DeleteAllByUser(ctx context.Context, userId string) (int, error)
+	ValidateSession(ctx context.Context, claims *helpers.UserClaim) error
+}
+
+type sessionService struct {
+	repository Repository
+	ttl        time.Duration
+}
+
+func (s sessionService) Create(ctx context.Context, userId string, ipAddress string, userAgent string) (*Session, error) {
+	session := NewSession(userId, ipAddress, userAgent)
+	if err := s.repository.Create(ctx, session, s.ttl); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s sessionService) GetAll(ctx context.Context, userId string, currentId string) ([]SessionResponse, error) {
+	sessions, err := s.repository.GetAllByUser(ctx, userId)
+	if err != nil {
+		return nil, appError.Unavailable("sessions_unavailable", "Service: failed to read sessions").Wrap(err)
+	}
+	responses := make([]SessionResponse, 0)
+	for i := 0; i < len(sessions); i++ {
+		responses = append(responses, *sessions[i].ToSessionResponse(currentId))
+	}
+	return responses, nil
+}
+
+// Delete signs out one of the user's own sessions. Sessions of other users are
+// reported as not found so that their ids cannot be probed.
+func (s sessionService) Delete(ctx context.Context, userId string, id string) (bool, error) {
+	session, err := s.repository.Get(ctx, id)
+	if err != nil || session.UserId != userId {
+		return false, appError.NotFound("session_not_found", "Service: session not found")
+	}
+	result, err := s.repository.Delete(ctx, userId, id)
+	if err != nil {
+		return false, appError.Unavailable("session_not_deleted", "Service: failed to delete session").Wrap(err)
+	}
+	if !result {
+		return false, appError.NotFound("session_not_found", "Service: session not found")
+	}
+	return true, nil
+}
+
+// DeleteOthers signs out every session of the user except the current one.
+func (s sessionService) DeleteOthers(ctx context.Context, userId string, currentId string) (int, error) {
+	sessions, err := s.repository.GetAllByUser(ctx, userId)
+	if err != nil {
+		return 0, appError.Unavailable("sessions_unavailable", "Service: failed to read sessions").Wrap(err)
+	}
+	count := 0
+	for _, session := range sessions {
+		if session.ID == currentId {
+			continue
+		}
+		result, err := s.repository.Delete(ctx, userId, session.ID)
+		if err != nil {
+			return count, appError.Unavailable("sessions_not_deleted", "Service: failed to delete sessions").Wrap(err)
+		}
+		if result {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s sessionService) DeleteAllByUser(ctx context.Context, userId string) (int, error) {
+	count, err := s.repository.DeleteAllByUser(ctx, userId)
+	if err != nil {
+		log.Errorf("Service: failed to delete sessions of %s: %v", userId, err)
+		return 0, appError.Unavailable("sessions_not_deleted", "Service: failed to delete sessions").Wrap(err)
+	}
+	return count, nil
+}
+
+// ValidateSession checks that the token's session was not signed out and records
+// the request as the session's latest activity.
+func (s sessionService) ValidateSession(ctx context.Context, claims *helpers.UserClaim) error {
+	if claims.Id == "" {
+		return errors.New("token has no session")
+	}
+	session, err := s.repository.Get(ctx, claims.Id)
+	if err != nil {
+		return err
+	}
+	// impersonation tokens live within the admin's session
+	owner := claims.ID
+	if claims.ImpersonatorId != "" {
+		owner = claims.ImpersonatorId
+	}
+	if session.UserId != owner {
+		return errors.New("session belongs to another user")
+	}
+
+	now := time.Now()
+	lastSeen, err := time.ParseInLocation(timeLayout, session.LastSeenAt, time.Local)
+	if err == nil && now.Sub(lastSeen) < touchInterval {
+		return nil
+	}
+	session.LastSeenAt = now.Format(timeLayout)
+	if err := s.repository.Touch(ctx, session); err != nil {
+		log.Errorf("Service: failed to update session activity: %v", err)
+	}
+	return nil
+}
+
+func NewSessionService(repo Repository, cfg config.JwtSettings) Service {
+	return &sessionService{repository: repo, ttl: time.Duration(cfg.SessionTime) * time.Hour}
+}
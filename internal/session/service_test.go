@@ -0,0 +1,113 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+)
+
+type memoryRepository struct {
+	sessions map[string]Session
+}
+
+func (m *memoryRepository) Create(ctx context.Context, session *Session, ttl time.Duration) error {
+	m.sessions[session.ID] = *session
+	return nil
+}
+
+func (m *memoryRepository) Get(ctx context.Context, id string) (*Session, error) {
+	session, found := m.sessions[id]
+	if !found {
+		return nil, errors.New("session not found")
+	}
+	return &session, nil
+}
+
+func (m *memoryRepository) GetAllByUser(ctx context.Context, userId string) ([]Session, error) {
+	sessions := make([]Session, 0)
+	for _, session := range m.sessions {
+		if session.UserId == userId {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *memoryRepository) Touch(ctx context.Context, session *Session) error {
+	m.sessions[session.ID] = *session
+	return nil
+}
+
+func (m *memoryRepository) Delete(ctx context.Context, userId string, id string) (bool, error) {
+	if _, found := m.sessions[id]; !found {
+		return false, nil
+	}
+	delete(m.sessions, id)
+	return true, nil
+}
+
+func (m *memoryRepository) DeleteAllByUser(ctx context.Context, userId string) (int, error) {
+	count := 0
+	for id, session := range m.sessions {
+		if session.UserId == userId {
+			delete(m.sessions, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func newTestService(sessions ...*Session) (Service, *memoryRepository) {
+	repo := &memoryRepository{sessions: map[string]Session{}}
+	for _, session := range sessions {
+		repo.sessions[session.ID] = *session
+	}
+	return &sessionService{repository: repo, ttl: time.Hour}, repo
+}
+
+func TestDeleteOwnSession(t *testing.T) {
+	own := NewSession("alice", "", "")
+	s, repo := newTestService(own)
+
+	result, err := s.Delete(context.Background(), "alice", own.ID)
+	if err != nil || !result {
+		t.Fatalf("Delete() = %v, %v, want true", result, err)
+	}
+	if _, found := repo.sessions[own.ID]; found {
+		t.Fatal("session was not deleted")
+	}
+}
+
+func TestDeleteSessionOfAnotherUser(t *testing.T) {
+	other := NewSession("bob", "", "")
+	s, repo := newTestService(other)
+
+	_, err := s.Delete(context.Background(), "alice", other.ID)
+	var appErr *appError.Error
+	if !errors.As(err, &appErr) || appErr.Code != "session_not_found" {
+		t.Fatalf("Delete() error = %v, want session_not_found", err)
+	}
+	if _, found := repo.sessions[other.ID]; !found {
+		t.Fatal("session of another user was deleted")
+	}
+}
+
+func TestDeleteOthersKeepsCurrentSession(t *testing.T) {
+	current := NewSession("alice", "", "")
+	old := NewSession("alice", "", "")
+	other := NewSession("bob", "", "")
+	s, repo := newTestService(current, old, other)
+
+	count, err := s.DeleteOthers(context.Background(), "alice", current.ID)
+	if err != nil || count != 1 {
+		t.Fatalf("DeleteOthers() = %d, %v, want 1", count, err)
+	}
+	for _, id := range []string{current.ID, other.ID} {
+		if _, found := repo.sessions[id]; !found {
+			t.Fatalf("session %s was deleted", id)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers of a delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret, prefixed with "sha256=".
+// Receivers should recompute it and reject old timestamps to stop replays.
+const (
+	EventHeader     = "X-Doc-System-Event"
+	DeliveryHeader  = "X-Doc-System-Delivery"
+	TimestampHeader = "X-Doc-System-Timestamp"
+	SignatureHeader = "X-Doc-System-Signature"
+)
+
+// Sign returns the signature header value of a body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher posts pending deliveries to their webhooks. Any 2xx response is a
+// success; other responses and network errors are retried with exponential backoff
+// until MaxAttempts is reached. A webhook whose deliveries failed DisableAfter times
+// in a row is disabled until an admin activates it again. Dispatchers of several
+// replicas claim a delivery before sending it, so each attempt is posted once.
+type Dispatcher struct {
+	webhooks   Repository
+	deliveries DeliveryRepository
+	client     *http.Client
+	settings   config.WebhookSettings
+}
+
+// Run polls the pending deliveries until the context is cancelled.
+func (d Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(d.settings.PollInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		// a full batch means more deliveries are due, so the next batch is read right away
+		if d.dispatch(ctx) == d.settings.BatchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch sends one batch of due deliveries and returns how many it claimed, so
+// failing claims wait for the next tick.
+func (d Dispatcher) dispatch(ctx context.Context) int {
+	deliveries, err := d.deliveries.GetPending(helpers.WithAllTenants(ctx), time.Now().Format(timeLayout), d.settings.BatchSize)
+	if err != nil {
+		log.Errorf("Dispatcher: failed to read the pending deliveries: %v", err)
+		return 0
+	}
+	until := time.Now().Add(time.Duration(d.settings.ClaimTimeout) * time.Second).Format(timeLayout)
+	sent := 0
+	for i := range deliveries {
+		claimed, err := d.deliveries.Claim(ctx, &deliveries[i], until)
+		if err != nil {
+			log.Errorf("Dispatcher: failed to claim delivery %s: %v", deliveries[i].ID, err)
+			continue
+		}
+		// another dispatcher got to the delivery first
+		if !claimed {
+			continue
+		}
+		d.deliver(helpers.WithTenant(ctx, deliveries[i].TenantId), &deliveries[i])
+		sent++
+	}
+	return sent
+}
+
+func (d Dispatcher) deliver(ctx context.Context, delivery *Delivery) {
+	webhook, err := d.webhooks.GetById(ctx, delivery.WebhookId)
+	if err != nil || !webhook.Active {
+		// the webhook was deleted or disabled since the delivery was queued
+		delivery.Status = Failed
+		delivery.LastError = "webhook is deleted or disabled"
+		d.save(ctx, delivery)
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus, err = d.post(ctx, webhook, delivery, now)
+	if err != nil {
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(helpers.Backoff(delivery.Attempts,
+			time.Duration(d.settings.RetryBase)*time.Second,
+			time.Duration(d.settings.MaxRetryDelay)*time.Second)).Format(timeLayout)
+		log.Warnf("Dispatcher: failed to deliver %s to webhook %s (attempt %d): %v", delivery.EventId, webhook.ID, delivery.Attempts, err)
+		if delivery.Attempts >= d.settings.MaxAttempts {
+			delivery.Status = Failed
+			d.failed(ctx, webhook, now)
+		}
+	} else {
+		delivery.Status = Succeeded
+		delivery.DeliveredAt = now.Format(timeLayout)
+		delivery.LastError = ""
+		if webhook.ConsecutiveFailures > 0 {
+			webhook.ConsecutiveFailures = 0
+			d.saveWebhook(ctx, webhook)
+		}
+	}
+	d.save(ctx, delivery)
+}
+
+// post sends the payload and returns the response status. Response bodies are not
+// kept, so the delivery log does not echo what the target answered.
+func (d Dispatcher) post(ctx context.Context, webhook *Webhook, delivery *Delivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	request.Header.Set("Content-Type", "application/cloudevents+json")
+	request.Header.Set("User-Agent", "doc-system-webhooks")
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(DeliveryHeader, delivery.ID)
+	request.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	_ = response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("webhook responded with %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// failed counts a delivery that ran out of attempts against the webhook.
+func (d Dispatcher) failed(ctx context.Context, webhook *Webhook, now time.Time) {
+	webhook.ConsecutiveFailures++
+	if d.settings.DisableAfter > 0 && webhook.ConsecutiveFailures >= d.settings.DisableAfter {
+		webhook.Active = false
+		webhook.DisabledAt = now.Format(timeLayout)
+		webhook.DisabledReason = fmt.Sprintf("%d deliveries in a row failed", webhook.ConsecutiveFailures)
+		log.Warnf("Dispatcher: disabled webhook %s of %s: %s", webhook.ID, webhook.TenantId, webhook.DisabledReason)
+	}
+	d.saveWebhook(ctx, webhook)
+}
+
+func (d Dispatcher) save(ctx context.Context, delivery *Delivery) {
+	if _, err := d.deliveries.Update(ctx, delivery); err != nil {
+		log.Errorf("Dispatcher: failed to store the status of delivery %s: %v", delivery.ID, err)
+	}
+}
+
+func (d Dispatcher) saveWebhook(ctx context.Context, webhook *Webhook) {
+	if _, err := d.webhooks.Update(ctx, webhook); err != nil {
+		log.Errorf("Dispatcher: failed to store webhook %s: %v", webhook.ID, err)
+	}
+}
+
+func NewDispatcher(repo Repository, deliveries DeliveryRepository, settings config.WebhookSettings) Dispatcher {
+	// no proxy and no redirects, so that every connection goes through the dial check
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialControl}).DialContext
+	client := &http.Client{
+		Timeout:   time.Duration(settings.Timeout) * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return Dispatcher{webhooks: repo, deliveries: deliveries, client: client, settings: settings}
+}
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	elasticclient "github.com/hasanbakirci/doc-system/pkg/elasticClient"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/pkg/errors"
+)
+
+// elasticRepository keeps all organizations in one index, told apart by TenantId.
+type elasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+// Create implements Repository
+func (e *elasticRepository) Create(ctx context.Context, webhook *Webhook) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, webhook.ID, webhook); err != nil {
+		return "", err
+	}
+	return webhook.ID, nil
+}
+
+// Update implements Repository
+func (e *elasticRepository) Update(ctx context.Context, webhook *Webhook) (bool, error) {
+	if _, err := e.GetById(ctx, webhook.ID); err != nil {
+		return false, err
+	}
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, webhook.ID, webhook); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete implements Repository
+func (e *elasticRepository) Delete(ctx context.Context, id string) (bool, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", id))
+	if err != nil {
+		return false, err
+	}
+	deleted, err := elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+	return deleted > 0, err
+}
+
+// GetAll implements Repository
+func (e *elasticRepository) GetAll(ctx context.Context) ([]Webhook, error) {
+	scoped, err := e.scope.Filter(ctx, map[string]interface{}{"match_all": map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Webhook](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+// GetById implements Repository
+func (e *elasticRepository) GetById(ctx context.Context, id string) (*Webhook, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", id))
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := elasticclient.Search[Webhook](ctx, e.client, e.index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) < 1 {
+		return nil, errors.New("Elastic repository: webhook not found")
+	}
+	return &webhooks[0], nil
+}
+
+// GetActive implements Repository
+func (e *elasticRepository) GetActive(ctx context.Context) ([]Webhook, error) {
+	scoped, err := e.scope.Filter(ctx, map[string]interface{}{"term": map[string]interface{}{"Active": true}})
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Webhook](ctx, e.client, e.index, map[string]interface{}{
+		"size":  1000,
+		"query": scoped,
+	})
+}
+
+func NewElasticRepository(elastic *elasticsearch.Client) Repository {
+	index, alias := "webhooks_19092022", "webhooks"
+	return &elasticRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
+
+type elasticDeliveryRepository struct {
+	client *elasticsearch.Client
+	index  string
+	alias  string
+	scope  elasticclient.TenantScope
+}
+
+// Create implements DeliveryRepository
+func (e *elasticDeliveryRepository) Create(ctx context.Context, delivery *Delivery) (string, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, delivery.ID, delivery); err != nil {
+		return "", err
+	}
+	return delivery.ID, nil
+}
+
+// Update implements DeliveryRepository
+func (e *elasticDeliveryRepository) Update(ctx context.Context, delivery *Delivery) (bool, error) {
+	if err := elasticclient.Index(ctx, e.client, e.index, e.alias, delivery.ID, delivery); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetById implements DeliveryRepository
+func (e *elasticDeliveryRepository) GetById(ctx context.Context, id string) (*Delivery, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("ID", id))
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := elasticclient.Search[Delivery](ctx, e.client, e.index, map[string]interface{}{
+		"query": scoped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) < 1 {
+		return nil, errors.New("Elastic repository: delivery not found")
+	}
+	return &deliveries[0], nil
+}
+
+// GetAll implements DeliveryRepository
+func (e *elasticDeliveryRepository) GetAll(ctx context.Context, webhookId string, status string, limit int) ([]Delivery, error) {
+	queries := []map[string]interface{}{elasticclient.Term("WebhookId", webhookId)}
+	if status != "" {
+		queries = append(queries, elasticclient.Term("Status", status))
+	}
+	scoped, err := e.scope.Filter(ctx, elasticclient.Must(queries...))
+	if err != nil {
+		return nil, err
+	}
+	return elasticclient.Search[Delivery](ctx, e.client, e.index, map[string]interface{}{
+		"size":  limit,
+		"sort":  []interface{}{map[string]interface{}{"CreatedAt.keyword": "desc"}},
+		"query": scoped,
+	})
+}
+
+// GetPending implements DeliveryRepository
+func (e *elasticDeliveryRepository) GetPending(ctx context.Context, due string, limit int) ([]Delivery, error) {
+	if !helpers.AllTenants(ctx) {
+		return nil, helpers.ErrAllTenantsRequired
+	}
+	hits, err := elasticclient.SearchVersioned[Delivery](ctx, e.client, e.index, map[string]interface{}{
+		"size": limit,
+		"sort": []interface{}{map[string]interface{}{"CreatedAt.keyword": "asc"}},
+		"query": elasticclient.Must(
+			elasticclient.Term("Status", Pending),
+			map[string]interface{}{"range": map[string]interface{}{"NextAttemptAt.keyword": map[string]interface{}{"lte": due}}},
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]Delivery, len(hits))
+	for i, hit := range hits {
+		deliveries[i] = hit.Source
+		deliveries[i].SeqNo, deliveries[i].PrimaryTerm = hit.SeqNo, hit.PrimaryTerm
+	}
+	return deliveries, nil
+}
+
+// Claim implements DeliveryRepository
+func (e *elasticDeliveryRepository) Claim(ctx context.Context, delivery *Delivery, until string) (bool, error) {
+	claimed := *delivery
+	claimed.NextAttemptAt = until
+	ok, err := elasticclient.IndexIf(ctx, e.client, e.index, delivery.ID, delivery.SeqNo, delivery.PrimaryTerm, &claimed)
+	if ok {
+		delivery.NextAttemptAt = until
+	}
+	return ok, err
+}
+
+// DeleteAllByWebhook implements DeliveryRepository
+func (e *elasticDeliveryRepository) DeleteAllByWebhook(ctx context.Context, webhookId string) (int64, error) {
+	scoped, err := e.scope.Filter(ctx, elasticclient.Term("WebhookId", webhookId))
+	if err != nil {
+		return 0, err
+	}
+	return elasticclient.DeleteByQuery(ctx, e.client, e.index, scoped)
+}
+
+func NewElasticDeliveryRepository(elastic *elasticsearch.Client) DeliveryRepository {
+	index, alias := "webhook_deliveries_19092022", "webhook_deliveries"
+	return &elasticDeliveryRepository{client: elastic, index: index, alias: alias, scope: elasticclient.NewTenantScope(index, alias, false)}
+}
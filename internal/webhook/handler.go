@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func (h Handler) createWebhook(c echo.Context) error {
+	uid := fmt.Sprintf("%v", c.Get("id"))
+	request := new(CreateWebhookRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Create(c.Request().Context(), uid, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusCreated, result, "Store the secret now, it will not be shown again")
+}
+
+func (h Handler) updateWebhook(c echo.Context) error {
+	id := c.Param("id")
+	request := new(UpdateWebhookRequest)
+	if _, err := helpers.Validate(c, request); err != nil {
+		return err
+	}
+	result, err := h.service.Update(c.Request().Context(), id, *request)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) deleteWebhook(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.Delete(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getAllWebhooks(c echo.Context) error {
+	result, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getByIdWebhook(c echo.Context) error {
+	id := c.Param("id")
+
+	result, err := h.service.GetById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) getDeliveries(c echo.Context) error {
+	id := c.Param("id")
+	query := new(DeliveryQuery)
+	if _, err := helpers.Validate(c, query); err != nil {
+		return err
+	}
+	result, err := h.service.GetDeliveries(c.Request().Context(), id, *query)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusOK, result, "Success")
+}
+
+func (h Handler) redeliver(c echo.Context) error {
+	id := c.Param("id")
+	deliveryId := c.Param("deliveryId")
+
+	result, err := h.service.Redeliver(c.Request().Context(), id, deliveryId)
+	if err != nil {
+		return err
+	}
+	return errorHandler.Success(c, http.StatusAccepted, result, "Success")
+}
+
+func NewWebhookHandler(s Service) Handler {
+	return Handler{service: s}
+}
+
+// RegisterWebhookHandlers lets admins manage the webhooks of their organization.
+func RegisterWebhookHandlers(instance *echo.Echo, h Handler, authenticator *middleware.Authenticator) {
+	admin := authenticator.TokenHandlerMiddlewareFunc("admin")
+	instance.POST("api/webhooks", h.createWebhook, admin)
+	instance.GET("api/webhooks", h.getAllWebhooks, admin)
+	instance.GET("api/webhooks/:id", h.getByIdWebhook, admin)
+	instance.PUT("api/webhooks/:id", h.updateWebhook, admin)
+	instance.DELETE("api/webhooks/:id", h.deleteWebhook, admin)
+	instance.GET("api/webhooks/:id/deliveries", h.getDeliveries, admin)
+	instance.POST("api/webhooks/:id/deliveries/:deliveryId/redeliver", h.redeliver, admin)
+}
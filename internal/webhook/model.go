@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const timeLayout = "2006-01-02-15-04-05"
+
+// Delivery statuses. A pending delivery is retried until it succeeds or runs out of
+// attempts and fails.
+const (
+	Pending   = "pending"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// Webhook is a subscription of an organization to events. EventTypes filters the
+// events by type, a trailing "*" matches a prefix, e.g. "document.*", and no types
+// match every event. The secret signs every delivery and is kept in plain text
+// since it is needed to sign.
+type Webhook struct {
+	ID                  string   `bson:"_id"`
+	Url                 string   `bson:"url"`
+	Secret              string   `bson:"secret"`
+	EventTypes          []string `bson:"event_types"`
+	Description         string   `bson:"description"`
+	Active              bool     `bson:"active"`
+	ConsecutiveFailures int      `bson:"consecutive_failures"`
+	DisabledAt          string   `bson:"disabled_at"`
+	DisabledReason      string   `bson:"disabled_reason"`
+	TenantId            string   `bson:"tenant_id"`
+	CreatedBy           string   `bson:"created_by"`
+	CreatedAt           string   `bson:"created_at"`
+	UpdatedAt           string   `bson:"updated_at"`
+}
+
+// Delivery is one event sent, or to be sent, to a webhook. Payload is the event as
+// it is posted.
+type Delivery struct {
+	ID             string `bson:"_id"`
+	WebhookId      string `bson:"webhook_id"`
+	TenantId       string `bson:"tenant_id"`
+	EventId        string `bson:"event_id"`
+	EventType      string `bson:"event_type"`
+	Payload        string `bson:"payload"`
+	Status         string `bson:"status"`
+	Attempts       int    `bson:"attempts"`
+	ResponseStatus int    `bson:"response_status"`
+	LastError      string `bson:"last_error"`
+	RedeliveryOf   string `bson:"redelivery_of"`
+	NextAttemptAt  string `bson:"next_attempt_at"`
+	CreatedAt      string `bson:"created_at"`
+	DeliveredAt    string `bson:"delivered_at"`
+	// SeqNo and PrimaryTerm are the elastic version the delivery was read at
+	SeqNo       int `json:"-" bson:"-"`
+	PrimaryTerm int `json:"-" bson:"-"`
+}
+
+type CreateWebhookRequest struct {
+	Url         string   `json:"url" validate:"required,url"`
+	Secret      string   `json:"secret" validate:"omitempty,min=16"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+}
+
+// UpdateWebhookRequest replaces the subscription. Activating a disabled webhook
+// clears its failures.
+type UpdateWebhookRequest struct {
+	Url         string   `json:"url" validate:"required,url"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+}
+
+type DeliveryQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	Limit  int    `query:"limit" validate:"min=0,max=1000"`
+}
+
+type WebhookResponse struct {
+	ID                  string   `json:"id"`
+	Url                 string   `json:"url"`
+	EventTypes          []string `json:"event_types"`
+	Description         string   `json:"description"`
+	Active              bool     `json:"active"`
+	ConsecutiveFailures int      `json:"consecutive_failures"`
+	DisabledAt          string   `json:"disabled_at"`
+	DisabledReason      string   `json:"disabled_reason"`
+	CreatedBy           string   `json:"created_by"`
+	CreatedAt           string   `json:"created_at"`
+	UpdatedAt           string   `json:"updated_at"`
+}
+
+// CreatedWebhookResponse is the only response that carries the secret.
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type DeliveryResponse struct {
+	ID             string `json:"id"`
+	WebhookId      string `json:"webhook_id"`
+	EventId        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	ResponseStatus int    `json:"response_status"`
+	LastError      string `json:"last_error"`
+	RedeliveryOf   string `json:"redelivery_of"`
+	NextAttemptAt  string `json:"next_attempt_at"`
+	CreatedAt      string `json:"created_at"`
+	DeliveredAt    string `json:"delivered_at"`
+}
+
+// ToWebhook generates a secret unless the request brings its own.
+func (receiver *CreateWebhookRequest) ToWebhook(creatorId string) (*Webhook, error) {
+	secret := receiver.Secret
+	if secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		secret = "whsec_" + hex.EncodeToString(raw)
+	}
+	now := time.Now().Format(timeLayout)
+	return &Webhook{
+		ID:          uuid.New().String(),
+		Url:         receiver.Url,
+		Secret:      secret,
+		EventTypes:  receiver.EventTypes,
+		Description: receiver.Description,
+		Active:      true,
+		CreatedBy:   creatorId,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// Accepts reports whether the webhook subscribes to the event type.
+func (w *Webhook) Accepts(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType || t == "*" || (strings.HasSuffix(t, "*") && strings.HasPrefix(eventType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDelivery queues the event for the webhook. The id is derived from both, so an
+// event the bus delivers twice is queued once.
+func NewDelivery(webhook *Webhook, eventId string, eventType string, payload []byte) *Delivery {
+	now := time.Now().Format(timeLayout)
+	return &Delivery{
+		ID:            uuid.NewSHA1(uuid.NameSpaceURL, []byte(webhook.ID+"/"+eventId)).String(),
+		WebhookId:     webhook.ID,
+		TenantId:      webhook.TenantId,
+		EventId:       eventId,
+		EventType:     eventType,
+		Payload:       string(payload),
+		Status:        Pending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// Redelivery queues the payload of the delivery again as a new delivery, keeping
+// the log of the original.
+func (d *Delivery) Redelivery() *Delivery {
+	now := time.Now().Format(timeLayout)
+	return &Delivery{
+		ID:            uuid.New().String(),
+		WebhookId:     d.WebhookId,
+		TenantId:      d.TenantId,
+		EventId:       d.EventId,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		Status:        Pending,
+		RedeliveryOf:  d.ID,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+func (w *Webhook) ToWebhookResponse() *WebhookResponse {
+	return &WebhookResponse{
+		ID:                  w.ID,
+		Url:                 w.Url,
+		EventTypes:          w.EventTypes,
+		Description:         w.Description,
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+		DisabledReason:      w.DisabledReason,
+		CreatedBy:           w.CreatedBy,
+		CreatedAt:           w.CreatedAt,
+		UpdatedAt:           w.UpdatedAt,
+	}
+}
+
+func (d *Delivery) ToDeliveryResponse() *DeliveryResponse {
+	return &DeliveryResponse{
+		ID:             d.ID,
+		WebhookId:      d.WebhookId,
+		EventId:        d.EventId,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		RedeliveryOf:   d.RedeliveryOf,
+		NextAttemptAt:  d.NextAttemptAt,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"github.com/hasanbakirci/doc-system/pkg/mongoClient"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// webhookRepository keeps all organizations in one collection, told apart by tenant_id.
+type webhookRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (w webhookRepository) Create(ctx context.Context, webhook *Webhook) (string, error) {
+	if _, err := w.collection.InsertOne(ctx, webhook); err != nil {
+		return "", err
+	}
+	return webhook.ID, nil
+}
+
+func (w webhookRepository) Update(ctx context.Context, webhook *Webhook) (bool, error) {
+	scoped, err := w.collections.Filter(ctx, bson.M{"_id": webhook.ID})
+	if err != nil {
+		return false, err
+	}
+	result, err := w.collection.ReplaceOne(ctx, scoped, webhook)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (w webhookRepository) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := w.collections.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (w webhookRepository) GetAll(ctx context.Context) ([]Webhook, error) {
+	return mongoClient.FindAll[Webhook](ctx, w.collections, bson.M{})
+}
+
+func (w webhookRepository) GetById(ctx context.Context, id string) (*Webhook, error) {
+	webhook := new(Webhook)
+	if err := w.collections.FindOne(ctx, bson.M{"_id": id}, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (w webhookRepository) GetActive(ctx context.Context) ([]Webhook, error) {
+	return mongoClient.FindAll[Webhook](ctx, w.collections, bson.M{"active": true})
+}
+
+func NewWebhookRepository(db *mongo.Database) Repository {
+	col := db.Collection("webhooks")
+	return &webhookRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "webhooks", false)}
+}
+
+type deliveryRepository struct {
+	collection  *mongo.Collection
+	collections mongoClient.TenantCollections
+}
+
+func (d deliveryRepository) Create(ctx context.Context, delivery *Delivery) (string, error) {
+	if _, err := d.collection.InsertOne(ctx, delivery); err != nil {
+		return "", err
+	}
+	return delivery.ID, nil
+}
+
+func (d deliveryRepository) Update(ctx context.Context, delivery *Delivery) (bool, error) {
+	result, err := d.collection.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (d deliveryRepository) GetById(ctx context.Context, id string) (*Delivery, error) {
+	delivery := new(Delivery)
+	if err := d.collections.FindOne(ctx, bson.M{"_id": id}, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (d deliveryRepository) GetAll(ctx context.Context, webhookId string, status string, limit int) ([]Delivery, error) {
+	filter := bson.M{"webhook_id": webhookId}
+	if status != "" {
+		filter["status"] = status
+	}
+	scoped, err := d.collections.Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	findOptions := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(int64(limit))
+	cursor, err := d.collection.Find(ctx, scoped, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]Delivery, 0)
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (d deliveryRepository) GetPending(ctx context.Context, due string, limit int) ([]Delivery, error) {
+	if !helpers.AllTenants(ctx) {
+		return nil, helpers.ErrAllTenantsRequired
+	}
+	findOptions := options.Find().SetSort(bson.M{"created_at": 1}).SetLimit(int64(limit))
+	cursor, err := d.collection.Find(ctx, bson.M{"status": Pending, "next_attempt_at": bson.M{"$lte": due}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]Delivery, 0)
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (d deliveryRepository) Claim(ctx context.Context, delivery *Delivery, until string) (bool, error) {
+	filter := bson.M{"_id": delivery.ID, "status": Pending, "next_attempt_at": delivery.NextAttemptAt}
+	result, err := d.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"next_attempt_at": until}})
+	if err != nil || result.MatchedCount == 0 {
+		return false, err
+	}
+	delivery.NextAttemptAt = until
+	return true, nil
+}
+
+func (d deliveryRepository) DeleteAllByWebhook(ctx context.Context, webhookId string) (int64, error) {
+	return d.collections.DeleteMany(ctx, bson.M{"webhook_id": webhookId})
+}
+
+func NewDeliveryRepository(db *mongo.Database) DeliveryRepository {
+	col := db.Collection("webhook_deliveries")
+	return &deliveryRepository{collection: col, collections: mongoClient.NewTenantCollections(db, "webhook_deliveries", false)}
+}
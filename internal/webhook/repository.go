@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, webhook *Webhook) (string, error)
+	Update(ctx context.Context, webhook *Webhook) (bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	GetAll(ctx context.Context) ([]Webhook, error)
+	GetById(ctx context.Context, id string) (*Webhook, error)
+	// GetActive returns the enabled webhooks of the context's organization.
+	GetActive(ctx context.Context) ([]Webhook, error)
+}
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, delivery *Delivery) (string, error)
+	Update(ctx context.Context, delivery *Delivery) (bool, error)
+	GetById(ctx context.Context, id string) (*Delivery, error)
+	// GetAll returns the deliveries of the webhook, newest first. An empty status
+	// does not filter.
+	GetAll(ctx context.Context, webhookId string, status string, limit int) ([]Delivery, error)
+	// GetPending returns up to limit pending deliveries of all organizations whose
+	// next attempt is due, oldest first.
+	GetPending(ctx context.Context, due string, limit int) ([]Delivery, error)
+	// Claim postpones the next attempt of a pending delivery to until, unless another
+	// dispatcher changed the delivery since it was read, and reports whether it did.
+	Claim(ctx context.Context, delivery *Delivery, until string) (bool, error)
+	DeleteAllByWebhook(ctx context.Context, webhookId string) (int64, error)
+}
@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/events"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Create(ctx context.Context, userId string, request CreateWebhookRequest) (*CreatedWebhookResponse, error)
+	Update(ctx context.Context, id string, request UpdateWebhookRequest) (bool, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	GetAll(ctx context.Context) ([]WebhookResponse, error)
+	GetById(ctx context.Context, id string) (*WebhookResponse, error)
+	GetDeliveries(ctx context.Context, id string, query DeliveryQuery) ([]DeliveryResponse, error)
+	Redeliver(ctx context.Context, id string, deliveryId string) (*DeliveryResponse, error)
+	Enqueue(ctx context.Context, event *events.Event) error
+}
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+var (
+	errWebhookNotFound  = appError.NotFound("webhook_not_found", "Service: webhook id not found")
+	errDeliveryNotFound = appError.NotFound("delivery_not_found", "Service: delivery id not found")
+	errInvalidUrl       = appError.Validation("invalid_webhook_url", "Service: webhook url must be an absolute http or https url")
+	errUnresolvableUrl  = appError.Validation("unresolvable_webhook_url", "Service: webhook url host can not be resolved")
+	errPrivateUrl       = appError.Validation("private_webhook_url", "Service: webhook url must not point to a loopback, link-local or private address")
+)
+
+type webhookService struct {
+	repository Repository
+	deliveries DeliveryRepository
+}
+
+func (w webhookService) Create(ctx context.Context, userId string, request CreateWebhookRequest) (*CreatedWebhookResponse, error) {
+	if err := checkTarget(ctx, request.Url); err != nil {
+		return nil, err
+	}
+	webhook, err := request.ToWebhook(userId)
+	if err != nil {
+		return nil, appError.Internal("webhook_secret_not_generated", "Service: failed to generate webhook secret").Wrap(err)
+	}
+	webhook.TenantId = helpers.Tenant(ctx)
+	if _, err := w.repository.Create(ctx, webhook); err != nil {
+		return nil, appError.Unavailable("webhook_not_created", "Service: failed to create webhook").Wrap(err)
+	}
+	return &CreatedWebhookResponse{WebhookResponse: *webhook.ToWebhookResponse(), Secret: webhook.Secret}, nil
+}
+
+func (w webhookService) Update(ctx context.Context, id string, request UpdateWebhookRequest) (bool, error) {
+	if err := checkTarget(ctx, request.Url); err != nil {
+		return false, err
+	}
+	webhook, err := w.repository.GetById(ctx, id)
+	if err != nil {
+		return false, errWebhookNotFound.Wrap(err)
+	}
+	webhook.Url = request.Url
+	webhook.EventTypes = request.EventTypes
+	webhook.Description = request.Description
+	if request.Active && !webhook.Active {
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledAt = ""
+		webhook.DisabledReason = ""
+	}
+	webhook.Active = request.Active
+	webhook.UpdatedAt = time.Now().Format(timeLayout)
+	if result, err := w.repository.Update(ctx, webhook); err != nil || !result {
+		return false, appError.Unavailable("webhook_not_updated", "Service: failed to update webhook").Wrap(err)
+	}
+	return true, nil
+}
+
+// Delete removes the webhook together with its delivery log.
+func (w webhookService) Delete(ctx context.Context, id string) (bool, error) {
+	if result, err := w.repository.Delete(ctx, id); err != nil || !result {
+		return false, errWebhookNotFound.Wrap(err)
+	}
+	if _, err := w.deliveries.DeleteAllByWebhook(ctx, id); err != nil {
+		log.Errorf("Service: failed to delete the deliveries of webhook %s: %v", id, err)
+	}
+	return true, nil
+}
+
+func (w webhookService) GetAll(ctx context.Context) ([]WebhookResponse, error) {
+	webhooks, err := w.repository.GetAll(ctx)
+	if err != nil {
+		return nil, appError.Unavailable("webhooks_unavailable", "Service: failed to read webhooks").Wrap(err)
+	}
+	responses := make([]WebhookResponse, 0)
+	for i := 0; i < len(webhooks); i++ {
+		responses = append(responses, *webhooks[i].ToWebhookResponse())
+	}
+	return responses, nil
+}
+
+func (w webhookService) GetById(ctx context.Context, id string) (*WebhookResponse, error) {
+	webhook, err := w.repository.GetById(ctx, id)
+	if err != nil {
+		return nil, errWebhookNotFound.Wrap(err)
+	}
+	return webhook.ToWebhookResponse(), nil
+}
+
+// GetDeliveries returns the delivery log of the webhook, newest first.
+func (w webhookService) GetDeliveries(ctx context.Context, id string, query DeliveryQuery) ([]DeliveryResponse, error) {
+	if _, err := w.repository.GetById(ctx, id); err != nil {
+		return nil, errWebhookNotFound.Wrap(err)
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	deliveries, err := w.deliveries.GetAll(ctx, id, query.Status, limit)
+	if err != nil {
+		return nil, appError.Unavailable("deliveries_unavailable", "Service: failed to read deliveries").Wrap(err)
+	}
+	responses := make([]DeliveryResponse, 0)
+	for i := 0; i < len(deliveries); i++ {
+		responses = append(responses, *deliveries[i].ToDeliveryResponse())
+	}
+	return responses, nil
+}
+
+// Redeliver queues the payload of a delivery again, whatever its outcome was. The
+// webhook has to be active, otherwise the delivery would fail right away.
+func (w webhookService) Redeliver(ctx context.Context, id string, deliveryId string) (*DeliveryResponse, error) {
+	webhook, err := w.repository.GetById(ctx, id)
+	if err != nil {
+		return nil, errWebhookNotFound.Wrap(err)
+	}
+	if !webhook.Active {
+		return nil, appError.Conflict("webhook_disabled", "Service: activate the webhook before redelivering")
+	}
+	delivery, err := w.deliveries.GetById(ctx, deliveryId)
+	if err != nil || delivery.WebhookId != webhook.ID {
+		return nil, errDeliveryNotFound.Wrap(err)
+	}
+	redelivery := delivery.Redelivery()
+	if _, err := w.deliveries.Create(ctx, redelivery); err != nil {
+		return nil, appError.Unavailable("delivery_not_created", "Service: failed to queue the redelivery").Wrap(err)
+	}
+	return redelivery.ToDeliveryResponse(), nil
+}
+
+// Enqueue queues the event for every active webhook of its organization that
+// subscribes to its type. An event the bus delivers again is not queued twice.
+func (w webhookService) Enqueue(ctx context.Context, event *events.Event) error {
+	ctx = helpers.WithTenant(ctx, event.TenantId)
+	webhooks, err := w.repository.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+	var payload []byte
+	for i := range webhooks {
+		if !webhooks[i].Accepts(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		delivery := NewDelivery(&webhooks[i], event.ID, event.Type, payload)
+		if _, err := w.deliveries.GetById(ctx, delivery.ID); err == nil {
+			continue
+		}
+		if _, err := w.deliveries.Create(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewWebhookService(repo Repository, deliveries DeliveryRepository) Service {
+	return &webhookService{repository: repo, deliveries: deliveries}
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// Webhooks are posted from inside the deployment, so their targets must not reach
+// loopback, link-local or private network hosts such as the cloud metadata service
+// or the elastic node. The target is checked when the webhook is saved and again
+// when the dispatcher dials, because its name may resolve differently later on.
+
+var errPrivateTarget = errors.New("webhook target is not a public address")
+
+// publicIP reports whether ip is a globally routable unicast address.
+func publicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
+// checkTarget parses raw and checks that every address its host resolves to is public.
+func checkTarget(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errInvalidUrl
+	}
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addresses) == 0 {
+		return errUnresolvableUrl.Wrap(err)
+	}
+	for _, address := range addresses {
+		if !publicIP(address.IP) {
+			return errPrivateUrl
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses once the name is resolved.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: %s", errPrivateTarget, host)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			if got := publicIP(net.ParseIP(test.ip)); got != test.want {
+				t.Fatalf("publicIP(%s) = %v, want %v", test.ip, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckTargetRejectsPrivateAddresses(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1:9200/_search",
+		"http://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"ftp://93.184.216.34/hook",
+		"/relative/hook",
+	} {
+		if err := checkTarget(context.Background(), raw); err == nil {
+			t.Fatalf("checkTarget(%q) accepted a non-public target", raw)
+		}
+	}
+	if err := checkTarget(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Fatalf("checkTarget() rejected a public target: %v", err)
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	if err := dialControl("tcp", "10.1.2.3:443", nil); err == nil {
+		t.Fatal("dialControl() allowed a private address")
+	}
+	if err := dialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Fatalf("dialControl() rejected a public address: %v", err)
+	}
+}
@@ -1,9 +1,15 @@
 package elasticclient
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func ConnectElastic() (*elasticsearch.Client, error) {
@@ -20,3 +26,43 @@ func ConnectElastic() (*elasticsearch.Client, error) {
 	fmt.Println(res)
 	return es, err
 }
+
+// EnsureIndex creates the index with its alias unless it already exists.
+func EnsureIndex(ctx context.Context, client *elasticsearch.Client, index, alias string) error {
+	exists, err := client.Indices.Exists([]string{index}, client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer exists.Body.Close()
+	if exists.StatusCode == 200 {
+		return nil
+	}
+
+	settings := map[string]interface{}{
+		"aliases": map[string]interface{}{
+			alias: map[string]interface{}{},
+		},
+		"settings": map[string]interface{}{
+			"number_of_shards":   3,
+			"number_of_replicas": 2,
+		},
+	}
+	dataBytes, err := json.Marshal(&settings)
+	if err != nil {
+		return err
+	}
+	req := esapi.IndicesCreateRequest{
+		Index: index,
+		Body:  bytes.NewReader(dataBytes),
+	}
+	res, err := req.Do(ctx, client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Wrap(errors.New(res.String()), "esClient.IndicesCreate error")
+	}
+	log.Infof("Elastic client: %s index created, %s alias added.", index, alias)
+	return nil
+}
@@ -6,11 +6,28 @@ type ElasticResponse[T any] struct {
 			Value int64 `json:"value"`
 		} `json:"total"`
 		Hits []struct {
-			Source T `json:"_source"`
+			Id          string `json:"_id"`
+			SeqNo       int    `json:"_seq_no"`
+			PrimaryTerm int    `json:"_primary_term"`
+			Source      T      `json:"_source"`
 		} `json:"hits"`
 	} `json:"hits"`
 }
+
+// Versioned is a search hit with the version it was read at, so that it can be
+// written back only if nobody changed it in between.
+type Versioned[T any] struct {
+	Id          string
+	SeqNo       int
+	PrimaryTerm int
+	Source      T
+}
+
 type ElasticResultResponse struct {
 	Deleted int64 `json:"deleted"`
 	Updated int64 `json:"updated"`
 }
+
+type ElasticCountResponse struct {
+	Count int64 `json:"count"`
+}
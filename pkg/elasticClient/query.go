@@ -0,0 +1,219 @@
+package elasticclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/pkg/errors"
+)
+
+// Index stores the document under id, creating the index with its alias first if needed.
+func Index(ctx context.Context, client *elasticsearch.Client, index, alias, id string, document interface{}) error {
+	if err := EnsureIndex(ctx, client, index, alias); err != nil {
+		return err
+	}
+	dataBytes, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	req := esapi.IndexRequest{Index: index, DocumentID: id, Body: bytes.NewReader(dataBytes), Refresh: "wait_for"}
+	res, err := req.Do(ctx, client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Wrap(errors.New(res.String()), "esClient.Index error")
+	}
+	return nil
+}
+
+// Search runs the query body and returns the sources of the hits. A missing index
+// yields no hits instead of an error.
+func Search[T any](ctx context.Context, client *elasticsearch.Client, index string, body map[string]interface{}) ([]T, error) {
+	hits, err := search[T](ctx, client, index, body)
+	if err != nil {
+		return nil, err
+	}
+	resList := make([]T, len(hits.Hits.Hits))
+	for i, source := range hits.Hits.Hits {
+		resList[i] = source.Source
+	}
+	return resList, nil
+}
+
+// SearchVersioned is Search for hits that are claimed with IndexIf.
+func SearchVersioned[T any](ctx context.Context, client *elasticsearch.Client, index string, body map[string]interface{}) ([]Versioned[T], error) {
+	body["seq_no_primary_term"] = true
+	hits, err := search[T](ctx, client, index, body)
+	if err != nil {
+		return nil, err
+	}
+	resList := make([]Versioned[T], len(hits.Hits.Hits))
+	for i, hit := range hits.Hits.Hits {
+		resList[i] = Versioned[T]{Id: hit.Id, SeqNo: hit.SeqNo, PrimaryTerm: hit.PrimaryTerm, Source: hit.Source}
+	}
+	return resList, nil
+}
+
+func search[T any](ctx context.Context, client *elasticsearch.Client, index string, body map[string]interface{}) (*ElasticResponse[T], error) {
+	dataBytes, err := json.Marshal(&body)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Search(
+		client.Search.WithContext(ctx),
+		client.Search.WithIndex(index),
+		client.Search.WithBody(bytes.NewReader(dataBytes)),
+		client.Search.WithIgnoreUnavailable(true),
+		client.Search.WithTimeout(5*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, errors.Wrap(errors.New(res.String()), "esClient.Search error")
+	}
+
+	hits := ElasticResponse[T]{}
+	if err := json.NewDecoder(res.Body).Decode(&hits); err != nil {
+		return nil, err
+	}
+	return &hits, nil
+}
+
+// IndexIf stores the document under id only if it is still at the version it was
+// read at, and reports false when another writer changed it first. Workers running
+// in several replicas use it to claim a document before acting on it.
+func IndexIf(ctx context.Context, client *elasticsearch.Client, index, id string, seqNo, primaryTerm int, document interface{}) (bool, error) {
+	dataBytes, err := json.Marshal(document)
+	if err != nil {
+		return false, err
+	}
+	req := esapi.IndexRequest{
+		Index:         index,
+		DocumentID:    id,
+		Body:          bytes.NewReader(dataBytes),
+		IfSeqNo:       &seqNo,
+		IfPrimaryTerm: &primaryTerm,
+		Refresh:       "wait_for",
+	}
+	res, err := req.Do(ctx, client)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+	if res.IsError() {
+		return false, errors.Wrap(errors.New(res.String()), "esClient.IndexIf error")
+	}
+	return true, nil
+}
+
+// Count returns how many documents match the query. A missing index counts none.
+func Count(ctx context.Context, client *elasticsearch.Client, index string, query map[string]interface{}) (int64, error) {
+	dataBytes, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.Count(
+		client.Count.WithContext(ctx),
+		client.Count.WithIndex(index),
+		client.Count.WithBody(bytes.NewReader(dataBytes)),
+		client.Count.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return 0, errors.Wrap(errors.New(res.String()), "esClient.Count error")
+	}
+
+	counted := ElasticCountResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&counted); err != nil {
+		return 0, err
+	}
+	return counted.Count, nil
+}
+
+// UpdateByQuery overwrites the given top level fields on every matching document
+// and returns how many were updated. A missing index updates nothing.
+func UpdateByQuery(ctx context.Context, client *elasticsearch.Client, index string, query, fields map[string]interface{}) (int64, error) {
+	updateRequest := map[string]interface{}{
+		"script": map[string]interface{}{
+			"source": "for (entry in params.fields.entrySet()) { ctx._source[entry.getKey()] = entry.getValue(); }",
+			"params": map[string]interface{}{"fields": fields},
+		},
+		"query": query,
+	}
+	dataBytes, err := json.Marshal(&updateRequest)
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.UpdateByQuery([]string{index},
+		client.UpdateByQuery.WithBody(bytes.NewReader(dataBytes)),
+		client.UpdateByQuery.WithContext(ctx),
+		client.UpdateByQuery.WithRefresh(true),
+		client.UpdateByQuery.WithConflicts("proceed"),
+		client.UpdateByQuery.WithIgnoreUnavailable(true),
+		client.UpdateByQuery.WithTimeout(5*time.Second))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return 0, errors.Wrap(errors.New(res.String()), "esClient.UpdateByQuery error")
+	}
+
+	updated := ElasticResultResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&updated); err != nil {
+		return 0, err
+	}
+	return updated.Updated, nil
+}
+
+// DeleteByQuery removes every matching document and returns how many were deleted.
+func DeleteByQuery(ctx context.Context, client *elasticsearch.Client, index string, query map[string]interface{}) (int64, error) {
+	dataBytes, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.DeleteByQuery([]string{index}, bytes.NewReader(dataBytes),
+		client.DeleteByQuery.WithContext(ctx),
+		client.DeleteByQuery.WithRefresh(true),
+		client.DeleteByQuery.WithConflicts("proceed"),
+		client.DeleteByQuery.WithIgnoreUnavailable(true),
+		client.DeleteByQuery.WithTimeout(5*time.Second))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return 0, errors.Wrap(errors.New(res.String()), "esClient.DeleteByQuery error")
+	}
+
+	deleted := ElasticResultResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&deleted); err != nil {
+		return 0, err
+	}
+	return deleted.Deleted, nil
+}
+
+// Term builds a term query on the keyword sub-field of a text field.
+func Term(field string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{"term": map[string]interface{}{field + ".keyword": value}}
+}
+
+// Must combines queries that all have to match.
+func Must(queries ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{"must": queries}}
+}
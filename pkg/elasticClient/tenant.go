@@ -0,0 +1,69 @@
+package elasticclient
+
+import (
+	"context"
+
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+)
+
+// TenantScope keeps the documents of each tenant apart. All tenants share one index
+// and are told apart by their TenantId field, or with PerTenantIndex every tenant
+// gets its own index and alias named after the shared ones. The TenantId filter is
+// applied in both modes. Queries fail with helpers.ErrTenantMissing unless the
+// context is scoped to a tenant or marked with helpers.WithAllTenants.
+type TenantScope struct {
+	Index          string
+	Alias          string
+	PerTenantIndex bool
+}
+
+func NewTenantScope(index, alias string, perTenantIndex bool) TenantScope {
+	return TenantScope{Index: index, Alias: alias, PerTenantIndex: perTenantIndex}
+}
+
+// SearchIndex returns the index to query for the tenant of the context. Contexts
+// marked with helpers.WithAllTenants cover the indexes of all tenants.
+func (s TenantScope) SearchIndex(ctx context.Context) (string, error) {
+	tenantId, err := helpers.TenantScope(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !s.PerTenantIndex {
+		return s.Index, nil
+	}
+	if tenantId == "" {
+		return s.Index + "*", nil
+	}
+	return s.Index + "_" + tenantId, nil
+}
+
+// WriteIndex returns the index and alias a document of the tenant is stored in.
+func (s TenantScope) WriteIndex(tenantId string) (string, string) {
+	if !s.PerTenantIndex || tenantId == "" {
+		return s.Index, s.Alias
+	}
+	return s.Index + "_" + tenantId, s.Alias + "_" + tenantId
+}
+
+// Filter restricts the query to the tenant of the context.
+func (s TenantScope) Filter(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
+	tenantId, err := helpers.TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantId == "" {
+		return query, nil
+	}
+	return Must(query, Term("TenantId", tenantId)), nil
+}
+
+// Query returns the index to search and the query restricted to the tenant of the
+// context.
+func (s TenantScope) Query(ctx context.Context, query map[string]interface{}) (string, map[string]interface{}, error) {
+	index, err := s.SearchIndex(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	scoped, err := s.Filter(ctx, query)
+	return index, scoped, err
+}
@@ -1,9 +1,18 @@
 package errorHandler
 
-import "github.com/labstack/echo/v4"
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hasanbakirci/doc-system/pkg/appError"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
 
 type ErrorDetails struct {
 	StatusCode int         `json:"statusCode"`
+	Code       string      `json:"code,omitempty"`
 	Message    interface{} `json:"message"`
 }
 
@@ -13,11 +22,15 @@ type SuccessDetails struct {
 	Message    string      `json:"message"`
 }
 
-func Panic(statusCode int, message string) {
-	panic(ErrorDetails{
-		StatusCode: statusCode,
-		Message:    message,
-	})
+var kindStatus = map[appError.Kind]int{
+	appError.KindValidation:      http.StatusBadRequest,
+	appError.KindUnauthorized:    http.StatusUnauthorized,
+	appError.KindForbidden:       http.StatusForbidden,
+	appError.KindNotFound:        http.StatusNotFound,
+	appError.KindConflict:        http.StatusConflict,
+	appError.KindTooManyRequests: http.StatusTooManyRequests,
+	appError.KindUnavailable:     http.StatusServiceUnavailable,
+	appError.KindInternal:        http.StatusInternalServerError,
 }
 
 func Success(c echo.Context, statusCode int, data interface{}, message string) (err error) {
@@ -38,3 +51,114 @@ func Error(c echo.Context, statusCode int, error interface{}) (err error) {
 	err = c.JSON(statusCode, errorDetails)
 	return
 }
+
+// Problem is an RFC 7807 problem details object. Type identifies the error code
+// as a URI, Title is the status text and Detail the message of the error.
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	RequestId string                `json:"requestId,omitempty"`
+	Errors    []appError.FieldError `json:"errors,omitempty"`
+}
+
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+	problemTypePrefix          = "urn:doc-system:problem:"
+)
+
+// HTTPErrorHandler is installed as the echo error handler and writes every error
+// returned by a handler or middleware. Application errors keep their message and
+// code, echo's own errors are passed through and anything else is logged and
+// answered with a generic internal error. Errors are rendered as problem+json for
+// clients that ask for it; everyone else keeps getting the legacy ErrorDetails.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	problem := NewProblem(c, err)
+	if problem.Status >= http.StatusInternalServerError {
+		log.WithFields(log.Fields{"code": problem.Code, "path": c.Path(), "request_id": problem.RequestId}).Errorf("request failed: %v", err)
+	}
+
+	var writeErr error
+	switch {
+	case c.Request().Method == http.MethodHead:
+		writeErr = c.NoContent(problem.Status)
+	case wantsProblem(c.Request().Header.Get(echo.HeaderAccept)):
+		writeErr = writeProblem(c, problem)
+	default:
+		writeErr = c.JSON(problem.Status, ErrorDetails{StatusCode: problem.Status, Code: problem.Code, Message: problem.Detail})
+	}
+	if writeErr != nil {
+		log.Errorf("failed to write error response: %v", writeErr)
+	}
+}
+
+// NewProblem describes the error of the request as a problem details object.
+func NewProblem(c echo.Context, err error) *Problem {
+	statusCode, code, message := Describe(err)
+	problem := &Problem{
+		Type:     problemTypePrefix + code,
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   message,
+		Instance: c.Request().URL.Path,
+		Code:     code,
+	}
+	if requestId, ok := c.Get("request_id").(string); ok {
+		problem.RequestId = requestId
+	}
+	if appErr, ok := appError.As(err); ok {
+		problem.Errors = appErr.Fields
+	}
+	return problem
+}
+
+func writeProblem(c echo.Context, problem *Problem) error {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(problem.Status, MIMEApplicationProblemJSON, body)
+}
+
+// wantsProblem reports whether the client explicitly accepts application/problem+json.
+// A missing Accept header or a wildcard keeps the legacy envelope.
+func wantsProblem(accept string) bool {
+	return strings.Contains(accept, MIMEApplicationProblemJSON)
+}
+
+// Describe maps an error to its status code, stable error code and client message.
+func Describe(err error) (statusCode int, code string, message string) {
+	if appErr, ok := appError.As(err); ok {
+		statusCode, found := kindStatus[appErr.Kind]
+		if !found {
+			statusCode = http.StatusInternalServerError
+		}
+		return statusCode, appErr.Code, appErr.Message
+	}
+	if httpErr, ok := err.(*echo.HTTPError); ok {
+		return httpErr.Code, statusCodeName(httpErr.Code), messageOf(httpErr)
+	}
+	return http.StatusInternalServerError, string(appError.KindInternal), http.StatusText(http.StatusInternalServerError)
+}
+
+func messageOf(httpErr *echo.HTTPError) string {
+	if message, ok := httpErr.Message.(string); ok {
+		return message
+	}
+	return http.StatusText(httpErr.Code)
+}
+
+// statusCodeName turns a status code into a code like "method_not_allowed".
+func statusCodeName(statusCode int) string {
+	text := http.StatusText(statusCode)
+	if text == "" {
+		return "http_error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -17,6 +17,11 @@ func Shutdown(instance *echo.Echo, timeout time.Duration) {
 
 	<-stop
 
+	Stop(instance, timeout)
+}
+
+// Stop shuts the server down right away, for callers that caught the signal themselves.
+func Stop(instance *echo.Echo, timeout time.Duration) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
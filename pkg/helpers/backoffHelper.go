@@ -0,0 +1,15 @@
+package helpers
+
+import "time"
+
+// Backoff doubles the base delay with every attempt after the first, up to max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTenantMissing is returned by tenant scoped repositories for contexts that are
+// neither scoped to a tenant nor marked with WithAllTenants.
+var ErrTenantMissing = errors.New("the context is not scoped to an organization")
+
+// ErrAllTenantsRequired is returned by system tasks that span all organizations
+// for contexts not marked with WithAllTenants.
+var ErrAllTenantsRequired = errors.New("the context does not span all organizations")
+
+type impersonatorKey struct{}
+
+// WithImpersonator marks the request context as acting on behalf of the admin.
+func WithImpersonator(ctx context.Context, impersonatorId string) context.Context {
+	return context.WithValue(ctx, impersonatorKey{}, impersonatorId)
+}
+
+// Impersonator returns the admin impersonating the current user, or "".
+func Impersonator(ctx context.Context) string {
+	id, _ := ctx.Value(impersonatorKey{}).(string)
+	return id
+}
+
+type tenantKey struct{}
+
+type allTenantsKey struct{}
+
+// WithTenant scopes repository queries made with the context to the tenant.
+// Repositories refuse contexts without a tenant.
+func WithTenant(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantId)
+}
+
+// WithAllTenants lifts the tenant scope of the context, which only system tasks
+// such as login lookups by e-mail address or background jobs spanning all
+// organizations should do. A later WithTenant scopes the context again.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(WithTenant(ctx, ""), allTenantsKey{}, true)
+}
+
+// Tenant returns the tenant the context is scoped to, or "" when it is not scoped.
+func Tenant(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+// TenantScope returns the tenant of the context, or "" for contexts marked with
+// WithAllTenants. Contexts with neither fail with ErrTenantMissing.
+func TenantScope(ctx context.Context) (string, error) {
+	if tenantId := Tenant(ctx); tenantId != "" {
+		return tenantId, nil
+	}
+	if AllTenants(ctx) {
+		return "", nil
+	}
+	return "", ErrTenantMissing
+}
+
+// AllTenants reports whether the context was marked with WithAllTenants and not
+// scoped to a tenant again since.
+func AllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all && Tenant(ctx) == ""
+}
+
+type actorKey struct{}
+
+// WithActor records the authenticated user the request acts as.
+func WithActor(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userId)
+}
+
+// Actor returns the user the request acts as, or "" for anonymous requests.
+func Actor(ctx context.Context) string {
+	id, _ := ctx.Value(actorKey{}).(string)
+	return id
+}
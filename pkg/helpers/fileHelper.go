@@ -4,11 +4,11 @@ import (
 	"fmt"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/google/uuid"
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/labstack/gommon/log"
 	"io/ioutil"
 	"mime/multipart"
-	"net/http"
+	"os"
 	"time"
 )
 
@@ -20,23 +20,24 @@ type FileResponse struct {
 	MimeType   string
 }
 
-func AddFile(file *multipart.FileHeader, types ...string) *FileResponse {
+func AddFile(file *multipart.FileHeader, types ...string) (*FileResponse, error) {
 
 	src, err := file.Open()
 	if err != nil {
 		log.Error(err)
-		errorHandler.Panic(http.StatusBadRequest, err.Error())
+		return nil, appError.Validation("invalid_file", "The file could not be read").Wrap(err)
 	}
+	defer src.Close()
 
 	fileByte, err := ioutil.ReadAll(src)
 	if err != nil {
 		log.Error(err)
-		errorHandler.Panic(http.StatusBadRequest, err.Error())
+		return nil, appError.Validation("invalid_file", "The file could not be read").Wrap(err)
 	}
 
 	mt := mimetype.Detect(fileByte)
 	if !handleMimeType(types, mt.String()) {
-		errorHandler.Panic(http.StatusBadRequest, "wrong file format")
+		return nil, appError.Validation("wrong_file_format", "wrong file format")
 	}
 	fileRename := fmt.Sprintf(uuid.New().String() + "-" + time.Now().Format("2006-01-02-15-04-05"))
 	path := "upload/" + fileRename + mt.Extension()
@@ -44,18 +45,27 @@ func AddFile(file *multipart.FileHeader, types ...string) *FileResponse {
 	err = ioutil.WriteFile(path, fileByte, 0777)
 	if err != nil {
 		log.Error(err)
-		errorHandler.Panic(http.StatusBadRequest, err.Error())
+		return nil, appError.Unavailable("file_not_stored", "The file could not be stored").Wrap(err)
 	}
 
-	defer src.Close()
-
 	return &FileResponse{
 		FileName:   file.Filename,
 		FileRename: fileRename,
 		Path:       path,
 		Extension:  mt.Extension(),
 		MimeType:   mt.String(),
+	}, nil
+}
+
+// RemoveFile deletes an uploaded file. A file that does not exist is not an error.
+func RemoveFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
 	}
+	return nil
 }
 
 func handleMimeType(types []string, mime string) bool {
@@ -5,66 +5,101 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/hasanbakirci/doc-system/internal/config"
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/labstack/gommon/log"
-	"net/http"
 	"time"
 )
 
+const (
+	TwoFactorLoginPurpose = "2fa-login"
+	TwoFactorSetupPurpose = "2fa-setup"
+)
+
+// UserClaim is the payload of every token. Tokens an admin uses to act as the user
+// carry the admin's id as ImpersonatorId.
 type UserClaim struct {
-	ID   string `json:"id"`
-	Role string `json:"role"`
+	ID             string   `json:"id"`
+	Role           string   `json:"role"`
+	TenantId       string   `json:"tenant_id"`
+	Purpose        string   `json:"purpose,omitempty"`
+	Scopes         []string `json:"scopes,omitempty"`
+	ImpersonatorId string   `json:"impersonator_id,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateJwtToken(id, role string, cfg config.JwtSettings) string {
-	secretKey := []byte(cfg.SecretKey)
+// GenerateJwtToken issues a regular token bound to the login session, whose id is
+// carried in the jti claim.
+func GenerateJwtToken(id, role, tenantId, sessionId string, cfg config.JwtSettings) string {
+	claims := newUserClaim(id, role, tenantId, "", sessionId, time.Duration(cfg.SessionTime)*time.Hour)
+	return signJwtToken(claims, cfg)
+}
+
+// GenerateImpersonationToken issues a short-lived token acting as the user on behalf
+// of the admin, bound to the admin's own login session.
+func GenerateImpersonationToken(id, role, tenantId, impersonatorId, sessionId string, cfg config.JwtSettings) string {
+	claims := newUserClaim(id, role, tenantId, "", sessionId, time.Duration(cfg.ImpersonationTime)*time.Minute)
+	claims.ImpersonatorId = impersonatorId
+	return signJwtToken(claims, cfg)
+}
 
-	claims := UserClaim{
-		ID:   id,
-		Role: role,
+// GenerateScopedJwtToken issues a token limited to the given purpose, such as the
+// short-lived challenge handed out between the password and the 2fa step.
+func GenerateScopedJwtToken(id, role, tenantId, purpose string, ttl time.Duration, cfg config.JwtSettings) string {
+	return signJwtToken(newUserClaim(id, role, tenantId, purpose, "", ttl), cfg)
+}
+
+func newUserClaim(id, role, tenantId, purpose, sessionId string, ttl time.Duration) UserClaim {
+	return UserClaim{
+		ID:       id,
+		Role:     role,
+		TenantId: tenantId,
+		Purpose:  purpose,
 		StandardClaims: jwt.StandardClaims{
 			Audience:  "hasan@hasan.com",
-			ExpiresAt: time.Now().Add(time.Duration(cfg.SessionTime) * time.Hour).Unix(),
+			ExpiresAt: time.Now().Add(ttl).Unix(),
+			Id:        sessionId,
 			Issuer:    "hasan@hasan.com",
 		},
 	}
+}
+
+func signJwtToken(claims UserClaim, cfg config.JwtSettings) string {
+	secretKey := []byte(cfg.SecretKey)
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(secretKey)
 	if err != nil {
-		log.Error("Couldn't get signed token : %v", err)
-		errorHandler.Panic(http.StatusBadRequest, fmt.Sprintf("Couldn't get signed token : %v", err))
+		// signing with an hmac key only fails on programming errors
+		panic(fmt.Sprintf("Couldn't get signed token : %v", err))
 	}
 
 	log.Info("The token was successfully generated.")
 	return tokenString
 }
 
+// VerifyToken returns the claims of a valid token and nil for anything else.
 func VerifyToken(token string, secret string) *UserClaim {
 	secretKey := []byte(secret)
 
 	decodedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			//return nil, fmt.Errorf("There was an error in parsing")
-			errorHandler.Panic(http.StatusBadRequest, "There was an error in parsing")
+			return nil, fmt.Errorf("There was an error in parsing")
 		}
 		return secretKey, nil
 	})
 
 	if err != nil {
-		log.Error("Jwt token parse error : %v", err)
-		errorHandler.Panic(http.StatusUnauthorized, fmt.Sprintf("Jwt token parse error : %v", err))
+		log.Errorf("Jwt token parse error : %v", err)
+		return nil
 	}
 	if !decodedToken.Valid {
 		log.Error("Jwt token not valid ")
-		errorHandler.Panic(http.StatusUnauthorized, "Jwt token not valid")
+		return nil
 	}
 
 	claims, ok := decodedToken.Claims.(jwt.MapClaims)
 	if !ok {
 		log.Error("Claims not found")
-		errorHandler.Panic(http.StatusUnauthorized, "Claims not found")
+		return nil
 	}
 
 	userClaims := new(UserClaim)
@@ -0,0 +1,25 @@
+package helpers
+
+const (
+	DocumentsRead  = "documents:read"
+	DocumentsWrite = "documents:write"
+	UsersRead      = "users:read"
+)
+
+var rolePermissions = map[string][]string{
+	"user":  {DocumentsRead, DocumentsWrite},
+	"admin": {DocumentsRead, DocumentsWrite, UsersRead},
+}
+
+func RolePermissions(role string) []string {
+	return rolePermissions[role]
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
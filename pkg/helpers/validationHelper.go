@@ -1,9 +1,12 @@
 package helpers
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/labstack/echo/v4"
-	"net/http"
 )
 
 type CustomValidator struct {
@@ -12,16 +15,61 @@ type CustomValidator struct {
 
 func Validate(ctx echo.Context, request interface{}) (result interface{}, err error) {
 	if err = ctx.Bind(request); err != nil {
-		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return nil, appError.Validation("invalid_body", "Invalid request body").Wrap(err)
 	}
 	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
 	if err := v.Struct(request); err != nil {
-		// Optionally, you could return the error to give each route more control over the status code
+		validationErrors, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return nil, appError.Validation("invalid_body", "Invalid request body").Wrap(err)
+		}
+		// the description is kept for clients that still read the message
 		description := "Validation Error: "
-		for _, e := range err.(validator.ValidationErrors) {
+		fields := make([]appError.FieldError, 0, len(validationErrors))
+		for _, e := range validationErrors {
 			description += e.Field() + ": " + e.ActualTag() + "  "
+			fields = append(fields, appError.FieldError{
+				Field:   e.Field(),
+				Rule:    e.ActualTag(),
+				Param:   e.Param(),
+				Message: fieldMessage(e),
+			})
 		}
-		return nil, echo.NewHTTPError(http.StatusBadRequest, description)
+		return nil, appError.Validation("validation_failed", description).WithFields(fields)
 	}
 	return request, nil
 }
+
+// fieldName reports fields by the name the client sent them with.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			break
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func fieldMessage(e validator.FieldError) string {
+	switch e.ActualTag() {
+	case "required":
+		return e.Field() + " is required"
+	case "email":
+		return e.Field() + " must be a valid e-mail address"
+	case "min":
+		return e.Field() + " must be at least " + e.Param()
+	case "max":
+		return e.Field() + " must be at most " + e.Param()
+	case "oneof":
+		return e.Field() + " must be one of: " + e.Param()
+	}
+	if e.Param() != "" {
+		return e.Field() + " must satisfy " + e.ActualTag() + "=" + e.Param()
+	}
+	return e.Field() + " must satisfy " + e.ActualTag()
+}
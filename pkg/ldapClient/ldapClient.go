@@ -0,0 +1,153 @@
+package ldapClient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/hasanbakirci/doc-system/internal/config"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+	ErrNotFound           = errors.New("ldap: entry not found")
+)
+
+type Entry struct {
+	Dn       string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+// LdapClient opens a connection per operation, binding with the service account
+// for searches and with the user's own dn to check a password.
+type LdapClient struct {
+	settings config.LdapSettings
+}
+
+func NewLdapClient(settings config.LdapSettings) *LdapClient {
+	return &LdapClient{settings: settings}
+}
+
+func (c *LdapClient) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.settings.InsecureSkipVerify}
+	conn, err := ldap.DialURL(c.settings.Url, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(10 * time.Second)
+	if c.settings.StartTls {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := c.serviceBind(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *LdapClient) serviceBind(conn *ldap.Conn) error {
+	if c.settings.BindDn == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	return conn.Bind(c.settings.BindDn, c.settings.BindPassword)
+}
+
+// Authenticate finds the entry matching the login and binds as it with the password.
+func (c *LdapClient) Authenticate(login, password string) (*Entry, error) {
+	if password == "" {
+		// an empty password would turn into an unauthenticated bind that always succeeds
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	filter := fmt.Sprintf(c.settings.UserFilter, ldap.EscapeFilter(login))
+	found, err := c.search(conn, c.settings.BaseDn, ldap.ScopeWholeSubtree, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(found.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if err := c.serviceBind(conn); err != nil {
+		return nil, err
+	}
+	return c.toEntry(conn, found)
+}
+
+// Lookup reads the entry with the given dn, used to resync accounts without a password.
+func (c *LdapClient) Lookup(dn string) (*Entry, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	found, err := c.search(conn, dn, ldap.ScopeBaseObject, "(objectClass=*)")
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.toEntry(conn, found)
+}
+
+func (c *LdapClient) search(conn *ldap.Conn, baseDn string, scope int, filter string) (*ldap.Entry, error) {
+	request := ldap.NewSearchRequest(baseDn, scope, ldap.NeverDerefAliases, 2, 10, false, filter,
+		[]string{"dn", c.settings.UsernameAttribute, c.settings.EmailAttribute, "memberOf"}, nil)
+	result, err := conn.Search(request)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrNotFound
+	}
+	return result.Entries[0], nil
+}
+
+// toEntry maps the attributes and resolves the groups, either by searching the group
+// base for the user's dn or, without a group base, from the memberOf attribute.
+func (c *LdapClient) toEntry(conn *ldap.Conn, found *ldap.Entry) (*Entry, error) {
+	entry := &Entry{
+		Dn:       found.DN,
+		Username: found.GetAttributeValue(c.settings.UsernameAttribute),
+		Email:    found.GetAttributeValue(c.settings.EmailAttribute),
+	}
+	if c.settings.GroupBaseDn == "" {
+		for _, dn := range found.GetAttributeValues("memberOf") {
+			parsed, err := ldap.ParseDN(dn)
+			if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+				continue
+			}
+			entry.Groups = append(entry.Groups, parsed.RDNs[0].Attributes[0].Value)
+		}
+		return entry, nil
+	}
+
+	filter := fmt.Sprintf(c.settings.GroupFilter, ldap.EscapeFilter(found.DN))
+	request := ldap.NewSearchRequest(c.settings.GroupBaseDn, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 10, false, filter,
+		[]string{c.settings.GroupAttribute}, nil)
+	result, err := conn.Search(request)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range result.Entries {
+		entry.Groups = append(entry.Groups, group.GetAttributeValue(c.settings.GroupAttribute))
+	}
+	return entry, nil
+}
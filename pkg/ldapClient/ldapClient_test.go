@@ -0,0 +1,257 @@
+package ldapClient
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+const (
+	serviceDn       = "cn=svc,dc=example,dc=org"
+	servicePassword = "svc-secret"
+	janeDn          = "uid=jane,ou=people,dc=example,dc=org"
+	janePassword    = "jane-secret"
+)
+
+type testEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// directory is the content of the test server: a service account, one user and the
+// groups of that user, both as memberOf and as group entries.
+var directory = []testEntry{
+	{dn: "ou=people,dc=example,dc=org", attributes: map[string][]string{"ou": {"people"}}},
+	{dn: "ou=groups,dc=example,dc=org", attributes: map[string][]string{"ou": {"groups"}}},
+	{dn: serviceDn, password: servicePassword, attributes: map[string][]string{"cn": {"svc"}}},
+	{dn: janeDn, password: janePassword, attributes: map[string][]string{
+		"uid":      {"jane"},
+		"mail":     {"jane@example.org"},
+		"memberOf": {"cn=editors,ou=groups,dc=example,dc=org", "cn=staff,ou=groups,dc=example,dc=org"},
+	}},
+	{dn: "cn=editors,ou=groups,dc=example,dc=org", attributes: map[string][]string{"cn": {"editors"}, "member": {janeDn}}},
+	{dn: "cn=staff,ou=groups,dc=example,dc=org", attributes: map[string][]string{"cn": {"staff"}, "member": {janeDn}}},
+	{dn: "cn=admins,ou=groups,dc=example,dc=org", attributes: map[string][]string{"cn": {"admins"}, "member": {"uid=john,ou=people,dc=example,dc=org"}}},
+}
+
+// startServer runs a minimal LDAP server answering simple binds and equality or
+// presence searches over the directory.
+func startServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return "ldap://" + listener.Addr().String()
+}
+
+func serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if dn == "" && password == "" {
+				code = ldap.LDAPResultSuccess
+			}
+			for _, entry := range directory {
+				if entry.dn == dn && entry.password != "" && entry.password == password {
+					code = ldap.LDAPResultSuccess
+				}
+			}
+			respond(conn, id, ldap.ApplicationBindResponse, code)
+		case ldap.ApplicationSearchRequest:
+			base := op.Children[0].Data.String()
+			scope := op.Children[1].Value.(int64)
+			found := false
+			for _, entry := range directory {
+				if entry.dn == base {
+					found = true
+				}
+				inScope := entry.dn == base || (scope == ldap.ScopeWholeSubtree && strings.HasSuffix(entry.dn, ","+base))
+				if inScope && matches(entry, op.Children[6]) {
+					sendEntry(conn, id, entry)
+				}
+			}
+			code := uint16(ldap.LDAPResultSuccess)
+			if !found {
+				code = ldap.LDAPResultNoSuchObject
+			}
+			respond(conn, id, ldap.ApplicationSearchResultDone, code)
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func matches(entry testEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterEqualityMatch:
+		attribute, value := filter.Children[0].Data.String(), filter.Children[1].Data.String()
+		for name, values := range entry.attributes {
+			if !strings.EqualFold(name, attribute) {
+				continue
+			}
+			for _, v := range values {
+				if strings.EqualFold(v, value) {
+					return true
+				}
+			}
+		}
+	case ldap.FilterPresent:
+		attribute := filter.Data.String()
+		if strings.EqualFold(attribute, "objectClass") {
+			return true
+		}
+		for name := range entry.attributes {
+			if strings.EqualFold(name, attribute) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func envelope(id int64, op *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	message.AppendChild(op)
+	return message
+}
+
+func respond(conn net.Conn, id int64, tag ber.Tag, code uint16) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	_, _ = conn.Write(envelope(id, op).Bytes())
+}
+
+func sendEntry(conn net.Conn, id int64, entry testEntry) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "Object Name"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "Value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	op.AppendChild(attributes)
+	_, _ = conn.Write(envelope(id, op).Bytes())
+}
+
+func testSettings(url string) config.LdapSettings {
+	return config.LdapSettings{
+		Url:               url,
+		BindDn:            serviceDn,
+		BindPassword:      servicePassword,
+		BaseDn:            "ou=people,dc=example,dc=org",
+		UserFilter:        "(uid=%s)",
+		UsernameAttribute: "uid",
+		EmailAttribute:    "mail",
+	}
+}
+
+func TestAuthenticateResolvesMemberOf(t *testing.T) {
+	client := NewLdapClient(testSettings(startServer(t)))
+
+	entry, err := client.Authenticate("jane", janePassword)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if entry.Dn != janeDn || entry.Username != "jane" || entry.Email != "jane@example.org" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if strings.Join(entry.Groups, ",") != "editors,staff" {
+		t.Fatalf("groups = %v, want [editors staff]", entry.Groups)
+	}
+}
+
+func TestAuthenticateSearchesGroupBase(t *testing.T) {
+	settings := testSettings(startServer(t))
+	settings.GroupBaseDn = "ou=groups,dc=example,dc=org"
+	settings.GroupFilter = "(member=%s)"
+	settings.GroupAttribute = "cn"
+	client := NewLdapClient(settings)
+
+	entry, err := client.Authenticate("jane", janePassword)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if strings.Join(entry.Groups, ",") != "editors,staff" {
+		t.Fatalf("groups = %v, want [editors staff]", entry.Groups)
+	}
+}
+
+func TestAuthenticateRejectsBadCredentials(t *testing.T) {
+	client := NewLdapClient(testSettings(startServer(t)))
+
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		want     error
+	}{
+		{"wrong password", "jane", "guess", ErrInvalidCredentials},
+		{"empty password", "jane", "", ErrInvalidCredentials},
+		{"unknown user", "john", "guess", ErrNotFound},
+		{"filter injection", "*", janePassword, ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := client.Authenticate(test.login, test.password); err != test.want {
+				t.Fatalf("Authenticate = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateFailsWithWrongServiceAccount(t *testing.T) {
+	settings := testSettings(startServer(t))
+	settings.BindPassword = "wrong"
+	if _, err := NewLdapClient(settings).Authenticate("jane", janePassword); err == nil {
+		t.Fatal("Authenticate succeeded without a valid service bind")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	client := NewLdapClient(testSettings(startServer(t)))
+
+	entry, err := client.Lookup(janeDn)
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if entry.Email != "jane@example.org" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if _, err := client.Lookup("uid=john,ou=people,dc=example,dc=org"); err != ErrNotFound {
+		t.Fatalf("Lookup of a missing entry = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// fileSender drops every message as an .eml file, handy for local development.
+type fileSender struct {
+	from      string
+	directory string
+}
+
+func (f fileSender) Send(ctx context.Context, message Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("2006-01-02-15-04-05"), uuid.New().String())
+	return os.WriteFile(filepath.Join(f.directory, name), message.Bytes(f.from), 0644)
+}
+
+func NewFileSender(from, directory string) (Sender, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	return &fileSender{from: from, directory: directory}, nil
+}
+
+type logSender struct {
+	from string
+}
+
+func (l logSender) Send(ctx context.Context, message Message) error {
+	log.WithFields(log.Fields{
+		"from":    l.from,
+		"to":      strings.Join(message.To, ", "),
+		"subject": message.Subject,
+	}).Info(message.Body)
+	return nil
+}
+
+func NewLogSender(from string) Sender {
+	return &logSender{from: from}
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewSender picks the sender configured by Driver: "smtp", "file" or "log".
+func NewSender(settings config.MailSettings) (Sender, error) {
+	switch settings.Driver {
+	case "smtp":
+		return NewSmtpSender(settings), nil
+	case "file":
+		return NewFileSender(settings.From, settings.Directory)
+	case "log", "":
+		return NewLogSender(settings.From), nil
+	}
+	return nil, fmt.Errorf("mail: unknown driver %q", settings.Driver)
+}
+
+// Bytes renders the message as a plain text RFC 5322 mail.
+func (m Message) Bytes(from string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", sanitizeHeader(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", sanitizeHeader(strings.Join(m.To, ", ")))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", sanitizeHeader(m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
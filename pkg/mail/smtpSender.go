@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func (s smtpSender) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from, message.To, message.Bytes(s.from))
+}
+
+func NewSmtpSender(settings config.MailSettings) Sender {
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+	return &smtpSender{
+		addr: fmt.Sprintf("%s:%d", settings.Host, settings.Port),
+		auth: auth,
+		from: settings.From,
+	}
+}
@@ -0,0 +1,134 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hasanbakirci/doc-system/internal/config"
+)
+
+// received is what the sink recorded of one SMTP transaction.
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSink runs a minimal SMTP server that accepts one message and records it.
+func startSink(t *testing.T) (string, int, <-chan received) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan received, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		var message received
+		reply("220 sink ready")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimRight(line, "\r\n")
+			switch upper := strings.ToUpper(command); {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 sink")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				message.from = strings.Trim(command[len("MAIL FROM:"):], "<> ")
+				reply("250 ok")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				message.to = append(message.to, strings.Trim(command[len("RCPT TO:"):], "<> "))
+				reply("250 ok")
+			case upper == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				message.data = data.String()
+				reply("250 queued")
+			case upper == "QUIT":
+				reply("221 bye")
+				messages <- message
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	number, _ := strconv.Atoi(port)
+	return host, number, messages
+}
+
+func TestSmtpSenderDeliversToSink(t *testing.T) {
+	host, port, messages := startSink(t)
+	sender, err := NewSender(config.MailSettings{Driver: "smtp", Host: host, Port: port, From: "noreply@doc-system.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sender.Send(context.Background(), Message{
+		To:      []string{"jane@example.com"},
+		Subject: "Reset your password",
+		Body:    "first line\nsecond line",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	select {
+	case message := <-messages:
+		if message.from != "noreply@doc-system.local" {
+			t.Errorf("MAIL FROM = %q", message.from)
+		}
+		if len(message.to) != 1 || message.to[0] != "jane@example.com" {
+			t.Errorf("RCPT TO = %v", message.to)
+		}
+		for _, want := range []string{"Subject: Reset your password\r\n", "To: jane@example.com\r\n", "first line\r\nsecond line"} {
+			if !strings.Contains(message.data, want) {
+				t.Errorf("message does not contain %q:\n%s", want, message.data)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the sink received no message")
+	}
+}
+
+func TestSmtpSenderHonoursCancelledContext(t *testing.T) {
+	sender := NewSmtpSender(config.MailSettings{Host: "127.0.0.1", Port: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sender.Send(ctx, Message{To: []string{"jane@example.com"}}); err != context.Canceled {
+		t.Fatalf("Send = %v, want context.Canceled", err)
+	}
+}
+
+func TestMessageBytesStripsHeaderInjection(t *testing.T) {
+	message := Message{To: []string{"jane@example.com"}, Subject: "hello\r\nBcc: evil@example.com"}
+	if strings.Contains(string(message.Bytes("noreply@doc-system.local")), "\r\nBcc:") {
+		t.Fatal("a newline in the subject started a new header")
+	}
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"context"
+	"fmt"
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/hasanbakirci/doc-system/pkg/helpers"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
@@ -9,40 +11,210 @@ import (
 	"strings"
 )
 
-func TokenHandlerMiddlewareFunc(secret string, roles ...string) echo.MiddlewareFunc {
+type ApiKeyResolver interface {
+	ResolveApiKey(ctx context.Context, key string) (*helpers.UserClaim, error)
+}
+
+// SessionValidator reports whether the login session of a regular token is still active.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, claims *helpers.UserClaim) error
+}
+
+// ImpersonationAuditor records the changes an admin makes while impersonating a user.
+type ImpersonationAuditor interface {
+	RecordImpersonatedRequest(ctx context.Context, impersonatorId, userId, ipAddress, method, path string)
+}
+
+// AccountValidator reports whether the user's account may still be used.
+type AccountValidator interface {
+	ValidateAccount(ctx context.Context, userId string) error
+}
+
+type Authenticator struct {
+	secret   string
+	apiKeys  ApiKeyResolver
+	sessions SessionValidator
+	auditor  ImpersonationAuditor
+	accounts AccountValidator
+}
+
+func NewAuthenticator(secret string) *Authenticator {
+	return &Authenticator{secret: secret}
+}
+
+// UseApiKeys lets ScopedMiddlewareFunc accept X-API-Key headers resolved by the resolver.
+func (a *Authenticator) UseApiKeys(resolver ApiKeyResolver) {
+	a.apiKeys = resolver
+}
+
+// UseSessions makes regular tokens valid only while their login session exists.
+func (a *Authenticator) UseSessions(validator SessionValidator) {
+	a.sessions = validator
+}
+
+// UseImpersonationAuditor records every non-GET request made with an impersonation token.
+func (a *Authenticator) UseImpersonationAuditor(auditor ImpersonationAuditor) {
+	a.auditor = auditor
+}
+
+// UseAccounts rejects every kind of token and api key of deactivated users.
+func (a *Authenticator) UseAccounts(validator AccountValidator) {
+	a.accounts = validator
+}
+
+func (a *Authenticator) TokenHandlerMiddlewareFunc(roles ...string) echo.MiddlewareFunc {
+	return a.tokenMiddlewareFunc([]string{""}, roles, "")
+}
+
+// TokenPurposeMiddlewareFunc accepts tokens issued for one of the given purposes
+// regardless of role; an empty purpose stands for a regular session token.
+func (a *Authenticator) TokenPurposeMiddlewareFunc(purposes ...string) echo.MiddlewareFunc {
+	return a.tokenMiddlewareFunc(purposes, nil, "")
+}
+
+// ScopedMiddlewareFunc works like TokenHandlerMiddlewareFunc but also accepts api keys
+// whose scopes include the permission.
+func (a *Authenticator) ScopedMiddlewareFunc(permission string, roles ...string) echo.MiddlewareFunc {
+	return a.tokenMiddlewareFunc([]string{""}, roles, permission)
+}
+
+func (a *Authenticator) tokenMiddlewareFunc(purposes []string, roles []string, permission string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if key := c.Request().Header.Get("X-API-Key"); key != "" && permission != "" && a.apiKeys != nil {
+				claims, err := a.apiKeys.ResolveApiKey(c.Request().Context(), key)
+				if err != nil {
+					log.Error("The api key is not correct.")
+					return appError.Unauthorized("api_key_invalid", "The api key is not correct.")
+				}
+				if !contains(claims.Scopes, permission) {
+					log.Error("The api key does not have the required scope.")
+					return appError.Forbidden("api_key_scope_missing", "The api key does not have the required scope.")
+				}
+				return a.authorized(c, next, claims, roles)
+			}
 			if c.Request().Header.Get("Authorization") != "" {
 
 				token := strings.Split(c.Request().Header.Get("Authorization"), " ")
-				if token[0] != "Bearer" {
+				if token[0] != "Bearer" || len(token) < 2 {
 					log.Error("Authorization type is not Bearer.")
-					return errorHandler.Error(c, http.StatusUnauthorized, "Authorization type is not Bearer.")
+					return appError.Unauthorized("authorization_type_invalid", "Authorization type is not Bearer.")
 				}
-				claims := helpers.VerifyToken(token[1], secret)
+				claims := helpers.VerifyToken(token[1], a.secret)
 				if claims == nil {
 					log.Error("The token is not correct.")
-					return errorHandler.Error(c, http.StatusUnauthorized, "The token is not correct.")
+					return appError.Unauthorized("token_invalid", "The token is not correct.")
 				}
 
-				if !handleRoles(roles, claims.Role) {
-					log.Error("The user's role is not equal to the expected role.")
-					return errorHandler.Error(c, http.StatusForbidden, "The user's role is not equal to the expected role.")
+				if !contains(purposes, claims.Purpose) {
+					log.Error("The token was not issued for this request.")
+					return appError.Unauthorized("token_purpose_invalid", "The token was not issued for this request.")
 				}
-
-				c.Set("id", claims.ID)
-				log.Info("id field in context is set to : %s", claims.ID)
-				return next(c)
+				if claims.Purpose == "" && a.sessions != nil {
+					if err := a.sessions.ValidateSession(c.Request().Context(), claims); err != nil {
+						log.Error("The session was terminated.")
+						return appError.Unauthorized("session_terminated", "The session was terminated.")
+					}
+					c.Set("sid", claims.Id)
+				}
+				return a.authorized(c, next, claims, roles)
 			}
 			log.Error("Authorization header is empty.")
-			return errorHandler.Error(c, http.StatusUnauthorized, "Authorization header is empty.")
+			return appError.Unauthorized("authorization_missing", "Authorization header is empty.")
+		}
+	}
+}
+
+func (a *Authenticator) authorized(c echo.Context, next echo.HandlerFunc, claims *helpers.UserClaim, roles []string) error {
+	if roles != nil && !contains(roles, claims.Role) {
+		log.Error("The user's role is not equal to the expected role.")
+		return appError.Forbidden("role_not_allowed", "The user's role is not equal to the expected role.")
+	}
+	if claims.TenantId == "" {
+		log.Error("The token does not belong to an organization.")
+		return appError.Unauthorized("tenant_missing", "The token does not belong to an organization.")
+	}
+	if a.accounts != nil {
+		if err := a.accounts.ValidateAccount(c.Request().Context(), claims.ID); err != nil {
+			log.Errorf("The account can not be used: %v", err)
+			return appError.Unauthorized("account_deactivated", "The account is deactivated.")
+		}
+	}
+
+	c.Set("id", claims.ID)
+	c.Set("role", claims.Role)
+	c.Set("tenant", claims.TenantId)
+	ctx := helpers.WithTenant(c.Request().Context(), claims.TenantId)
+	c.SetRequest(c.Request().WithContext(helpers.WithActor(ctx, claims.ID)))
+	log.Infof("id field in context is set to : %s", claims.ID)
+	if claims.ImpersonatorId != "" {
+		a.impersonated(c, claims)
+	}
+	return next(c)
+}
+
+// impersonated marks the request so that handlers, logs and audit events can tell
+// the admin behind it apart from the user.
+func (a *Authenticator) impersonated(c echo.Context, claims *helpers.UserClaim) {
+	c.Set("impersonator", claims.ImpersonatorId)
+	ctx := helpers.WithImpersonator(c.Request().Context(), claims.ImpersonatorId)
+	c.SetRequest(c.Request().WithContext(ctx))
+	log.WithFields(log.Fields{
+		"impersonated_by": claims.ImpersonatorId,
+		"user_id":         claims.ID,
+		"method":          c.Request().Method,
+		"path":            c.Path(),
+	}).Warn("impersonated request")
+	if a.auditor != nil && c.Request().Method != http.MethodGet {
+		a.auditor.RecordImpersonatedRequest(ctx, claims.ImpersonatorId, claims.ID, c.RealIP(), c.Request().Method, c.Path())
+	}
+}
+
+// accessTokenParam carries the bearer token of clients that can not set headers.
+const accessTokenParam = "access_token"
+
+// QueryTokenMiddlewareFunc accepts the bearer token from the access_token query
+// parameter, since browsers can not set headers on EventSource and WebSocket
+// requests. It has to run before one of the token middlewares.
+func QueryTokenMiddlewareFunc(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if token := c.QueryParam(accessTokenParam); token != "" && c.Request().Header.Get("Authorization") == "" {
+			c.Request().Header.Set("Authorization", "Bearer "+token)
+		}
+		return next(c)
+	}
+}
+
+// NotImpersonatedMiddlewareFunc rejects privileged actions, such as changing
+// credentials or managing other accounts, on impersonation tokens. It has to run
+// after one of the token middlewares.
+func NotImpersonatedMiddlewareFunc(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get("impersonator") != nil {
+			log.Error("This action is not allowed while impersonating.")
+			return appError.Forbidden("impersonation_not_allowed", "This action is not allowed while impersonating.")
+		}
+		return next(c)
+	}
+}
+
+// SystemAdminMiddlewareFunc lets only the listed users through, for actions that
+// span all organizations. It has to run after one of the token middlewares.
+func SystemAdminMiddlewareFunc(systemAdmins []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !contains(systemAdmins, fmt.Sprintf("%v", c.Get("id"))) {
+				log.Error("Only system admins can do this.")
+				return appError.Forbidden("system_admin_required", "Only system admins can do this.")
+			}
+			return next(c)
 		}
 	}
 }
 
-func handleRoles(roles []string, role string) bool {
-	for _, r := range roles {
-		if r == role {
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
 			return true
 		}
 	}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/url"
+
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 )
@@ -8,11 +10,24 @@ import (
 func LoggingMiddlewareFunc(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		request := log.Fields{
-			"method": c.Request().Method,
-			"path":   c.Path(),
-			"url":    c.Request().RequestURI,
+			"method":     c.Request().Method,
+			"path":       c.Path(),
+			"url":        redactedUri(c.Request().URL),
+			"request_id": c.Get("request_id"),
 		}
 		log.WithFields(request).Info("request details")
 		return next(c)
 	}
 }
+
+// redactedUri keeps tokens passed in the query string out of the logs.
+func redactedUri(u *url.URL) string {
+	query := u.Query()
+	if query.Get(accessTokenParam) == "" {
+		return u.RequestURI()
+	}
+	query.Set(accessTokenParam, "REDACTED")
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.RequestURI()
+}
@@ -1,27 +1,22 @@
 package middleware
 
 import (
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/hasanbakirci/doc-system/pkg/appError"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 )
 
+// RecoveryMiddlewareFunc turns a panic, which only a bug should cause, into an
+// internal error for the error handler and logs it with its stack trace.
 func RecoveryMiddlewareFunc(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
+	return func(c echo.Context) (err error) {
 		defer func() {
-			//str := recover()
-			//c.JSON(http.StatusInternalServerError, str)
 			if r := recover(); r != nil {
-				switch t := r.(type) {
-				case errorHandler.ErrorDetails:
-					log.Error(r)
-					errorHandler.Error(c, t.StatusCode, t.Message)
-				default:
-					log.Warn(r)
-					errorHandler.Error(c, 500, r)
-				}
-				//c.JSON(http.StatusInternalServerError, err)
-				//errorHandler.ErrorResponse(c, 500, 5000, r)
+				log.WithField("stack", string(debug.Stack())).Errorf("panic: %v", r)
+				err = appError.Internal("internal", "Internal server error").Wrap(fmt.Errorf("panic: %v", r))
 			}
 		}()
 		return next(c)
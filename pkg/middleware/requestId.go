@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestIdMiddlewareFunc keeps the X-Request-ID sent by a proxy or generates one,
+// stores it as "request_id" and echoes it in the response so errors reported by
+// clients can be found in the logs.
+func RequestIdMiddlewareFunc(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestId := c.Request().Header.Get(echo.HeaderXRequestID)
+		if requestId == "" || len(requestId) > 128 {
+			requestId = uuid.New().String()
+		}
+		c.Set("request_id", requestId)
+		c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+		return next(c)
+	}
+}
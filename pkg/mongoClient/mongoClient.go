@@ -22,7 +22,8 @@ func ConnectDb(settings config.MongoSettings) (db *mongo.Database, err error) {
 		log.Errorf("Mongo: couldn't connect to mongo: %v", err)
 		return db, err
 	}
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 	err = client.Connect(ctx)
 	if err != nil {
 		log.Errorf("Mongo: mongo client couldn't connect with background context: %v", err)
@@ -32,7 +33,7 @@ func ConnectDb(settings config.MongoSettings) (db *mongo.Database, err error) {
 	// Check the connection
 	err = client.Ping(context.TODO(), nil)
 	if err != nil {
-		log.Errorf("Mongo: Client Ping error", err)
+		log.Errorf("Mongo: Client Ping error: %v", err)
 	}
 
 	db = client.Database(settings.DatabaseName)
@@ -0,0 +1,200 @@
+package mongoClient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hasanbakirci/doc-system/pkg/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TenantCollections keeps the documents of each tenant apart, either in one shared
+// collection told apart by tenant_id or, with perTenant, in a collection per tenant
+// named "<name>_<tenant>". The tenant_id filter is applied in both modes. Queries
+// fail with helpers.ErrTenantMissing unless the context is scoped to a tenant or
+// marked with helpers.WithAllTenants.
+type TenantCollections struct {
+	db        *mongo.Database
+	name      string
+	perTenant bool
+}
+
+func NewTenantCollections(db *mongo.Database, name string, perTenant bool) TenantCollections {
+	return TenantCollections{db: db, name: name, perTenant: perTenant}
+}
+
+// Collection returns the collection documents of the tenant are written to.
+func (t TenantCollections) Collection(tenantId string) *mongo.Collection {
+	if !t.perTenant || tenantId == "" {
+		return t.db.Collection(t.name)
+	}
+	return t.db.Collection(t.name + "_" + tenantId)
+}
+
+// Collections returns the collections to query for the tenant of the context.
+func (t TenantCollections) Collections(ctx context.Context) ([]*mongo.Collection, error) {
+	tenantId, err := helpers.TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !t.perTenant || tenantId != "" {
+		return []*mongo.Collection{t.Collection(tenantId)}, nil
+	}
+	names, err := t.db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	collections := make([]*mongo.Collection, 0)
+	for _, name := range names {
+		if name == t.name || strings.HasPrefix(name, t.name+"_") {
+			collections = append(collections, t.db.Collection(name))
+		}
+	}
+	return collections, nil
+}
+
+// Filter restricts the filter to the tenant of the context.
+func (t TenantCollections) Filter(ctx context.Context, filter bson.M) (bson.M, error) {
+	tenantId, err := helpers.TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scopeFilter(tenantId, filter), nil
+}
+
+// scoped is Filter for the helpers below, whose Collections call already failed
+// for contexts without a tenant.
+func (t TenantCollections) scoped(ctx context.Context, filter bson.M) bson.M {
+	return scopeFilter(helpers.Tenant(ctx), filter)
+}
+
+func scopeFilter(tenantId string, filter bson.M) bson.M {
+	if tenantId == "" {
+		return filter
+	}
+	scoped := bson.M{"tenant_id": tenantId}
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	return scoped
+}
+
+func (t TenantCollections) FindOne(ctx context.Context, filter bson.M, value interface{}) error {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		err := collection.FindOne(ctx, t.scoped(ctx, filter)).Decode(value)
+		if err != mongo.ErrNoDocuments {
+			return err
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (t TenantCollections) CountDocuments(ctx context.Context, filter bson.M) (int64, error) {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, collection := range collections {
+		count, err := collection.CountDocuments(ctx, t.scoped(ctx, filter))
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// UpdateOne updates the first matching document of the first collection holding one.
+func (t TenantCollections) UpdateOne(ctx context.Context, filter bson.M, update interface{}) (*mongo.UpdateResult, error) {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &mongo.UpdateResult{}
+	for _, collection := range collections {
+		result, err = collection.UpdateOne(ctx, t.scoped(ctx, filter), update)
+		if err != nil || result.MatchedCount > 0 {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// DeleteOne deletes the first matching document of the first collection holding one.
+func (t TenantCollections) DeleteOne(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error) {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &mongo.DeleteResult{}
+	for _, collection := range collections {
+		result, err = collection.DeleteOne(ctx, t.scoped(ctx, filter))
+		if err != nil || result.DeletedCount > 0 {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// UpdateMany updates the matching documents of every collection of the scope and
+// returns how many were modified.
+func (t TenantCollections) UpdateMany(ctx context.Context, filter bson.M, update interface{}) (int64, error) {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, collection := range collections {
+		result, err := collection.UpdateMany(ctx, t.scoped(ctx, filter), update)
+		if err != nil {
+			return total, err
+		}
+		total += result.ModifiedCount
+	}
+	return total, nil
+}
+
+// DeleteMany deletes the matching documents of every collection of the scope and
+// returns how many were deleted.
+func (t TenantCollections) DeleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, collection := range collections {
+		result, err := collection.DeleteMany(ctx, t.scoped(ctx, filter))
+		if err != nil {
+			return total, err
+		}
+		total += result.DeletedCount
+	}
+	return total, nil
+}
+
+// FindAll collects the matching documents of every collection of the scope.
+func FindAll[T any](ctx context.Context, t TenantCollections, filter bson.M) ([]T, error) {
+	collections, err := t.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, 0)
+	for _, collection := range collections {
+		cursor, err := collection.Find(ctx, t.scoped(ctx, filter))
+		if err != nil {
+			return nil, err
+		}
+		found := make([]T, 0)
+		if err = cursor.All(ctx, &found); err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+	return results, nil
+}
@@ -0,0 +1,233 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	clientId    = "doc-system"
+	redirectUrl = "http://localhost:8080/api/auth/oidc/callback"
+)
+
+// mockProvider is an OpenID Connect issuer that hands out one authorization code
+// per authorize request and checks the PKCE verifier when it is exchanged.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// claims overrides the claims of the next id token
+	claims func(claims jwt.MapClaims)
+	codes  map[string]authorization
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{t: t, key: key, codes: map[string]authorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(discovery{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JwksUri:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			Kid: "test-key",
+			Kty: "RSA",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != clientId {
+			http.Error(w, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		code := "code-" + query.Get("state")
+		m.codes[code] = authorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+		target := query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		auth, ok := m.codes[r.PostForm.Get("code")]
+		if !ok || CodeChallenge(r.PostForm.Get("code_verifier")) != auth.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		delete(m.codes, r.PostForm.Get("code"))
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", IdToken: m.idToken(auth.nonce), TokenType: "Bearer"})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) idToken(nonce string) string {
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   clientId,
+		"sub":   "user-1",
+		"email": "jane@example.com",
+		"nonce": nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	if m.claims != nil {
+		m.claims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return signed
+}
+
+// authorize follows the authorization url like a browser and returns the code and
+// state the provider redirected back with.
+func authorize(t *testing.T, authUrl string) (string, string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := client.Get(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize answered %s", res.Status)
+	}
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(location.String(), redirectUrl) {
+		t.Fatalf("redirected to %s", location)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// login runs the flow up to the token exchange and returns the id token.
+func login(t *testing.T, m *mockProvider, provider *Provider, nonce string) string {
+	t.Helper()
+	ctx := context.Background()
+	state, _ := RandomString()
+	verifier, _ := RandomString()
+	authUrl, err := provider.AuthCodeUrl(ctx, state, nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, returnedState := authorize(t, authUrl)
+	if returnedState != state {
+		t.Fatalf("state = %q, want %q", returnedState, state)
+	}
+	token, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	return token.IdToken
+}
+
+func newTestProvider(m *mockProvider) *Provider {
+	return NewProvider(Config{Issuer: m.server.URL, ClientId: clientId, RedirectUrl: redirectUrl, Scopes: []string{"email"}}, nil)
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	m := newMockProvider(t)
+	provider := newTestProvider(m)
+	nonce, _ := RandomString()
+
+	claims, err := provider.VerifyIdToken(context.Background(), login(t, m, provider, nonce), nonce)
+	if err != nil {
+		t.Fatalf("VerifyIdToken failed: %v", err)
+	}
+	if claims["sub"] != "user-1" || claims["email"] != "jane@example.com" {
+		t.Fatalf("unexpected claims %v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	provider := newTestProvider(m)
+	ctx := context.Background()
+	authUrl, err := provider.AuthCodeUrl(ctx, "state", "nonce", "the-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := authorize(t, authUrl)
+	if _, err := provider.Exchange(ctx, code, "another-verifier"); err == nil {
+		t.Fatal("Exchange accepted a code with the wrong PKCE verifier")
+	}
+}
+
+func TestVerifyIdTokenRejectsBadNonce(t *testing.T) {
+	m := newMockProvider(t)
+	provider := newTestProvider(m)
+	idToken := login(t, m, provider, "issued-nonce")
+	if _, err := provider.VerifyIdToken(context.Background(), idToken, "expected-nonce"); err == nil {
+		t.Fatal("VerifyIdToken accepted a token with another nonce")
+	}
+}
+
+func TestVerifyIdTokenRejectsForeignTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(claims jwt.MapClaims)
+	}{
+		{"audience", func(claims jwt.MapClaims) { claims["aud"] = "another-client" }},
+		{"authorized party", func(claims jwt.MapClaims) { claims["azp"] = "another-client" }},
+		{"issuer", func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }},
+		{"expired", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"no expiry", func(claims jwt.MapClaims) { delete(claims, "exp") }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			m.claims = test.claims
+			provider := newTestProvider(m)
+			if _, err := provider.VerifyIdToken(context.Background(), login(t, m, provider, "nonce"), "nonce"); err == nil {
+				t.Fatalf("VerifyIdToken accepted a token with a bad %s", test.name)
+			}
+		})
+	}
+}
+
+func TestVerifyIdTokenRejectsForeignSignature(t *testing.T) {
+	m := newMockProvider(t)
+	provider := newTestProvider(m)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.key = other
+	if _, err := provider.VerifyIdToken(context.Background(), m.idToken("nonce"), "nonce"); err == nil {
+		t.Fatal("VerifyIdToken accepted a token signed by another key")
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/go-redis/redis/v8"
-	"github.com/hasanbakirci/doc-system/pkg/errorHandler"
 	"github.com/labstack/gommon/log"
 	"time"
 )
 
+// errNil is reachable inside methods whose receiver shadows the redis package.
+var errNil = redis.Nil
+
+// KeepTTL passed as expiration overwrites a value without touching its lifetime.
+const KeepTTL = redis.KeepTTL
+
 type RedisClient struct {
 	redisClient *redis.Client
 }
@@ -17,18 +22,19 @@ func NewRedisClient(host string) *RedisClient {
 	client := redis.NewClient(&redis.Options{Addr: host})
 	_, err := client.Ping(context.TODO()).Result()
 	if err != nil {
-		errorHandler.Panic(400, err.Error())
+		log.Errorf("Redis connection error: %v", err)
+		panic(err)
 	}
 	log.Infof("Mongo:Connection Uri:%s", host)
 	return &RedisClient{redisClient: client}
 }
 
-func (redis RedisClient) Publish(channel string, message interface{}) {
-	body, _ := json.Marshal(message)
-	err := redis.redisClient.Publish(context.Background(), channel, body).Err()
+func (redis RedisClient) Publish(channel string, message interface{}) error {
+	body, err := json.Marshal(message)
 	if err != nil {
-		errorHandler.Panic(400, err.Error())
+		return err
 	}
+	return redis.redisClient.Publish(context.Background(), channel, body).Err()
 }
 
 func (redis RedisClient) Subscribe(channel string) *redis.PubSub {
@@ -44,11 +50,100 @@ func (redis RedisClient) Subscribe(channel string) *redis.PubSub {
 	return subs
 }
 
-func (redis RedisClient) Set(key string, value interface{}) {
+func (redis RedisClient) Set(key string, value interface{}) error {
 	v, err := json.Marshal(value)
 	if err != nil {
-		errorHandler.Panic(400, err.Error())
+		return err
 	}
 	//redis.redisClient.LPush(context.TODO(), key+"00", v)
-	redis.redisClient.Set(context.TODO(), key, v, 100*time.Second)
+	return redis.redisClient.Set(context.TODO(), key, v, 100*time.Second).Err()
+}
+
+// incrementScript bumps a counter and sets its expiration in one step, so a counter
+// never outlives its window when the client fails in between. Counters left
+// without an expiration are given one as well.
+var incrementScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count`)
+
+// Increment bumps a counter and starts its expiration when the counter is created.
+func (redis RedisClient) Increment(key string, expiration time.Duration) (int64, error) {
+	return incrementScript.Run(context.TODO(), redis.redisClient, []string{key}, expiration.Milliseconds()).Int64()
+}
+
+func (redis RedisClient) SetWithExpiration(key string, value interface{}, expiration time.Duration) error {
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return redis.redisClient.Set(context.TODO(), key, v, expiration).Err()
+}
+
+// SetIfAbsent stores the value only when the key does not exist yet and reports
+// whether it did.
+func (redis RedisClient) SetIfAbsent(key string, value interface{}, expiration time.Duration) (bool, error) {
+	v, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return redis.redisClient.SetNX(context.TODO(), key, v, expiration).Result()
+}
+
+// TTL returns the remaining lifetime of a key, zero when it does not exist.
+func (redis RedisClient) TTL(key string) (time.Duration, error) {
+	ttl, err := redis.redisClient.TTL(context.TODO(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (redis RedisClient) Delete(keys ...string) error {
+	return redis.redisClient.Del(context.TODO(), keys...).Err()
+}
+
+// GetAndDelete reads a key and removes it in one step, used for single-use tokens.
+func (redis RedisClient) GetAndDelete(key string, value interface{}) (bool, error) {
+	v, err := redis.redisClient.GetDel(context.TODO(), key).Bytes()
+	if err == errNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(v, value)
+}
+
+// Get decodes the value of a key, reporting false when it does not exist.
+func (redis RedisClient) Get(key string, value interface{}) (bool, error) {
+	v, err := redis.redisClient.Get(context.TODO(), key).Bytes()
+	if err == errNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(v, value)
+}
+
+func (redis RedisClient) AddToSet(key string, member string, expiration time.Duration) error {
+	ctx := context.TODO()
+	if err := redis.redisClient.SAdd(ctx, key, member).Err(); err != nil {
+		return err
+	}
+	return redis.redisClient.Expire(ctx, key, expiration).Err()
+}
+
+func (redis RedisClient) RemoveFromSet(key string, members ...string) error {
+	return redis.redisClient.SRem(context.TODO(), key, members).Err()
+}
+
+func (redis RedisClient) SetMembers(key string) ([]string, error) {
+	return redis.redisClient.SMembers(context.TODO(), key).Result()
 }
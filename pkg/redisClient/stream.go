@@ -0,0 +1,175 @@
+package redisClient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// StreamMessage is an entry read from a stream. Deliveries counts how often the
+// entry was handed to a consumer of the group, including this time.
+type StreamMessage struct {
+	ID         string
+	Payload    string
+	Deliveries int64
+}
+
+const payloadField = "payload"
+
+// the stream arguments are aliased since the method receivers shadow the redis package
+type (
+	xAddArgs        = redis.XAddArgs
+	xReadArgs       = redis.XReadArgs
+	xReadGroupArgs  = redis.XReadGroupArgs
+	xPendingExtArgs = redis.XPendingExtArgs
+	xClaimArgs      = redis.XClaimArgs
+)
+
+// AddToStream appends the message to the stream, creating it when missing. A
+// positive maxLen trims the stream to roughly that many entries.
+func (redis RedisClient) AddToStream(stream string, maxLen int64, message interface{}) (string, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+	args := &xAddArgs{Stream: stream, Values: map[string]interface{}{payloadField: body}}
+	if maxLen > 0 {
+		args.MaxLen = maxLen
+		args.Approx = true
+	}
+	return redis.redisClient.XAdd(context.TODO(), args).Result()
+}
+
+// CreateGroup creates the consumer group and the stream if they do not exist yet.
+// A new group starts at the beginning of the stream so no entry written before the
+// first consumer started is lost.
+func (redis RedisClient) CreateGroup(stream string, group string) error {
+	err := redis.redisClient.XGroupCreateMkStream(context.TODO(), stream, group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// ReadGroup returns up to count entries that were never delivered to the group,
+// waiting at most block for one to arrive. It returns no entries on timeout.
+func (redis RedisClient) ReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) ([]StreamMessage, error) {
+	streams, err := redis.redisClient.XReadGroup(ctx, &xReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == errNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]StreamMessage, 0)
+	for _, s := range streams {
+		for _, m := range s.Messages {
+			messages = append(messages, toStreamMessage(m, 1))
+		}
+	}
+	return messages, nil
+}
+
+// ReadStream returns up to count entries added after the given id ("$" for entries
+// added from now on) without a consumer group, waiting at most block for one to
+// arrive. It returns no entries on timeout.
+func (redis RedisClient) ReadStream(ctx context.Context, stream string, after string, count int64, block time.Duration) ([]StreamMessage, error) {
+	streams, err := redis.redisClient.XRead(ctx, &xReadArgs{
+		Streams: []string{stream, after},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if err == errNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]StreamMessage, 0)
+	for _, s := range streams {
+		for _, m := range s.Messages {
+			messages = append(messages, toStreamMessage(m, 0))
+		}
+	}
+	return messages, nil
+}
+
+// Ack removes handled entries from the pending list of the group.
+func (redis RedisClient) Ack(stream string, group string, ids ...string) error {
+	return redis.redisClient.XAck(context.TODO(), stream, group, ids...).Err()
+}
+
+// ClaimPending takes over up to count entries that another consumer, typically one
+// that crashed, read but did not acknowledge within minIdle.
+func (redis RedisClient) ClaimPending(ctx context.Context, stream string, group string, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error) {
+	pending, err := redis.redisClient.XPendingExt(ctx, &xPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make(map[string]int64)
+	ids := make([]string, 0)
+	for _, p := range pending {
+		if p.Idle >= minIdle {
+			deliveries[p.ID] = p.RetryCount
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	claimed, err := redis.redisClient.XClaim(ctx, &xClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]StreamMessage, 0, len(claimed))
+	for _, m := range claimed {
+		// claiming counts as another delivery
+		messages = append(messages, toStreamMessage(m, deliveries[m.ID]+1))
+	}
+	return messages, nil
+}
+
+func toStreamMessage(message redis.XMessage, deliveries int64) StreamMessage {
+	payload, _ := message.Values[payloadField].(string)
+	return StreamMessage{ID: message.ID, Payload: payload, Deliveries: deliveries}
+}
+
+// RangeStream returns up to count entries of the stream, oldest first, starting at
+// the given id ("-" for the beginning).
+func (redis RedisClient) RangeStream(stream string, start string, count int64) ([]StreamMessage, error) {
+	messages, err := redis.redisClient.XRangeN(context.TODO(), stream, start, "+", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]StreamMessage, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, toStreamMessage(m, 0))
+	}
+	return result, nil
+}
+
+// DeleteFromStream removes entries from the stream and reports how many existed.
+func (redis RedisClient) DeleteFromStream(stream string, ids ...string) (int64, error) {
+	return redis.redisClient.XDel(context.TODO(), stream, ids...).Result()
+}